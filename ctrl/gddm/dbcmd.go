@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/ctrl/utils"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/major"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Low level database operations",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Tools to look into and fix the chain database of a node that is not running,
+for instance after a crash left it with missing trie nodes or a broken head.
+Keys and values are given as 0x-prefixed hex, anything else is taken as the
+literal key.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "inspect",
+				Usage:  "Show the size of the database by kind of data",
+				Action: utils.MigrateFlags(inspectDB),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+				Description: `
+Walks the whole database and tallies the number and size of its entries by the
+kind of data their key says they hold.`,
+			},
+			{
+				Name:      "get",
+				Usage:     "Show the value stored under a key",
+				ArgsUsage: "<key>",
+				Action:    utils.MigrateFlags(dbGet),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+			},
+			{
+				Name:      "put",
+				Usage:     "Store a value under a key",
+				ArgsUsage: "<key> <value>",
+				Action:    utils.MigrateFlags(dbPut),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete the value stored under a key",
+				ArgsUsage: "<key>",
+				Action:    utils.MigrateFlags(dbDelete),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+			},
+			{
+				Name:      "check-state-root",
+				Usage:     "Check that a state is complete",
+				ArgsUsage: "[<root>]",
+				Action:    utils.MigrateFlags(checkStateRoot),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+Walks every trie node and contract code of the state with the given root, or of
+the head block if none is given, and reports the first one missing.`,
+			},
+			{
+				Name:   "repair-head",
+				Usage:  "Rewind the head to the latest block with complete state",
+				Action: utils.MigrateFlags(repairHead),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+Walks back from the head block to the most recent block whose header, body,
+receipts and state are all in the database, checking each candidate state in
+full, and rewrites the head markers and the canonical chain to end there. The
+blocks after it are synced again once the node is started.`,
+			},
+			{
+				Name:      "migrate",
+				Usage:     "Move the chain database to another storage engine",
+				ArgsUsage: "<engine>",
+				Action:    utils.MigrateFlags(migrateDB),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+				Description: `
+Copies every entry of the chain database into a new database of the given
+engine, checks that both hold exactly the same entries and then puts the new
+database in place of the old one. The old database is kept next to it with
+the name of its engine appended, and can be deleted once the node runs fine.
+An ancient store inside the chain database is moved over as well. The node
+must not be running.`,
+			},
+		},
+	}
+)
+
+func inspectDB(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	stats, err := core.InspectDatabase(db)
+	if err != nil {
+		utils.Fatalf("Failed to inspect database: %v", err)
+	}
+	var (
+		w     = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+		count uint64
+		size  common.StorageSize
+	)
+	fmt.Fprintln(w, "Category\tEntries\tSize\t")
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%v\t\n", stat.Category, stat.Count, stat.Size)
+		if !strings.HasPrefix(stat.Category, "Ancient") {
+			count += stat.Count
+		}
+		size += stat.Size
+	}
+	fmt.Fprintf(w, "Total\t%d\t%v\t\n", count, size)
+	return w.Flush()
+}
+
+// parseDBKey decodes a key or value given on the command line.
+func parseDBKey(arg string) []byte {
+	if !strings.HasPrefix(arg, "0x") {
+		return []byte(arg)
+	}
+	key, err := hexutil.Decode(arg)
+	if err != nil {
+		utils.Fatalf("Invalid hex %q: %v", arg, err)
+	}
+	return key
+}
+
+func dbGet(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a key as argument")
+	}
+	key := parseDBKey(ctx.Args().First())
+
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	value, err := db.Get(key)
+	if err != nil {
+		utils.Fatalf("Failed to read key %x: %v", key, err)
+	}
+	fmt.Println(hexutil.Encode(value))
+	return nil
+}
+
+func dbPut(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires a key and a value as arguments")
+	}
+	key, value := parseDBKey(ctx.Args().Get(0)), parseDBKey(ctx.Args().Get(1))
+
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	if old, err := db.Get(key); err == nil {
+		fmt.Printf("Previous value: %s\n", hexutil.Encode(old))
+	}
+	if err := db.Put(key, value); err != nil {
+		utils.Fatalf("Failed to write key %x: %v", key, err)
+	}
+	return nil
+}
+
+func dbDelete(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a key as argument")
+	}
+	key := parseDBKey(ctx.Args().First())
+
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	old, err := db.Get(key)
+	if err != nil {
+		utils.Fatalf("Failed to read key %x: %v", key, err)
+	}
+	if err := db.Delete(key); err != nil {
+		utils.Fatalf("Failed to delete key %x: %v", key, err)
+	}
+	fmt.Printf("Deleted value: %s\n", hexutil.Encode(old))
+	return nil
+}
+
+func checkStateRoot(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	var root common.Hash
+	switch len(ctx.Args()) {
+	case 0:
+		hash := core.GetHeadBlockHash(db)
+		header := core.GetHeader(db, hash, core.GetBlockNumber(db, hash))
+		if header == nil {
+			utils.Fatalf("Head block %x not found, pass a state root", hash)
+		}
+		root = header.Root
+		fmt.Printf("Checking state of head block #%d [%x…]\n", header.Number, hash[:4])
+	case 1:
+		enc, err := hexutil.Decode(ctx.Args().First())
+		if err != nil || len(enc) != common.HashLength {
+			utils.Fatalf("Invalid state root %q", ctx.Args().First())
+		}
+		root = common.BytesToHash(enc)
+	default:
+		utils.Fatalf("This command takes at most a state root as argument")
+	}
+	start := time.Now()
+	accounts, err := state.CheckState(state.NewDatabase(db), root)
+	if err != nil {
+		utils.Fatalf("State %x is incomplete: %v", root, err)
+	}
+	fmt.Printf("State %x is complete, %d accounts checked in %v\n", root, accounts, common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func repairHead(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	head, rewound, err := core.RepairHead(db)
+	if err != nil {
+		utils.Fatalf("Failed to repair head: %v", err)
+	}
+	fmt.Printf("Head set to block #%d [%x…], %d blocks rewound in %v\n", head.NumberU64(), head.Hash().Bytes()[:4], rewound, common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func migrateDB(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the target engine as argument")
+	}
+	target := ctx.Args().First()
+	if !ddmdb.IsEngine(target) {
+		utils.Fatalf("Unknown database engine %q", target)
+	}
+	stack, _ := makeConfigNode(ctx)
+
+	name := "chaindata"
+	if ctx.GlobalBool(utils.LightModeFlag.Name) {
+		name = "lightchaindata"
+	}
+	var (
+		dir     = stack.ResolvePath(name)
+		tmpdir  = dir + ".migrating"
+		source  = ddmdb.DetectEngine(dir)
+		backup  = dir + "." + source
+		cache   = ctx.GlobalInt(utils.CacheFlag.Name) / 2
+		handles = 256
+	)
+	switch {
+	case source == "":
+		utils.Fatalf("No database found in %s", dir)
+	case source == target:
+		utils.Fatalf("Database %s already uses %s", dir, target)
+	case common.FileExist(tmpdir):
+		utils.Fatalf("Directory %s left behind by an earlier migration, remove it first", tmpdir)
+	case common.FileExist(backup):
+		utils.Fatalf("Directory %s is in the way of the old database, remove it first", backup)
+	}
+	src, err := ddmdb.NewDiskDatabase(source, dir, cache, handles)
+	if err != nil {
+		utils.Fatalf("Failed to open database: %v", err)
+	}
+	dst, err := ddmdb.NewDiskDatabase(target, tmpdir, cache, handles)
+	if err != nil {
+		utils.Fatalf("Failed to create database: %v", err)
+	}
+	start := time.Now()
+	entries, size, err := copyDatabase(src, dst)
+	if err != nil {
+		utils.Fatalf("Failed to copy database: %v", err)
+	}
+	fmt.Printf("Copied %d entries (%v) in %v\n", entries, size, common.PrettyDuration(time.Since(start)))
+
+	start = time.Now()
+	if err := verifyDatabase(src, dst); err != nil {
+		utils.Fatalf("Copy verification failed: %v", err)
+	}
+	fmt.Printf("Verified copy in %v\n", common.PrettyDuration(time.Since(start)))
+
+	src.Close()
+	dst.Close()
+
+	if ancient := filepath.Join(dir, "ancient"); common.FileExist(ancient) {
+		if err := os.Rename(ancient, filepath.Join(tmpdir, "ancient")); err != nil {
+			utils.Fatalf("Failed to move ancient store: %v", err)
+		}
+	}
+	if err := os.Rename(dir, backup); err != nil {
+		utils.Fatalf("Failed to move old database aside: %v", err)
+	}
+	if err := os.Rename(tmpdir, dir); err != nil {
+		utils.Fatalf("Failed to move new database in place: %v", err)
+	}
+	fmt.Printf("Database %s now uses %s, the %s database was kept in %s\n", dir, target, source, backup)
+	return nil
+}
+
+// copyDatabase writes every entry of src into dst.
+func copyDatabase(src, dst ddmdb.Database) (int, common.StorageSize, error) {
+	var (
+		it      = src.NewIterator()
+		batch   = dst.NewBatch()
+		entries int
+		size    common.StorageSize
+		logged  = time.Now()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return entries, size, err
+		}
+		entries++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= ddmdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return entries, size, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Copying database", "entries", entries, "size", size, "key", fmt.Sprintf("%x", it.Key()))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return entries, size, err
+	}
+	return entries, size, batch.Write()
+}
+
+// verifyDatabase checks that both databases hold the same entries.
+func verifyDatabase(a, b ddmdb.Database) error {
+	ita, itb := a.NewIterator(), b.NewIterator()
+	defer ita.Release()
+	defer itb.Release()
+
+	for {
+		nexta, nextb := ita.Next(), itb.Next()
+		switch {
+		case !nexta && !nextb:
+			if err := ita.Error(); err != nil {
+				return err
+			}
+			return itb.Error()
+		case !nexta:
+			return fmt.Errorf("unexpected key %x in copy", itb.Key())
+		case !nextb:
+			return fmt.Errorf("key %x missing from copy", ita.Key())
+		case !bytes.Equal(ita.Key(), itb.Key()):
+			return fmt.Errorf("key mismatch: have %x, want %x", itb.Key(), ita.Key())
+		case !bytes.Equal(ita.Value(), itb.Value()):
+			return fmt.Errorf("value mismatch for key %x", ita.Key())
+		}
+	}
+}
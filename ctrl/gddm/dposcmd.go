@@ -0,0 +1,99 @@
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/ctrl/utils"
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major"
+	"github.com/ddmchain/go-ddmchain/rule/dpos"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dposCommand = cli.Command{
+		Name:     "dpos",
+		Usage:    "Manage the dpos voting snapshots",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The dpos voting snapshots are persisted at every checkpoint and pruned beyond
+the --dpos.snapshots retention window. These commands recompute them from the
+canonical headers.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "rebuild",
+				Usage:  "Recompute and store the checkpoint snapshots from headers",
+				Action: utils.MigrateFlags(rebuildSnapshots),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+					utils.DPosSnapshotsFlag,
+				},
+				Description: `
+Replays the canonical headers from genesis and rewrites every checkpoint
+snapshot, including the snapshot index. Snapshots outside the retention
+window are pruned.`,
+			},
+			{
+				Name:   "verify",
+				Usage:  "Check the stored checkpoint snapshots against headers",
+				Action: utils.MigrateFlags(verifySnapshots),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+				},
+				Description: `
+Replays the canonical headers from genesis and compares every retained
+checkpoint snapshot and its index entry with the recomputed one.`,
+			},
+		},
+	}
+)
+
+func makeDposChain(ctx *cli.Context) (*core.BlockChain, *dpos.Dpos, func()) {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+
+	engine, ok := chain.Engine().(*dpos.Dpos)
+	if !ok {
+		utils.Fatalf("Chain is not running the dpos consensus engine")
+	}
+	return chain, engine, func() {
+		chain.Stop()
+		chainDb.Close()
+	}
+}
+
+func rebuildSnapshots(ctx *cli.Context) error {
+	chain, engine, release := makeDposChain(ctx)
+	defer release()
+
+	start := time.Now()
+	stored, err := engine.RebuildSnapshots(chain)
+	if err != nil {
+		utils.Fatalf("Failed to rebuild snapshots: %v", err)
+	}
+	fmt.Printf("Rebuilt %d snapshots in %v\n", stored, common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func verifySnapshots(ctx *cli.Context) error {
+	chain, engine, release := makeDposChain(ctx)
+	defer release()
+
+	start := time.Now()
+	checked, missing, err := engine.VerifySnapshots(chain)
+	if err != nil {
+		utils.Fatalf("Snapshot verification failed: %v", err)
+	}
+	fmt.Printf("Verified %d snapshots (%d missing) in %v\n", checked, missing, common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -36,6 +36,8 @@ var (
 		utils.PasswordFileFlag,
 
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.DBEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
 		utils.DashboardEnabledFlag,
@@ -54,13 +56,23 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolOrderingFlag,
+		utils.TxPoolPriorityFlag,
+		utils.TxPoolBundleBlocksFlag,
 
 		utils.GCModeFlag,
+		utils.SnapshotFlag,
+		utils.StateHistoryFlag,
+		utils.SyncCheckpointFlag,
+		utils.SyncCheckpointSnapshotFlag,
 
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
 		utils.TrieCacheGenFlag,
+		utils.DPosSnapshotsFlag,
+		utils.DPosSignerFlag,
+		utils.DPosSignerTimeoutFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -73,6 +85,7 @@ var (
 		utils.NodeKeyFileFlag,
 
 		utils.VMEnableDebugFlag,
+		utils.VMParallelFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
@@ -111,6 +124,9 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		dposCommand,
+		snapshotCommand,
+		dbCommand,
 
 		monitorCommand,
 
@@ -0,0 +1,99 @@
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/ctrl/utils"
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	pruneRetainFlag = cli.Uint64Flag{
+		Name:  "retain",
+		Usage: "Number of recent blocks whose state is kept",
+		Value: 128,
+	}
+
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Manage the persisted state",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "prune-state",
+				Usage:  "Delete the state of all but the most recent blocks",
+				Action: utils.MigrateFlags(pruneState),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+					pruneRetainFlag,
+				},
+				Description: `
+Marks every trie node and contract code reachable from the state of the last
+--retain canonical blocks and from the genesis state, then deletes all other
+state entries from the database and compacts it. Only blocks whose state is
+available on disk are kept; the node must not be running.`,
+			},
+		},
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	var (
+		start  = time.Now()
+		head   = chain.CurrentBlock().NumberU64()
+		retain = ctx.Uint64(pruneRetainFlag.Name)
+		marker = state.NewStateMarker(state.NewDatabase(chainDb))
+		roots  []common.Hash
+	)
+	for number := head; number+retain > head; number-- {
+		block := chain.GetBlockByNumber(number)
+		if block != nil && chain.HasState(block.Root()) {
+			if err := marker.Mark(block.Root()); err != nil {
+				utils.Fatalf("Failed to mark state of block %d: %v", number, err)
+			}
+			roots = append(roots, block.Root())
+		}
+		if number == 0 {
+			break
+		}
+	}
+	if len(roots) == 0 {
+		utils.Fatalf("No state available for the last %d blocks", retain)
+	}
+	if genesis := chain.Genesis().Root(); chain.HasState(genesis) {
+		if err := marker.Mark(genesis); err != nil {
+			utils.Fatalf("Failed to mark genesis state: %v", err)
+		}
+	}
+	chain.Stop()
+	fmt.Printf("Marked %d state entries of %d blocks in %v\n", marker.Len(), len(roots), common.PrettyDuration(time.Since(start)))
+
+	start = time.Now()
+	deleted, size, err := marker.SweepDatabase(chainDb)
+	if err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	core.WriteFlushedStateRoots(chainDb, roots)
+	fmt.Printf("Deleted %d state entries (%v) in %v\n", deleted, size, common.PrettyDuration(time.Since(start)))
+
+	start = time.Now()
+	fmt.Println("Compacting entire database...")
+	if err := chainDb.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v\n", common.PrettyDuration(time.Since(start)))
+	return nil
+}
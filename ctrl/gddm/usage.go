@@ -49,11 +49,17 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			configFileFlag,
 			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
 			utils.NetworkIdFlag,
 
 			utils.GCModeFlag,
+			utils.SnapshotFlag,
+			utils.StateHistoryFlag,
+			utils.SyncCheckpointFlag,
+			utils.SyncCheckpointSnapshotFlag,
 			utils.DDMStatsURLFlag,
 			utils.IdentityFlag,
 
@@ -73,6 +79,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolOrderingFlag,
+			utils.TxPoolPriorityFlag,
+			utils.TxPoolBundleBlocksFlag,
 		},
 	},
 	{
@@ -82,6 +91,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
+			utils.DPosSnapshotsFlag,
 		},
 	},
 	{
@@ -89,6 +99,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
+			utils.DPosSignerFlag,
+			utils.DPosSignerTimeoutFlag,
 		},
 	},
 	{
@@ -133,6 +145,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMParallelFlag,
 		},
 	},
 	{
@@ -2,6 +2,7 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
@@ -94,6 +95,14 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for the immutable chain segments (default = inside chaindata)",
+	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database engine: " + strings.Join(ddmdb.Engines, ", ") + " (default = the one the database was created with, or leveldb)",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -143,14 +152,30 @@ var (
 	defaultSyncMode = ddm.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "snap", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
+	SyncCheckpointFlag = cli.StringFlag{
+		Name:  "sync.checkpoint",
+		Usage: "Trusted checkpoint to sync from, as <number>:<hash>:<td>[:<signer>,...]",
+	}
+	SyncCheckpointSnapshotFlag = cli.StringFlag{
+		Name:  "sync.checkpoint.snapshot",
+		Usage: "File with the dpos snapshot at the trusted checkpoint, as returned by dpos_getSnapshot",
+	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
-		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Usage: `Blockchain garbage collection mode ("full", "archive", "prune")`,
 		Value: "full",
 	}
+	SnapshotFlag = cli.BoolFlag{
+		Name:  "snapshot",
+		Usage: "Keep a flat snapshot of the state for faster state reads and iteration",
+	}
+	StateHistoryFlag = cli.BoolFlag{
+		Name:  "history.state",
+		Usage: "Record the state changes of every block to serve the state of past blocks",
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -270,6 +295,21 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ddm.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolOrderingFlag = cli.StringFlag{
+		Name:  "txpool.ordering",
+		Usage: "Policy deciding which transactions are evicted from a full pool (price, fifo, priority)",
+		Value: ddm.DefaultConfig.TxPool.Ordering,
+	}
+	TxPoolBundleBlocksFlag = cli.Uint64Flag{
+		Name:  "txpool.bundleblocks",
+		Usage: "Number of blocks a private transaction bundle stays eligible for inclusion",
+		Value: ddm.DefaultConfig.TxPool.BundleBlocks,
+	}
+	TxPoolPriorityFlag = cli.StringFlag{
+		Name:  "txpool.priority",
+		Usage: "Comma separated accounts with guaranteed pool slots under the priority ordering",
+		Value: "",
+	}
 
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -286,6 +326,20 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning",
 		Value: 25,
 	}
+	DPosSnapshotsFlag = cli.Uint64Flag{
+		Name:  "dpos.snapshots",
+		Usage: "Number of recent dpos checkpoint snapshots to keep on disk (0 = keep all)",
+		Value: ddm.DefaultConfig.DPosSnapshots,
+	}
+	DPosSignerFlag = cli.StringFlag{
+		Name:  "dpos.signer",
+		Usage: "External signer endpoint (IPC path or HTTP URL) sealing dpos blocks instead of a local account",
+	}
+	DPosSignerTimeoutFlag = cli.DurationFlag{
+		Name:  "dpos.signer.timeout",
+		Usage: "Maximum time to wait for the external dpos signer",
+		Value: ddm.DefaultConfig.DPosSignerTimeout,
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
@@ -336,6 +390,10 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMParallelFlag = cli.BoolFlag{
+		Name:  "vm.parallel",
+		Usage: "Execute the transactions of imported blocks in parallel",
+	}
 
 	DDMStatsURLFlag = cli.StringFlag{
 		Name:  "ddmstats",
@@ -835,6 +893,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "rinkeby")
 	}
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		cfg.DBEngine = ctx.GlobalString(DBEngineFlag.Name)
+		if !ddmdb.IsEngine(cfg.DBEngine) {
+			Fatalf("Option %q: unknown engine %q, want one of %s", DBEngineFlag.Name, cfg.DBEngine, strings.Join(ddmdb.Engines, ", "))
+		}
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -886,6 +950,21 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolOrderingFlag.Name) {
+		cfg.Ordering = ctx.GlobalString(TxPoolOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolBundleBlocksFlag.Name) {
+		cfg.BundleBlocks = ctx.GlobalUint64(TxPoolBundleBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPriorityFlag.Name) {
+		cfg.Priority = nil
+		for _, account := range splitAndTrim(ctx.GlobalString(TxPoolPriorityFlag.Name)) {
+			if !common.IsHexAddress(account) {
+				Fatalf("Invalid account in --%s: %s", TxPoolPriorityFlag.Name, account)
+			}
+			cfg.Priority = append(cfg.Priority, common.HexToAddress(account))
+		}
+	}
 }
 
 func setDDMhash(ctx *cli.Context, cfg *ddm.Config) {
@@ -909,6 +988,35 @@ func setDDMhash(ctx *cli.Context, cfg *ddm.Config) {
 	}
 }
 
+func parseSyncCheckpoint(spec string) *params.TrustedCheckpoint {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		Fatalf("--%s must be <number>:<hash>:<td>[:<signer>,...]", SyncCheckpointFlag.Name)
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		Fatalf("Invalid checkpoint number %q: %v", parts[0], err)
+	}
+	hash := common.FromHex(parts[1])
+	if len(hash) != common.HashLength {
+		Fatalf("Invalid checkpoint hash %q", parts[1])
+	}
+	td, ok := new(big.Int).SetString(parts[2], 10)
+	if !ok {
+		Fatalf("Invalid checkpoint total difficulty %q", parts[2])
+	}
+	cp := &params.TrustedCheckpoint{Number: number, Hash: common.BytesToHash(hash), Td: td}
+	if len(parts) == 4 {
+		for _, signer := range strings.Split(parts[3], ",") {
+			if !common.IsHexAddress(signer) {
+				Fatalf("Invalid checkpoint signer %q", signer)
+			}
+			cp.Signers = append(cp.Signers, common.HexToAddress(signer))
+		}
+	}
+	return cp
+}
+
 func checkExclusive(ctx *cli.Context, args ...interface{}) {
 	set := make([]string, 0, 1)
 	for i := 0; i < len(args); i++ {
@@ -974,6 +1082,19 @@ func SetDDMConfig(ctx *cli.Context, stack *node.Node, cfg *ddm.Config) {
 	case ctx.GlobalBool(LightModeFlag.Name):
 		cfg.SyncMode = downloader.LightSync
 	}
+	if ctx.GlobalIsSet(SyncCheckpointFlag.Name) {
+		cfg.SyncCheckpoint = parseSyncCheckpoint(ctx.GlobalString(SyncCheckpointFlag.Name))
+	}
+	if ctx.GlobalIsSet(SyncCheckpointSnapshotFlag.Name) {
+		if cfg.SyncCheckpoint == nil {
+			Fatalf("--%s needs --%s", SyncCheckpointSnapshotFlag.Name, SyncCheckpointFlag.Name)
+		}
+		blob, err := ioutil.ReadFile(ctx.GlobalString(SyncCheckpointSnapshotFlag.Name))
+		if err != nil {
+			Fatalf("Failed to read checkpoint snapshot: %v", err)
+		}
+		cfg.SyncCheckpoint.Snapshot = bytes.TrimSpace(blob)
+	}
 	if ctx.GlobalIsSet(LightServFlag.Name) {
 		cfg.LightServ = ctx.GlobalInt(LightServFlag.Name)
 	}
@@ -988,15 +1109,30 @@ func SetDDMConfig(ctx *cli.Context, stack *node.Node, cfg *ddm.Config) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
 
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "prune" {
+		Fatalf("--%s must be either 'full', 'archive' or 'prune'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.DiskPruning = ctx.GlobalString(GCModeFlag.Name) == "prune"
+	cfg.Snapshot = ctx.GlobalBool(SnapshotFlag.Name)
+	cfg.StateHistory = ctx.GlobalBool(StateHistoryFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(DPosSnapshotsFlag.Name) {
+		cfg.DPosSnapshots = ctx.GlobalUint64(DPosSnapshotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(DPosSignerFlag.Name) {
+		cfg.DPosSigner = ctx.GlobalString(DPosSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(DPosSignerTimeoutFlag.Name) {
+		cfg.DPosSignerTimeout = ctx.GlobalDuration(DPosSignerTimeoutFlag.Name)
+	}
 	if ctx.GlobalIsSet(MinerThreadsFlag.Name) {
 		cfg.MinerThreads = ctx.GlobalInt(MinerThreadsFlag.Name)
 	}
@@ -1013,6 +1149,7 @@ func SetDDMConfig(ctx *cli.Context, stack *node.Node, cfg *ddm.Config) {
 
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	cfg.ParallelTxs = ctx.GlobalBool(VMParallelFlag.Name)
 
 	switch {
 	case ctx.GlobalBool(TestnetFlag.Name):
@@ -1122,11 +1259,15 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ddmdb.Database {
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 		handles = makeDatabaseHandles()
 	)
-	name := "chaindata"
+	var (
+		chainDb ddmdb.Database
+		err     error
+	)
 	if ctx.GlobalBool(LightModeFlag.Name) {
-		name = "lightchaindata"
+		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles)
+	} else {
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", cache, handles, ctx.GlobalString(AncientFlag.Name))
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -1155,8 +1296,11 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Fatalf("%v", err)
 	}
 	var engine consensus.Engine
-	if config.Dpos != nil {
-		engine = dpos.New(config.Dpos, chainDb)
+	if config.DPos != nil {
+		engine = dpos.New(config.DPos, chainDb)
+		if ctx.GlobalIsSet(DPosSnapshotsFlag.Name) {
+			engine.(*dpos.Dpos).SetSnapshotRetention(ctx.GlobalUint64(DPosSnapshotsFlag.Name))
+		}
 	} else {
 		engine = ddmhash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1170,13 +1314,17 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 			})
 		}
 	}
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "prune" {
+		Fatalf("--%s must be either 'full', 'archive' or 'prune'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
+		Pruning:       ctx.GlobalString(GCModeFlag.Name) == "prune",
+		Snapshot:      ctx.GlobalBool(SnapshotFlag.Name),
+		History:       ctx.GlobalBool(StateHistoryFlag.Name),
 		TrieNodeLimit: ddm.DefaultConfig.TrieCache,
 		TrieTimeLimit: ddm.DefaultConfig.TrieTimeout,
+		ParallelTxs:   ctx.GlobalBool(VMParallelFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -14,7 +14,9 @@ import (
 	"github.com/ddmchain/go-ddmchain/general/hexutil"
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
 	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/major/vm"
 	"github.com/ddmchain/go-ddmchain/sign"
 	"github.com/ddmchain/go-ddmchain/pack"
 	"github.com/ddmchain/go-ddmchain/part"
@@ -258,6 +260,8 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	var block *types.Block
 	if blockNr == rpc.LatestBlockNumber {
 		block = api.ddm.blockchain.CurrentBlock()
+	} else if blockNr == rpc.FinalizedBlockNumber {
+		block = api.ddm.blockchain.CurrentFinalizedBlock()
 	} else {
 		block = api.ddm.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
@@ -310,9 +314,37 @@ func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common
 	if st == nil {
 		return StorageRangeResult{}, fmt.Errorf("account %x doesn't exist", contractAddress)
 	}
+	var start common.Hash
+	copy(start[:], keyStart)
+	if it, err := statedb.StorageIterator(contractAddress, start); err == nil {
+		defer it.Release()
+		return storageRangeAtSnapshot(st, it, maxResult)
+	}
 	return storageRangeAt(st, keyStart, maxResult)
 }
 
+func storageRangeAtSnapshot(st state.Trie, it snapshot.Iterator, maxResult int) (StorageRangeResult, error) {
+	result := StorageRangeResult{Storage: storageMap{}}
+	for i := 0; i < maxResult && it.Next(); i++ {
+		_, content, _, err := rlp.Split(it.Value())
+		if err != nil {
+			return StorageRangeResult{}, err
+		}
+		e := storageEntry{Value: common.BytesToHash(content)}
+		if preimage := st.GetKey(it.Hash().Bytes()); preimage != nil {
+			preimage := common.BytesToHash(preimage)
+			e.Key = &preimage
+		}
+		result.Storage[it.Hash()] = e
+	}
+
+	if it.Next() {
+		next := it.Hash()
+		result.NextKey = &next
+	}
+	return result, it.Error()
+}
+
 func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeResult, error) {
 	it := trie.NewIterator(st.NodeIterator(start))
 	result := StorageRangeResult{Storage: storageMap{}}
@@ -336,6 +368,184 @@ func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeRes
 	return result, nil
 }
 
+// AccountStateDiff is an account changed by a block, with its state before and
+// after it. A nil state means the account did not exist.
+type AccountStateDiff struct {
+	Address common.Address     `json:"address"`
+	Prev    *AccountState      `json:"prev"`
+	Post    *AccountState      `json:"post"`
+	Storage []StorageStateDiff `json:"storage"`
+}
+
+type AccountState struct {
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	Root     common.Hash    `json:"root"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// StorageStateDiff is a changed storage slot. Key is only known for slots
+// written by the block, slots cleared by a self-destruct carry their hash only.
+type StorageStateDiff struct {
+	Key  *common.Hash `json:"key"`
+	Hash common.Hash  `json:"hash"`
+	Prev common.Hash  `json:"prev"`
+	Post common.Hash  `json:"post"`
+}
+
+// GetStateDiff returns the accounts and storage slots changed by a block, as
+// recorded by a node keeping state history.
+func (api *PrivateDebugAPI) GetStateDiff(ctx context.Context, blockNr rpc.BlockNumber) ([]AccountStateDiff, error) {
+	var block *types.Block
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		return nil, fmt.Errorf("state diff of pending block not available")
+	case rpc.LatestBlockNumber:
+		block = api.ddm.blockchain.CurrentBlock()
+	default:
+		block = api.ddm.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	diff := core.GetStateDiff(api.ddm.ChainDb(), block.Hash(), block.NumberU64())
+	if diff == nil {
+		return nil, fmt.Errorf("state diff of block #%d not recorded", block.NumberU64())
+	}
+	result := make([]AccountStateDiff, 0, len(diff.Accounts))
+	for _, account := range diff.Accounts {
+		entry := AccountStateDiff{Address: account.Address, Storage: make([]StorageStateDiff, 0, len(account.Storage))}
+		var err error
+		if entry.Prev, err = decodeAccountState(account.Prev); err != nil {
+			return nil, err
+		}
+		if entry.Post, err = decodeAccountState(account.Post); err != nil {
+			return nil, err
+		}
+		for _, slot := range account.Storage {
+			s := StorageStateDiff{Hash: slot.Hash}
+			if len(slot.Key) > 0 {
+				key := common.BytesToHash(slot.Key)
+				s.Key = &key
+			}
+			if s.Prev, err = decodeStorageValue(slot.Prev); err != nil {
+				return nil, err
+			}
+			if s.Post, err = decodeStorageValue(slot.Post); err != nil {
+				return nil, err
+			}
+			entry.Storage = append(entry.Storage, s)
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func decodeAccountState(enc []byte) (*AccountState, error) {
+	if len(enc) == 0 {
+		return nil, nil
+	}
+	var data state.Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		return nil, err
+	}
+	return &AccountState{
+		Balance:  (*hexutil.Big)(data.Balance),
+		Nonce:    hexutil.Uint64(data.Nonce),
+		Root:     data.Root,
+		CodeHash: common.BytesToHash(data.CodeHash),
+	}, nil
+}
+
+func decodeStorageValue(enc []byte) (common.Hash, error) {
+	if len(enc) == 0 {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
+
+// BlockWitness lists what executing a block accessed, along with the trie nodes
+// and contract code needed to execute it again on the state root of its parent.
+type BlockWitness struct {
+	Root     common.Hash                      `json:"root"`
+	Accounts map[common.Address][]common.Hash `json:"accounts"`
+	Codes    []hexutil.Bytes                  `json:"codes"`
+	Nodes    []hexutil.Bytes                  `json:"nodes"`
+}
+
+// GetBlockWitness re-executes a block on the state of its parent, recording the
+// accounts, storage slots and code it accesses, and proves them.
+func (api *PrivateDebugAPI) GetBlockWitness(ctx context.Context, blockNr rpc.BlockNumber) (*BlockWitness, error) {
+	var block *types.Block
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		return nil, fmt.Errorf("witness of pending block not available")
+	case rpc.LatestBlockNumber:
+		block = api.ddm.blockchain.CurrentBlock()
+	default:
+		block = api.ddm.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	if block.NumberU64() == 0 {
+		return nil, fmt.Errorf("genesis is not executed")
+	}
+	parent := api.ddm.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, err := api.ddm.blockchain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	recorder := state.NewAccessRecorder()
+	statedb.SetAccessRecorder(recorder)
+
+	if _, _, _, err := api.ddm.blockchain.Processor().Process(block, statedb, vm.Config{}); err != nil {
+		return nil, err
+	}
+	if root := statedb.IntermediateRoot(api.config.IsEIP158(block.Number())); root != block.Root() {
+		return nil, fmt.Errorf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	witness, err := statedb.Witness()
+	if err != nil {
+		return nil, err
+	}
+	result := &BlockWitness{
+		Root:     witness.Root,
+		Accounts: make(map[common.Address][]common.Hash),
+		Codes:    make([]hexutil.Bytes, 0, len(witness.Codes)),
+		Nodes:    make([]hexutil.Bytes, 0, len(witness.Nodes)),
+	}
+	for _, addr := range recorder.Accounts() {
+		result.Accounts[addr] = recorder.Storage(addr)
+	}
+	for _, code := range witness.Codes {
+		result.Codes = append(result.Codes, code)
+	}
+	for _, node := range witness.Nodes {
+		result.Nodes = append(result.Nodes, node)
+	}
+	return result, nil
+}
+
+// TrustedCheckpoint returns the checkpoint the chain is allowed to be synced
+// from, if any.
+func (api *PrivateDebugAPI) TrustedCheckpoint() *params.TrustedCheckpoint {
+	return api.ddm.blockchain.TrustedCheckpoint()
+}
+
+// TrustedAnchor returns the header of the checkpoint the chain was synced from,
+// or nil if it was synced from genesis.
+func (api *PrivateDebugAPI) TrustedAnchor() *types.Header {
+	return api.ddm.blockchain.TrustedAnchor()
+}
+
 func (api *PrivateDebugAPI) GetModifiedAccountsByNumber(startNum uint64, endNum *uint64) ([]common.Address, error) {
 	var startBlock, endBlock *types.Block
 
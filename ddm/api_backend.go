@@ -49,6 +49,9 @@ func (b *DDMApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ddm.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.ddm.blockchain.CurrentFinalizedBlock().Header(), nil
+	}
 	return b.ddm.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -62,6 +65,9 @@ func (b *DDMApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ddm.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.ddm.blockchain.CurrentFinalizedBlock(), nil
+	}
 	return b.ddm.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
@@ -76,7 +82,7 @@ func (b *DDMApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	if header == nil || err != nil {
 		return nil, nil, err
 	}
-	stateDb, err := b.ddm.BlockChain().StateAt(header.Root)
+	stateDb, err := b.ddm.BlockChain().HistoricalState(header)
 	return stateDb, header, err
 }
 
@@ -124,6 +130,14 @@ func (b *DDMApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.ddm.txPool.AddLocal(signedTx)
 }
 
+func (b *DDMApiBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return b.ddm.txPool.AddPrivate(signedTx)
+}
+
+func (b *DDMApiBackend) SendBundle(ctx context.Context, signedTxs types.Transactions) error {
+	return b.ddm.txPool.AddBundle(signedTxs)
+}
+
 func (b *DDMApiBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending, err := b.ddm.txPool.Pending()
 	if err != nil {
@@ -131,7 +145,11 @@ func (b *DDMApiBackend) GetPoolTransactions() (types.Transactions, error) {
 	}
 	var txs types.Transactions
 	for _, batch := range pending {
-		txs = append(txs, batch...)
+		for _, tx := range batch {
+			if !b.ddm.txPool.Private(tx.Hash()) {
+				txs = append(txs, tx)
+			}
+		}
 	}
 	return txs, nil
 }
@@ -156,6 +174,10 @@ func (b *DDMApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.ddm.TxPool().SubscribeTxPreEvent(ch)
 }
 
+func (b *DDMApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return b.ddm.TxPool().SubscribeTxDropEvent(ch)
+}
+
 func (b *DDMApiBackend) Downloader() *downloader.Downloader {
 	return b.ddm.Downloader()
 }
@@ -168,6 +190,14 @@ func (b *DDMApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *DDMApiBackend) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gpo.SuggestTipCap(ctx)
+}
+
+func (b *DDMApiBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
 func (b *DDMApiBackend) ChainDb() ddmdb.Database {
 	return b.ddm.ChainDb()
 }
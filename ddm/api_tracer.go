@@ -71,6 +71,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.ddm.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.ddm.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.ddm.blockchain.CurrentFinalizedBlock()
 	default:
 		from = api.ddm.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -79,6 +81,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.ddm.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.ddm.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.ddm.blockchain.CurrentFinalizedBlock()
 	default:
 		to = api.ddm.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -157,7 +161,7 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 				signer := types.MakeSigner(api.config, task.block.Number())
 
 				for i, tx := range task.block.Transactions() {
-					msg, _ := tx.AsMessage(signer)
+					msg, _ := tx.AsMessage(signer, task.block.BaseFee())
 					vmctx := core.NewEVMContext(msg, task.block.Header(), api.ddm.blockchain, nil)
 
 					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
@@ -302,6 +306,8 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.ddm.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.ddm.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.ddm.blockchain.CurrentFinalizedBlock()
 	default:
 		block = api.ddm.blockchain.GetBlockByNumber(uint64(number))
 	}
@@ -373,7 +379,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 			defer pend.Done()
 
 			for task := range jobs {
-				msg, _ := txs[task.index].AsMessage(signer)
+				msg, _ := txs[task.index].AsMessage(signer, block.BaseFee())
 				vmctx := core.NewEVMContext(msg, block.Header(), api.ddm.blockchain, nil)
 
 				res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
@@ -391,7 +397,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 
 		jobs <- &txTraceTask{statedb: statedb.Copy(), index: i}
 
-		msg, _ := tx.AsMessage(signer)
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
 		vmctx := core.NewEVMContext(msg, block.Header(), api.ddm.blockchain, nil)
 
 		vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{})
@@ -413,7 +419,7 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 
 func (api *PrivateDebugAPI) computeStateDB(block *types.Block, reexec uint64) (*state.StateDB, error) {
 
-	statedb, err := api.ddm.blockchain.StateAt(block.Root())
+	statedb, err := api.ddm.blockchain.HistoricalState(block.Header())
 	if err == nil {
 		return statedb, nil
 	}
@@ -569,7 +575,7 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 
 	for idx, tx := range block.Transactions() {
 
-		msg, _ := tx.AsMessage(signer)
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
 		context := core.NewEVMContext(msg, block.Header(), api.ddm.blockchain, nil)
 		if idx == txIndex {
 			return msg, context, statedb, nil
@@ -58,6 +58,7 @@ type DDMchain struct {
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
 	accountManager *accounts.Manager
+	sealer         *dpos.RemoteSealer
 
 	bloomRequests chan chan *bloombits.Retrieval
 	bloomIndexer  *core.ChainIndexer
@@ -86,7 +87,7 @@ func New(ctx *node.ServiceContext, config *Config) (*DDMchain, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
-	chainDb, err := CreateDB(ctx, config, "chaindata")
+	chainDb, err := CreateChainDB(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +112,17 @@ func New(ctx *node.ServiceContext, config *Config) (*DDMchain, error) {
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
+	if engine, ok := ddm.engine.(*dpos.Dpos); ok {
+		if err := engine.UpgradeSnapshots(); err != nil {
+			return nil, err
+		}
+		engine.SetSnapshotRetention(config.DPosSnapshots)
+		if config.DPosSigner != "" {
+			if ddm.sealer, err = dpos.NewRemoteSealer(config.DPosSigner, config.DPosSignerTimeout, ctx.ResolvePath("dpos-hwm.json")); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	log.Info("Initialising DDMchain protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -123,7 +135,7 @@ func New(ctx *node.ServiceContext, config *Config) (*DDMchain, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, Pruning: config.DiskPruning, Snapshot: config.Snapshot, History: config.StateHistory, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, ParallelTxs: config.ParallelTxs}
 	)
 	ddm.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, ddm.chainConfig, ddm.engine, vmConfig)
 	if err != nil {
@@ -135,6 +147,11 @@ func New(ctx *node.ServiceContext, config *Config) (*DDMchain, error) {
 		ddm.blockchain.SetHead(compat.RewindTo)
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	checkpoint := config.SyncCheckpoint
+	if checkpoint == nil {
+		checkpoint = params.TrustedCheckpoints[genesisHash]
+	}
+	ddm.blockchain.SetTrustedCheckpoint(checkpoint)
 	ddm.bloomIndexer.Start(ddm.blockchain)
 
 	if config.TxPool.Journal != "" {
@@ -186,10 +203,23 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ddmdb.Data
 	return db, nil
 }
 
+// CreateChainDB opens the chain database of a full node, which moves the
+// immutable part of the chain into the configured freezer directory.
+func CreateChainDB(ctx *node.ServiceContext, config *Config) (ddmdb.Database, error) {
+	db, err := ctx.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer)
+	if err != nil {
+		return nil, err
+	}
+	if db, ok := ddmdb.LevelDB(db); ok {
+		db.Meter("ddm/db/chaindata/")
+	}
+	return db, nil
+}
+
 func CreateConsensusEngine(ctx *node.ServiceContext, config *ddmhash.Config, chainConfig *params.ChainConfig, db ddmdb.Database) consensus.Engine {
 
-	if chainConfig.Dpos != nil {
-		return dpos.New(chainConfig.Dpos, db)
+	if chainConfig.DPos != nil {
+		return dpos.New(chainConfig.DPos, db)
 	}
 
 	switch {
@@ -310,7 +340,9 @@ func (s *DDMchain) StartMining(local bool) error {
 		log.Error("Cannot start mining without ddmxbase", "err", err)
 		return fmt.Errorf("ddmxbase missing: %v", err)
 	}
-	if dpos, ok := s.engine.(*dpos.Dpos); ok {
+	if dpos, ok := s.engine.(*dpos.Dpos); ok && s.sealer != nil {
+		dpos.AuthorizeSealer(eb, s.sealer)
+	} else if ok {
 		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 		if wallet == nil || err != nil {
 			log.Error("DDMXbase account unavailable locally", "err", err)
@@ -381,6 +413,9 @@ func (s *DDMchain) Stop() error {
 	}
 	s.txPool.Stop()
 	s.miner.Stop()
+	if s.sealer != nil {
+		s.sealer.Close()
+	}
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
@@ -33,6 +33,9 @@ var DefaultConfig = Config{
 	TrieCache:     256,
 	TrieTimeout:   5 * time.Minute,
 
+	DPosSnapshots:     128,
+	DPosSignerTimeout: 5 * time.Second,
+
 	GasPrice:      big.NewInt(5 * params.Shannon),
 
 	TxPool: core.DefaultTxPoolConfig,
@@ -64,7 +67,11 @@ type Config struct {
 
 	NetworkId uint64 
 	SyncMode  downloader.SyncMode
+	SyncCheckpoint *params.TrustedCheckpoint `toml:",omitempty"`
 	NoPruning bool
+	DiskPruning bool
+	Snapshot  bool
+	StateHistory bool
 
 	LightServ  int `toml:",omitempty"` 
 	LightPeers int `toml:",omitempty"` 
@@ -72,6 +79,7 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string
 	TrieCache          int
 	TrieTimeout        time.Duration
 
@@ -82,12 +90,18 @@ type Config struct {
 
 	DDMhash ddmhash.Config
 
+	DPosSnapshots     uint64
+	DPosSigner        string        `toml:",omitempty"`
+	DPosSignerTimeout time.Duration `toml:",omitempty"`
+
 	TxPool core.TxPoolConfig
 
 	GPO gasprice.Config
 
 	EnablePreimageRecording bool
 
+	ParallelTxs bool
+
 	DocRoot string `toml:"-"`
 }
 
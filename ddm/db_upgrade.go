@@ -30,7 +30,7 @@ func upgradeDeduplicateData(db ddmdb.Database) func() error {
 
 	go func() {
 
-		it := db.(*ddmdb.LDBDatabase).NewIterator()
+		it := db.NewIterator()
 		defer func() {
 			if it != nil {
 				it.Release()
@@ -79,7 +79,7 @@ func upgradeDeduplicateData(db ddmdb.Database) func() error {
 			converted++
 			if converted%100000 == 0 {
 				it.Release()
-				it = db.(*ddmdb.LDBDatabase).NewIterator()
+				it = db.NewIterator()
 				it.Seek(key)
 
 				log.Info("Deduplicating database entries", "deduped", converted)
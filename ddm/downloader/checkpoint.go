@@ -0,0 +1,119 @@
+
+package downloader
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+const checkpointAncestors = 128
+
+var errCheckpointBehind = errors.New("peer not far enough past the trusted checkpoint")
+
+// findCheckpoint finds the block to sync on from. A fast or snap sync starting
+// from scratch anchors the chain at the trusted checkpoint instead of searching
+// for the common ancestor, and continues from the anchor until the chain passes
+// it.
+func (d *Downloader) findCheckpoint(p *peerConnection, height uint64) (uint64, error) {
+	if d.mode != FastSync && d.mode != SnapSync {
+		return d.findAncestor(p, height)
+	}
+	anchor := d.blockchain.TrustedAnchor()
+	if anchor == nil {
+		cp := d.blockchain.TrustedCheckpoint()
+		if cp == nil || d.lightchain.CurrentHeader().Number.Uint64() >= cp.Number || height <= cp.Number+uint64(fsMinFullBlocks) {
+			return d.findAncestor(p, height)
+		}
+		headers, err := d.fetchCheckpoint(p, cp)
+		if err != nil {
+			return 0, err
+		}
+		if err := d.blockchain.InsertCheckpoint(headers); err != nil {
+			p.log.Warn("Failed to anchor at trusted checkpoint", "number", cp.Number, "hash", cp.Hash, "err", err)
+			return 0, errInvalidChain
+		}
+		return cp.Number, nil
+	}
+	number := anchor.Number.Uint64()
+	if d.blockchain.CurrentFastBlock().NumberU64() >= number {
+		return d.findAncestor(p, height)
+	}
+	if height <= number+uint64(fsMinFullBlocks) {
+		return 0, errCheckpointBehind
+	}
+	headers, err := d.fetchHeadersAt(p, number, 1)
+	if err != nil {
+		return 0, err
+	}
+	if headers[0].Hash() != anchor.Hash() {
+		p.log.Debug("Peer doesn't have the trusted anchor", "number", number, "hash", headers[0].Hash(), "anchor", anchor.Hash())
+		return 0, errInvalidAncestor
+	}
+	p.log.Debug("Resuming sync from trusted anchor", "number", number, "hash", anchor.Hash())
+	return number, nil
+}
+
+// fetchCheckpoint retrieves the checkpoint header along with the ancestors the
+// consensus engine needs to verify the chain on from it.
+func (d *Downloader) fetchCheckpoint(p *peerConnection, cp *params.TrustedCheckpoint) ([]*types.Header, error) {
+	count := uint64(checkpointAncestors + 1)
+	if count > cp.Number+1 {
+		count = cp.Number + 1
+	}
+	p.log.Debug("Retrieving trusted checkpoint", "number", cp.Number, "hash", cp.Hash, "ancestors", count-1)
+
+	headers, err := d.fetchHeadersAt(p, cp.Number-count+1, int(count))
+	if err != nil {
+		return nil, err
+	}
+	if last := headers[len(headers)-1]; last.Hash() != cp.Hash {
+		p.log.Debug("Peer doesn't have the trusted checkpoint", "number", cp.Number, "hash", last.Hash(), "checkpoint", cp.Hash)
+		return nil, errInvalidAncestor
+	}
+	return headers, nil
+}
+
+func (d *Downloader) fetchHeadersAt(p *peerConnection, from uint64, count int) ([]*types.Header, error) {
+	go p.peer.RequestHeadersByNumber(from, count, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCancelHeaderFetch
+
+		case packet := <-d.headerCh:
+
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+
+			headers := packet.(*headerPack).headers
+			if len(headers) != count {
+				p.log.Debug("Invalid checkpoint header count", "requested", count, "received", len(headers))
+				return nil, errBadPeer
+			}
+			for i, header := range headers {
+				if header.Number.Uint64() != from+uint64(i) {
+					p.log.Warn("Checkpoint headers broke chain ordering", "index", i, "requested", from+uint64(i), "received", header.Number)
+					return nil, errInvalidChain
+				}
+			}
+			return headers, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for checkpoint headers timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+
+		}
+	}
+}
@@ -112,6 +112,10 @@ type Downloader struct {
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack 
 
+	snap          *snapSyncer
+	snapSyncStart chan *snapJob
+	snapCh        chan dataPack
+
 	cancelPeer string        
 	cancelCh   chan struct{} 
 	cancelLock sync.RWMutex  
@@ -156,6 +160,12 @@ type BlockChain interface {
 	InsertChain(types.Blocks) (int, error)
 
 	InsertReceiptChain(types.Blocks, []types.Receipts) (int, error)
+
+	TrustedCheckpoint() *params.TrustedCheckpoint
+
+	TrustedAnchor() *types.Header
+
+	InsertCheckpoint([]*types.Header) error
 }
 
 func New(mode SyncMode, stateDb ddmdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
@@ -184,9 +194,12 @@ func New(mode SyncMode, stateDb ddmdb.Database, mux *event.TypeMux, chain BlockC
 		stateCh:        make(chan dataPack),
 		stateSyncStart: make(chan *stateSync),
 		trackStateReq:  make(chan *stateReq),
+		snapSyncStart:  make(chan *snapJob),
+		snapCh:         make(chan dataPack),
 	}
 	go dl.qosTuner()
 	go dl.stateFetcher()
+	go dl.snapFetcher()
 	return dl
 }
 
@@ -199,7 +212,7 @@ func (d *Downloader) Progress() ddmchain.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
 	case LightSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
@@ -359,7 +372,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		return err
 	}
 	height := latest.Number.Uint64()
-	origin, err := d.findAncestor(p, height)
+	origin, err := d.findCheckpoint(p, height)
 	if err != nil {
 
 		return err
@@ -373,7 +386,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	d.syncStatsLock.Unlock()
 
 	pivot := uint64(0)
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		if height <= uint64(fsMinFullBlocks) {
 			origin = 0
 		} else {
@@ -384,7 +397,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		}
 	}
 	d.committed = 1
-	if d.mode == FastSync && pivot != 0 {
+	if (d.mode == FastSync || d.mode == SnapSync) && pivot != 0 {
 		d.committed = 0
 	}
 
@@ -398,7 +411,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		func() error { return d.fetchReceipts(origin + 1) },        
 		func() error { return d.processHeaders(origin+1, pivot, td) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -506,12 +519,17 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 
 	if d.mode == FullSync {
 		ceil = d.blockchain.CurrentBlock().NumberU64()
-	} else if d.mode == FastSync {
+	} else if d.mode == FastSync || d.mode == SnapSync {
 		ceil = d.blockchain.CurrentFastBlock().NumberU64()
 	}
 	if ceil >= MaxForkAncestry {
 		floor = int64(ceil - MaxForkAncestry)
 	}
+	if d.mode == FastSync || d.mode == SnapSync {
+		if anchor := d.blockchain.TrustedAnchor(); anchor != nil && int64(anchor.Number.Uint64()) > floor+1 {
+			floor = int64(anchor.Number.Uint64()) - 1
+		}
+	}
 	p.log.Debug("Looking for common ancestor", "local", ceil, "remote", height)
 
 	head := ceil
@@ -1059,7 +1077,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					}
 				}
 
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					head := d.lightchain.CurrentHeader()
 					if td.Cmp(d.lightchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
 						return errStallingPeer
@@ -1086,7 +1104,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				}
 				chunk := headers[:limit]
 
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1114,7 +1132,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					}
 				}
 
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode == FastSync || d.mode == SnapSync {
 
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1355,6 +1373,18 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+func (d *Downloader) DeliverAccountRange(id string, reqID uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &accountRangePack{id, reqID, hashes, accounts, proof}, snapInMeter, snapDropMeter)
+}
+
+func (d *Downloader) DeliverStorageRanges(id string, reqID uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &storageRangesPack{id, reqID, hashes, slots, proof}, snapInMeter, snapDropMeter)
+}
+
+func (d *Downloader) DeliverByteCodes(id string, reqID uint64, codes [][]byte) (err error) {
+	return d.deliver(id, d.snapCh, &byteCodesPack{id, reqID, codes}, snapInMeter, snapDropMeter)
+}
+
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 
 	inMeter.Mark(int64(packet.Items()))
@@ -23,4 +23,7 @@ var (
 
 	stateInMeter   = metrics.NewMeter("ddm/downloader/states/in")
 	stateDropMeter = metrics.NewMeter("ddm/downloader/states/drop")
+
+	snapInMeter   = metrics.NewMeter("ddm/downloader/snap/in")
+	snapDropMeter = metrics.NewMeter("ddm/downloader/snap/drop")
 )
@@ -69,6 +69,13 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// SnapPeer is a peer that serves ranges of the state, from ddm/65 on.
+type SnapPeer interface {
+	RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin []byte, bytes uint64) error
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+}
+
 type lightPeerWrapper struct {
 	peer LightPeer
 }
@@ -193,6 +200,16 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// snapPeer returns the peer as a SnapPeer if it speaks a version serving state
+// ranges.
+func (p *peerConnection) snapPeer() (SnapPeer, bool) {
+	if p.version < 65 {
+		return nil, false
+	}
+	peer, ok := p.peer.(SnapPeer)
+	return peer, ok
+}
+
 func (p *peerConnection) SetHeadersIdle(delivered int) {
 
 	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerIdle)
@@ -401,7 +418,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
@@ -413,7 +430,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
@@ -425,7 +442,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
@@ -437,7 +454,7 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 func (ps *peerSet) idlePeers(minProtocol, maxProtocol int, idleCheck func(*peerConnection) bool, throughput func(*peerConnection) float64) ([]*peerConnection, int) {
@@ -0,0 +1,833 @@
+
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"math/big"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/black/sha3"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+const (
+	snapAccountTasks    = 16
+	snapResponseBytes   = 512 * 1024
+	snapStorageAccounts = 128
+	snapMaxPending      = 4096
+)
+
+var (
+	emptyRoot     = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCodeHash = crypto.Keccak256(nil)
+)
+
+// snapJob asks the snap fetcher to download the state of a root by ranges.
+type snapJob struct {
+	root   common.Hash
+	cancel chan struct{}
+	done   chan error
+}
+
+// syncStateRanges downloads what the peers serve of the state of root by ranges,
+// leaving the rest for the trie sync to heal.
+func (d *Downloader) syncStateRanges(root common.Hash, cancel chan struct{}) error {
+	job := &snapJob{root: root, cancel: cancel, done: make(chan error, 1)}
+	select {
+	case d.snapSyncStart <- job:
+	case <-cancel:
+		return errCancelStateFetch
+	case <-d.quitCh:
+		return errCancelStateFetch
+	}
+	return <-job.done
+}
+
+func (d *Downloader) snapFetcher() {
+	for {
+		select {
+		case job := <-d.snapSyncStart:
+			if d.snap == nil {
+				d.snap = newSnapSyncer(d)
+			}
+			job.done <- d.snap.sync(job.root, job.cancel)
+
+		case <-d.snapCh:
+
+		case <-d.quitCh:
+			return
+		}
+	}
+}
+
+// accountTask is a slice of the account hash space. Its accounts are inserted
+// into its subtrie in order.
+type accountTask struct {
+	next   common.Hash
+	last   common.Hash
+	done   bool
+	failed map[string]struct{}
+
+	trie  *trieBuilder
+	queue []*snapAccount
+}
+
+// snapAccount is a downloaded account, possibly waiting for its storage or code.
+type snapAccount struct {
+	hash        common.Hash
+	task        *accountTask
+	blob        []byte
+	account     state.Account
+	needCode    bool
+	needStorage bool
+	abandoned   bool
+
+	storage *trieBuilder
+	origin  common.Hash
+	failed  map[string]struct{}
+}
+
+type snapRequestKind int
+
+const (
+	accountRequest snapRequestKind = iota
+	storageRequest
+	codeRequest
+)
+
+// snapRequest is a range request in flight, at most one per peer.
+type snapRequest struct {
+	id    uint64
+	kind  snapRequestKind
+	peer  *peerConnection
+	timer *time.Timer
+
+	task     *accountTask
+	accounts []common.Hash
+	origin   common.Hash
+	codes    []common.Hash
+}
+
+// snapSyncer downloads the state by proven ranges. Its progress survives pivot
+// moves, the differences are left for the healing.
+type snapSyncer struct {
+	d      *Downloader
+	root   common.Hash
+	keccak hash.Hash
+
+	tasks []*accountTask
+
+	pending      map[common.Hash]*snapAccount
+	storageQueue []common.Hash
+	codeQueue    []common.Hash
+	codeOwners   map[common.Hash][]common.Hash
+	codeFailed   map[common.Hash]map[string]struct{}
+
+	requests map[string]*snapRequest
+	nextID   uint64
+
+	accountsSynced uint64
+	slotsSynced    uint64
+	codesSynced    uint64
+	reported       uint64
+	logged         time.Time
+}
+
+func newSnapSyncer(d *Downloader) *snapSyncer {
+	s := &snapSyncer{
+		d:          d,
+		keccak:     sha3.NewKeccak256(),
+		pending:    make(map[common.Hash]*snapAccount),
+		codeOwners: make(map[common.Hash][]common.Hash),
+		codeFailed: make(map[common.Hash]map[string]struct{}),
+		requests:   make(map[string]*snapRequest),
+		logged:     time.Now(),
+	}
+	// Every task covers the subtrie under one nibble of the account trie
+	step := new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), big.NewInt(snapAccountTasks))
+	for i := 0; i < snapAccountTasks; i++ {
+		var (
+			next = common.BigToHash(new(big.Int).Mul(step, big.NewInt(int64(i))))
+			last = common.BigToHash(new(big.Int).Sub(new(big.Int).Mul(step, big.NewInt(int64(i+1))), common.Big1))
+		)
+		s.tasks = append(s.tasks, &accountTask{
+			next:   next,
+			last:   last,
+			failed: make(map[string]struct{}),
+			trie:   newTrieBuilder(d.stateDB, []byte{byte(i)}),
+		})
+	}
+	return s
+}
+
+func (s *snapSyncer) sync(root common.Hash, cancel chan struct{}) error {
+	if root != s.root {
+		if s.root != (common.Hash{}) {
+			log.Info("State root moved, continuing range sync", "old", s.root, "new", root, "abandoned", len(s.pending))
+		}
+		s.root = root
+		s.resetPending()
+	}
+	newPeer := make(chan *peerConnection, 1024)
+	newSub := s.d.peers.SubscribeNewPeers(newPeer)
+	defer newSub.Unsubscribe()
+
+	peerDrop := make(chan *peerConnection, 1024)
+	dropSub := s.d.peers.SubscribePeerDrops(peerDrop)
+	defer dropSub.Unsubscribe()
+
+	timeout := make(chan *snapRequest)
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		for _, req := range s.requests {
+			req.timer.Stop()
+			s.release(req, false)
+		}
+	}()
+
+	for {
+		if err := s.forward(); err != nil {
+			return err
+		}
+		s.assign(timeout, done)
+
+		if len(s.requests) == 0 {
+			return s.finish()
+		}
+		select {
+		case <-newPeer:
+
+		case p := <-peerDrop:
+			if req := s.requests[p.id]; req != nil {
+				req.timer.Stop()
+				s.release(req, false)
+			}
+
+		case req := <-timeout:
+			if s.requests[req.peer.id] == req {
+				req.peer.log.Debug("State range request timed out", "id", req.id)
+				s.release(req, true)
+			}
+
+		case pack := <-s.d.snapCh:
+			if err := s.process(pack); err != nil {
+				log.Warn("Invalid state range, dropping peer", "peer", pack.PeerId(), "err", err)
+				s.d.dropPeer(pack.PeerId())
+			}
+
+		case <-cancel:
+			return errCancelStateFetch
+
+		case <-s.d.cancelCh:
+			return errCancelStateFetch
+		}
+	}
+}
+
+// resetPending abandons the accounts waiting for storage or code when the root
+// changes, the account tasks go on from where they were.
+func (s *snapSyncer) resetPending() {
+	for _, account := range s.pending {
+		account.abandoned = true
+	}
+	s.pending = make(map[common.Hash]*snapAccount)
+	s.storageQueue, s.codeQueue = nil, nil
+	s.codeOwners = make(map[common.Hash][]common.Hash)
+	s.codeFailed = make(map[common.Hash]map[string]struct{})
+
+	for _, task := range s.tasks {
+		task.failed = make(map[string]struct{})
+	}
+}
+
+// finish writes out the account tries so far, the healing fills in the rest.
+func (s *snapSyncer) finish() error {
+	complete := len(s.pending) == 0
+	for _, task := range s.tasks {
+		if task.trie != nil {
+			if err := task.trie.flush(); err != nil {
+				return err
+			}
+		}
+		complete = complete && task.trie == nil
+	}
+	s.logProgress(true)
+
+	if complete {
+		log.Info("State ranges downloaded, healing the trie", "root", s.root)
+	} else {
+		log.Info("State ranges incomplete, healing the trie", "root", s.root, "pending", len(s.pending))
+	}
+	return nil
+}
+
+// forward inserts the queued accounts of every task up to the first incomplete
+// one, committing the trie of a task once done.
+func (s *snapSyncer) forward() error {
+	for _, task := range s.tasks {
+		for len(task.queue) > 0 {
+			account := task.queue[0]
+			if !account.abandoned {
+				if account.needCode || account.needStorage {
+					break
+				}
+				if err := task.trie.add(account.hash[:], account.blob); err != nil {
+					return err
+				}
+			}
+			task.queue[0] = nil
+			task.queue = task.queue[1:]
+		}
+		if task.done && len(task.queue) == 0 && task.trie != nil {
+			if _, err := task.trie.commit(); err != nil {
+				return err
+			}
+			task.trie = nil
+		}
+	}
+	return nil
+}
+
+// snapPeers returns the peers serving state ranges, and the idle ones among them.
+func (s *snapSyncer) snapPeers() (int, []*peerConnection) {
+	var (
+		count int
+		idle  []*peerConnection
+	)
+	for _, p := range s.d.peers.AllPeers() {
+		if _, ok := p.snapPeer(); !ok {
+			continue
+		}
+		count++
+		if s.requests[p.id] == nil {
+			idle = append(idle, p)
+		}
+	}
+	return count, idle
+}
+
+// assign hands out requests to the idle peers, codes first, then storage, then accounts.
+func (s *snapSyncer) assign(timeout chan *snapRequest, done chan struct{}) {
+	_, idle := s.snapPeers()
+	for _, p := range idle {
+		req := &snapRequest{peer: p}
+		if !s.fillCodes(req) && !s.fillStorage(req) && !s.fillAccounts(req) {
+			continue
+		}
+		s.nextID++
+		req.id = s.nextID
+
+		var (
+			peer, _ = p.snapPeer()
+			err     error
+		)
+		switch req.kind {
+		case accountRequest:
+			err = peer.RequestAccountRange(req.id, s.root, req.task.next, req.task.last, snapResponseBytes)
+		case storageRequest:
+			var origin []byte
+			if req.origin != (common.Hash{}) {
+				origin = req.origin[:]
+			}
+			err = peer.RequestStorageRanges(req.id, s.root, req.accounts, origin, snapResponseBytes)
+		case codeRequest:
+			err = peer.RequestByteCodes(req.id, req.codes, snapResponseBytes)
+		}
+		if err != nil {
+			p.log.Debug("Failed to request state range", "err", err)
+			s.release(req, true)
+			continue
+		}
+		req.timer = time.AfterFunc(s.d.requestTTL(), func() {
+			select {
+			case timeout <- req:
+			case <-done:
+			}
+		})
+		s.requests[p.id] = req
+	}
+}
+
+func (s *snapSyncer) fillCodes(req *snapRequest) bool {
+	for i := 0; i < len(s.codeQueue) && len(req.codes) < MaxStateFetch; {
+		hash := s.codeQueue[i]
+		if _, failed := s.codeFailed[hash][req.peer.id]; failed {
+			i++
+			continue
+		}
+		req.codes = append(req.codes, hash)
+		s.codeQueue = append(s.codeQueue[:i], s.codeQueue[i+1:]...)
+	}
+	req.kind = codeRequest
+	return len(req.codes) > 0
+}
+
+func (s *snapSyncer) fillStorage(req *snapRequest) bool {
+	for i := 0; i < len(s.storageQueue) && len(req.accounts) < snapStorageAccounts; {
+		var (
+			hash    = s.storageQueue[i]
+			account = s.pending[hash]
+		)
+		if _, failed := account.failed[req.peer.id]; failed {
+			i++
+			continue
+		}
+		// A storage continuing a previous response is requested on its own
+		if account.storage != nil {
+			if len(req.accounts) > 0 {
+				i++
+				continue
+			}
+			req.origin = account.origin
+		}
+		req.accounts = append(req.accounts, hash)
+		s.storageQueue = append(s.storageQueue[:i], s.storageQueue[i+1:]...)
+
+		if account.storage != nil {
+			break
+		}
+	}
+	req.kind = storageRequest
+	return len(req.accounts) > 0
+}
+
+func (s *snapSyncer) fillAccounts(req *snapRequest) bool {
+	if len(s.pending) >= snapMaxPending {
+		return false
+	}
+	for _, task := range s.tasks {
+		if task.done || len(task.queue) >= snapMaxPending || s.taskActive(task) {
+			continue
+		}
+		if _, failed := task.failed[req.peer.id]; failed {
+			continue
+		}
+		req.kind, req.task = accountRequest, task
+		return true
+	}
+	return false
+}
+
+func (s *snapSyncer) taskActive(task *accountTask) bool {
+	for _, req := range s.requests {
+		if req.task == task {
+			return true
+		}
+	}
+	return false
+}
+
+// release requeues the items of an unanswered request, abandoning those every
+// peer failed.
+func (s *snapSyncer) release(req *snapRequest, failed bool) {
+	if s.requests[req.peer.id] == req {
+		delete(s.requests, req.peer.id)
+	}
+	peers, _ := s.snapPeers()
+
+	switch req.kind {
+	case accountRequest:
+		if failed {
+			req.task.failed[req.peer.id] = struct{}{}
+			if len(req.task.failed) >= peers {
+				log.Debug("Abandoning account range", "next", req.task.next, "last", req.task.last)
+				req.task.done = true
+			}
+		}
+	case storageRequest:
+		for _, hash := range req.accounts {
+			account := s.pending[hash]
+			if account == nil {
+				continue
+			}
+			if failed {
+				account.failed[req.peer.id] = struct{}{}
+				if len(account.failed) >= peers {
+					log.Debug("Abandoning account storage", "account", hash)
+					s.abandon(hash)
+					continue
+				}
+			}
+			s.storageQueue = append(s.storageQueue, hash)
+		}
+	case codeRequest:
+		for _, hash := range req.codes {
+			if _, ok := s.codeOwners[hash]; !ok {
+				continue
+			}
+			if failed {
+				if s.codeFailed[hash] == nil {
+					s.codeFailed[hash] = make(map[string]struct{})
+				}
+				s.codeFailed[hash][req.peer.id] = struct{}{}
+				if len(s.codeFailed[hash]) >= peers {
+					log.Debug("Abandoning contract code", "hash", hash)
+					for _, owner := range s.codeOwners[hash] {
+						s.abandon(owner)
+					}
+					delete(s.codeOwners, hash)
+					delete(s.codeFailed, hash)
+					continue
+				}
+			}
+			s.codeQueue = append(s.codeQueue, hash)
+		}
+	}
+}
+
+// abandon drops an incomplete account, leaving it to the healing.
+func (s *snapSyncer) abandon(hash common.Hash) {
+	if account := s.pending[hash]; account != nil {
+		account.abandoned = true
+	}
+	delete(s.pending, hash)
+	for i, queued := range s.storageQueue {
+		if queued == hash {
+			s.storageQueue = append(s.storageQueue[:i], s.storageQueue[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *snapSyncer) process(pack dataPack) error {
+	req := s.requests[pack.PeerId()]
+
+	var id uint64
+	switch pack := pack.(type) {
+	case *accountRangePack:
+		id = pack.id
+	case *storageRangesPack:
+		id = pack.id
+	case *byteCodesPack:
+		id = pack.id
+	}
+	if req == nil || req.id != id {
+		log.Debug("Unrequested state range", "peer", pack.PeerId(), "id", id)
+		return nil
+	}
+	req.timer.Stop()
+	delete(s.requests, req.peer.id)
+
+	var err error
+	switch pack := pack.(type) {
+	case *accountRangePack:
+		if req.kind == accountRequest {
+			err = s.processAccounts(req, pack)
+		}
+	case *storageRangesPack:
+		if req.kind == storageRequest {
+			err = s.processStorage(req, pack)
+		}
+	case *byteCodesPack:
+		if req.kind == codeRequest {
+			err = s.processCodes(req, pack)
+		}
+	}
+	if err != nil {
+		s.release(req, true)
+	}
+	s.logProgress(false)
+	return err
+}
+
+func (s *snapSyncer) processAccounts(req *snapRequest, pack *accountRangePack) error {
+	task := req.task
+	if len(pack.hashes) == 0 && len(pack.proof) == 0 {
+		s.release(req, true)
+		return nil
+	}
+	if len(pack.hashes) != len(pack.accounts) {
+		return fmt.Errorf("%d account hashes for %d accounts", len(pack.hashes), len(pack.accounts))
+	}
+	keys := make([][]byte, len(pack.hashes))
+	for i, hash := range pack.hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	last := task.next[:]
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	more, err := trie.VerifyRangeProof(s.root, task.next[:], last, keys, pack.accounts, s.proofDB(pack.proof))
+	if err != nil {
+		return err
+	}
+	// The last account may be past the task, it only proves the end of it
+	for len(keys) > 0 && bytes.Compare(keys[len(keys)-1], task.last[:]) > 0 {
+		keys, more = keys[:len(keys)-1], false
+	}
+	accounts := make([]*snapAccount, len(keys))
+	for i := range keys {
+		accounts[i] = &snapAccount{hash: pack.hashes[i], task: task, blob: pack.accounts[i], failed: make(map[string]struct{})}
+		if err := rlp.DecodeBytes(pack.accounts[i], &accounts[i].account); err != nil {
+			return err
+		}
+	}
+	for _, account := range accounts {
+		s.addAccount(account)
+	}
+	switch {
+	case !more:
+		task.done = true
+	case bytes.Equal(last, task.last[:]):
+		task.done = true
+	default:
+		task.next = incHash(common.BytesToHash(last))
+	}
+	return nil
+}
+
+// addAccount queues a downloaded account along with its missing storage and code.
+func (s *snapSyncer) addAccount(account *snapAccount) {
+	hash := account.hash
+	account.task.queue = append(account.task.queue, account)
+	s.accountsSynced++
+
+	if !bytes.Equal(account.account.CodeHash, emptyCodeHash) {
+		if ok, _ := s.d.stateDB.Has(account.account.CodeHash); !ok {
+			code := common.BytesToHash(account.account.CodeHash)
+			if _, queued := s.codeOwners[code]; !queued {
+				s.codeQueue = append(s.codeQueue, code)
+			}
+			s.codeOwners[code] = append(s.codeOwners[code], hash)
+			account.needCode = true
+		}
+	}
+	if account.account.Root != emptyRoot {
+		if ok, _ := s.d.stateDB.Has(account.account.Root[:]); !ok {
+			s.storageQueue = append(s.storageQueue, hash)
+			account.needStorage = true
+		}
+	}
+	if account.needCode || account.needStorage {
+		s.pending[hash] = account
+	}
+}
+
+// completeAccount marks an account ready once its storage and code are there.
+func (s *snapSyncer) completeAccount(hash common.Hash) {
+	if account := s.pending[hash]; account != nil && !account.needCode && !account.needStorage {
+		delete(s.pending, hash)
+	}
+}
+
+func (s *snapSyncer) processStorage(req *snapRequest, pack *storageRangesPack) error {
+	if len(pack.slots) == 0 && len(pack.proof) == 0 {
+		s.release(req, true)
+		return nil
+	}
+	if len(pack.hashes) != len(pack.slots) || len(pack.slots) > len(req.accounts) {
+		return fmt.Errorf("%d storage ranges for %d accounts", len(pack.slots), len(req.accounts))
+	}
+	// Check all the ranges before inserting any, the tries can't take them twice
+	var (
+		ranges = make([][][]byte, len(pack.slots))
+		more   bool
+	)
+	for i, slots := range pack.slots {
+		var (
+			hash    = req.accounts[i]
+			account = s.pending[hash]
+			proof   trie.DatabaseReader
+			origin  common.Hash
+		)
+		if len(pack.hashes[i]) != len(slots) {
+			return fmt.Errorf("%d slot hashes for %d slots", len(pack.hashes[i]), len(slots))
+		}
+		if i == len(pack.slots)-1 && len(pack.proof) > 0 {
+			proof, origin = s.proofDB(pack.proof), req.origin
+		}
+		if account == nil {
+			continue
+		}
+		keys := make([][]byte, len(slots))
+		for j, slot := range pack.hashes[i] {
+			keys[j] = common.CopyBytes(slot[:])
+		}
+		last := origin[:]
+		if len(keys) > 0 {
+			last = keys[len(keys)-1]
+		}
+		var err error
+		if more, err = trie.VerifyRangeProof(account.account.Root, origin[:], last, keys, slots, proof); err != nil {
+			return err
+		}
+		ranges[i] = keys
+	}
+	for i, keys := range ranges {
+		var (
+			hash    = req.accounts[i]
+			account = s.pending[hash]
+		)
+		if account == nil {
+			continue
+		}
+		if account.storage == nil {
+			account.storage = newTrieBuilder(s.d.stateDB, nil)
+		}
+		for j, key := range keys {
+			if err := account.storage.add(key, pack.slots[i][j]); err != nil {
+				return err
+			}
+		}
+		s.slotsSynced += uint64(len(keys))
+
+		if more && i == len(ranges)-1 {
+			account.origin = incHash(common.BytesToHash(keys[len(keys)-1]))
+			s.storageQueue = append(s.storageQueue, hash)
+			continue
+		}
+		root, err := account.storage.commit()
+		if err != nil {
+			return err
+		}
+		account.storage = nil
+		if root != account.account.Root {
+			log.Debug("Storage range root mismatch", "account", hash, "want", account.account.Root, "have", root)
+			s.abandon(hash)
+			continue
+		}
+		account.needStorage = false
+		s.completeAccount(hash)
+	}
+	// Accounts the peer had no room for go back into the queue
+	for _, hash := range req.accounts[len(pack.slots):] {
+		if s.pending[hash] != nil {
+			s.storageQueue = append(s.storageQueue, hash)
+		}
+	}
+	return nil
+}
+
+func (s *snapSyncer) processCodes(req *snapRequest, pack *byteCodesPack) error {
+	requested := make(map[common.Hash]struct{}, len(req.codes))
+	for _, hash := range req.codes {
+		requested[hash] = struct{}{}
+	}
+	batch := s.d.stateDB.NewBatch()
+	for _, code := range pack.codes {
+		var hash common.Hash
+		s.keccak.Reset()
+		s.keccak.Write(code)
+		s.keccak.Sum(hash[:0])
+
+		if _, ok := requested[hash]; !ok {
+			continue
+		}
+		delete(requested, hash)
+		if err := batch.Put(hash[:], code); err != nil {
+			return err
+		}
+		s.codesSynced++
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	for _, hash := range req.codes {
+		if _, missing := requested[hash]; missing {
+			continue
+		}
+		owners := s.codeOwners[hash]
+		delete(s.codeOwners, hash)
+		delete(s.codeFailed, hash)
+
+		for _, owner := range owners {
+			if account := s.pending[owner]; account != nil {
+				account.needCode = false
+				s.completeAccount(owner)
+			}
+		}
+	}
+	if len(requested) > 0 {
+		missing := &snapRequest{kind: codeRequest, peer: req.peer}
+		for hash := range requested {
+			missing.codes = append(missing.codes, hash)
+		}
+		s.release(missing, len(requested) == len(req.codes))
+	}
+	return nil
+}
+
+// proofDB indexes the nodes of a range proof by their hashes.
+func (s *snapSyncer) proofDB(proof [][]byte) trie.DatabaseReader {
+	db, _ := ddmdb.NewMemDatabase()
+	for _, node := range proof {
+		var hash common.Hash
+		s.keccak.Reset()
+		s.keccak.Write(node)
+		s.keccak.Sum(hash[:0])
+		db.Put(hash[:], node)
+	}
+	return db
+}
+
+func (s *snapSyncer) logProgress(force bool) {
+	synced := s.accountsSynced + s.slotsSynced + s.codesSynced
+
+	s.d.syncStatsLock.Lock()
+	s.d.syncStatsState.processed += synced - s.reported
+	processed := s.d.syncStatsState.processed
+	s.d.syncStatsLock.Unlock()
+	s.reported = synced
+
+	if synced > 0 && (force || time.Since(s.logged) > 8*time.Second) {
+		log.Info("Imported new state ranges", "accounts", s.accountsSynced, "slots", s.slotsSynced, "codes", s.codesSynced, "processed", processed, "pending", len(s.pending))
+		s.logged = time.Now()
+	}
+}
+
+// incHash returns the hash following h.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
+
+// trieBuilder builds a trie from its ordered leaves straight into the database.
+type trieBuilder struct {
+	batch ddmdb.Batch
+	trie  *trie.StackTrie
+}
+
+func newTrieBuilder(db ddmdb.Database, prefix []byte) *trieBuilder {
+	batch := db.NewBatch()
+	return &trieBuilder{batch: batch, trie: trie.NewStackTrieAt(batch, prefix)}
+}
+
+func (b *trieBuilder) add(key, value []byte) error {
+	if err := b.trie.TryUpdate(key, value); err != nil {
+		return err
+	}
+	if b.batch.ValueSize() >= ddmdb.IdealBatchSize {
+		return b.flush()
+	}
+	return nil
+}
+
+func (b *trieBuilder) flush() error {
+	if err := b.batch.Write(); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	return nil
+}
+
+func (b *trieBuilder) commit() (common.Hash, error) {
+	root, err := b.trie.Commit()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return root, b.flush()
+}
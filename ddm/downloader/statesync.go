@@ -168,7 +168,9 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 }
 
 type stateSync struct {
-	d *Downloader 
+	d    *Downloader 
+	root common.Hash
+	snap bool
 
 	sched  *trie.TrieSync             
 	keccak hash.Hash                  
@@ -191,7 +193,8 @@ type stateTask struct {
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
 	return &stateSync{
 		d:       d,
-		sched:   state.NewStateSync(root, d.stateDB),
+		root:    root,
+		snap:    d.mode == SnapSync,
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
 		deliver: make(chan *stateReq),
@@ -200,7 +203,17 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 	}
 }
 
+// run downloads the state, in snap sync mode first by ranges and then by trie
+// nodes for whatever the ranges left out.
 func (s *stateSync) run() {
+	if s.snap {
+		if err := s.d.syncStateRanges(s.root, s.cancel); err != nil {
+			s.err = err
+			close(s.done)
+			return
+		}
+	}
+	s.sched = state.NewStateSync(s.root, s.d.stateDB)
 	s.err = s.loop()
 	close(s.done)
 }
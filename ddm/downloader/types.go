@@ -4,6 +4,7 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/major/types"
 )
 
@@ -56,3 +57,37 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerId }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+type accountRangePack struct {
+	peerId   string
+	id       uint64
+	hashes   []common.Hash
+	accounts [][]byte
+	proof    [][]byte
+}
+
+func (p *accountRangePack) PeerId() string { return p.peerId }
+func (p *accountRangePack) Items() int     { return len(p.accounts) }
+func (p *accountRangePack) Stats() string  { return fmt.Sprintf("%d", len(p.accounts)) }
+
+type storageRangesPack struct {
+	peerId string
+	id     uint64
+	hashes [][]common.Hash
+	slots  [][][]byte
+	proof  [][]byte
+}
+
+func (p *storageRangesPack) PeerId() string { return p.peerId }
+func (p *storageRangesPack) Items() int     { return len(p.slots) }
+func (p *storageRangesPack) Stats() string  { return fmt.Sprintf("%d", len(p.slots)) }
+
+type byteCodesPack struct {
+	peerId string
+	id     uint64
+	codes  [][]byte
+}
+
+func (p *byteCodesPack) PeerId() string { return p.peerId }
+func (p *byteCodesPack) Items() int     { return len(p.codes) }
+func (p *byteCodesPack) Stats() string  { return fmt.Sprintf("%d", len(p.codes)) }
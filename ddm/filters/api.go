@@ -12,6 +12,7 @@ import (
 
 	ddmchain "github.com/ddmchain/go-ddmchain"
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/general/hexutil"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/ddmpv"
@@ -133,6 +134,44 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// droppedTransaction is the notification sent for a transaction evicted from
+// the pool.
+type droppedTransaction struct {
+	Hash   common.Hash `json:"hash"`
+	Reason string      `json:"reason"`
+}
+
+// DroppedTransactions notifies of every transaction the transaction pool
+// discards, together with the reason it was discarded.
+func (api *PublicFilterAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		drops := make(chan core.TxDropEvent)
+		dropSub := api.events.SubscribeDroppedTxEvents(drops)
+
+		for {
+			select {
+			case ev := <-drops:
+				notifier.Notify(rpcSub.ID, &droppedTransaction{Hash: ev.Tx.Hash(), Reason: ev.Reason})
+			case <-rpcSub.Err():
+				dropSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				dropSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
 	var (
 		headers   = make(chan *types.Header)
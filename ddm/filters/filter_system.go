@@ -32,6 +32,8 @@ const (
 
 	BlocksSubscription
 
+	DroppedTransactionsSubscription
+
 	LastIndexSubscription
 )
 
@@ -39,6 +41,8 @@ const (
 
 	txChanSize = 4096 *10 
 
+	dropChanSize = 4096
+
 	rmLogsChanSize = 10
 
 	logsChanSize = 10
@@ -58,6 +62,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	hashes    chan common.Hash
 	headers   chan *types.Header
+	drops     chan core.TxDropEvent
 	installed chan struct{} 
 	err       chan error    
 }
@@ -107,6 +112,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.drops:
 			}
 		}
 
@@ -164,6 +170,7 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ddmchain.FilterQuery, logs
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -179,6 +186,7 @@ func (es *EventSystem) subscribeLogs(crit ddmchain.FilterQuery, logs chan []*typ
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -194,6 +202,7 @@ func (es *EventSystem) subscribePendingLogs(crit ddmchain.FilterQuery, logs chan
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -208,6 +217,7 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		hashes:    make(chan common.Hash),
 		headers:   headers,
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -222,6 +232,24 @@ func (es *EventSystem) SubscribePendingTxEvents(hashes chan common.Hash) *Subscr
 		logs:      make(chan []*types.Log),
 		hashes:    hashes,
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeDroppedTxEvents creates a subscription that writes every transaction
+// the pool discards, along with the reason, to the given channel.
+func (es *EventSystem) SubscribeDroppedTxEvents(drops chan core.TxDropEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       DroppedTransactionsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan common.Hash),
+		headers:   make(chan *types.Header),
+		drops:     drops,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -265,6 +293,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		for _, f := range filters[PendingTransactionsSubscription] {
 			f.hashes <- e.Tx.Hash()
 		}
+	case core.TxDropEvent:
+		for _, f := range filters[DroppedTransactionsSubscription] {
+			f.drops <- e
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			f.headers <- e.Block.Header()
@@ -345,6 +377,9 @@ func (es *EventSystem) eventLoop() {
 		txCh  = make(chan core.TxPreEvent, txChanSize)
 		txSub = es.backend.SubscribeTxPreEvent(txCh)
 
+		dropCh  = make(chan core.TxDropEvent, dropChanSize)
+		dropSub = es.backend.SubscribeTxDropEvent(dropCh)
+
 		rmLogsCh  = make(chan core.RemovedLogsEvent, rmLogsChanSize)
 		rmLogsSub = es.backend.SubscribeRemovedLogsEvent(rmLogsCh)
 
@@ -357,6 +392,7 @@ func (es *EventSystem) eventLoop() {
 
 	defer sub.Unsubscribe()
 	defer txSub.Unsubscribe()
+	defer dropSub.Unsubscribe()
 	defer rmLogsSub.Unsubscribe()
 	defer logsSub.Unsubscribe()
 	defer chainEvSub.Unsubscribe()
@@ -375,6 +411,8 @@ func (es *EventSystem) eventLoop() {
 
 		case ev := <-txCh:
 			es.broadcast(index, ev)
+		case ev := <-dropCh:
+			es.broadcast(index, ev)
 		case ev := <-rmLogsCh:
 			es.broadcast(index, ev)
 		case ev := <-logsCh:
@@ -403,6 +441,8 @@ func (es *EventSystem) eventLoop() {
 
 		case <-txSub.Err():
 			return
+		case <-dropSub.Err():
+			return
 		case <-rmLogsSub.Err():
 			return
 		case <-logsSub.Err():
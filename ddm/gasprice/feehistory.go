@@ -0,0 +1,145 @@
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/rule/misc"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/control"
+)
+
+const maxFeeHistory = 1024
+
+var (
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+)
+
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+type sortGasAndReward []txGasAndReward
+
+func (s sortGasAndReward) Len() int           { return len(s) }
+func (s sortGasAndReward) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s sortGasAndReward) Less(i, j int) bool { return s[i].reward.Cmp(s[j].reward) < 0 }
+
+// FeeHistory returns the base fee, gas used ratio and, for every requested
+// percentile of gas used, the tip paid in blocks up to lastBlock. The base
+// fees hold one entry more than the other results, the base fee of the block
+// following lastBlock. Blocks before the London fork report a zero base fee.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	if blocks < 1 {
+		return nil, nil, nil, nil, nil
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, nil, nil, nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, nil, nil, nil, fmt.Errorf("%v: #%d:%f > #%d:%f", errInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	if lastBlock == rpc.PendingBlockNumber {
+		lastBlock = rpc.LatestBlockNumber
+	}
+	last, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if last == nil {
+		if err == nil {
+			err = errRequestBeyondHead
+		}
+		return nil, nil, nil, nil, err
+	}
+	if uint64(blocks) > last.Number.Uint64()+1 {
+		blocks = int(last.Number.Uint64() + 1)
+	}
+	oldest := last.Number.Uint64() + 1 - uint64(blocks)
+
+	var (
+		config  = gpo.backend.ChainConfig()
+		reward  = make([][]*big.Int, blocks)
+		baseFee = make([]*big.Int, blocks+1)
+		ratio   = make([]float64, blocks)
+	)
+	for i := 0; i < blocks; i++ {
+		number := rpc.BlockNumber(oldest + uint64(i))
+
+		var (
+			header *types.Header
+			block  *types.Block
+		)
+		if len(rewardPercentiles) > 0 {
+			if block, err = gpo.backend.BlockByNumber(ctx, number); block != nil {
+				header = block.Header()
+			}
+		} else {
+			header, err = gpo.backend.HeaderByNumber(ctx, number)
+		}
+		if header == nil {
+			if err == nil {
+				err = errRequestBeyondHead
+			}
+			return nil, nil, nil, nil, err
+		}
+		baseFee[i] = new(big.Int)
+		if header.BaseFee != nil {
+			baseFee[i].Set(header.BaseFee)
+		}
+		if header.GasLimit > 0 {
+			ratio[i] = float64(header.GasUsed) / float64(header.GasLimit)
+		}
+		if block != nil {
+			receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			reward[i] = blockRewards(block, receipts, rewardPercentiles)
+		}
+	}
+	baseFee[blocks] = new(big.Int)
+	if next := new(big.Int).Add(last.Number, big.NewInt(1)); config.IsLondon(next) {
+		baseFee[blocks] = misc.CalcBaseFee(config, last)
+	}
+	if len(rewardPercentiles) == 0 {
+		reward = nil
+	}
+	return new(big.Int).SetUint64(oldest), reward, baseFee, ratio, nil
+}
+
+func blockRewards(block *types.Block, receipts types.Receipts, percentiles []float64) []*big.Int {
+	reward := make([]*big.Int, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 || len(receipts) != len(txs) {
+		for i := range reward {
+			reward[i] = new(big.Int)
+		}
+		return reward
+	}
+	sorted := make(sortGasAndReward, len(txs))
+	for i, tx := range txs {
+		sorted[i] = txGasAndReward{gasUsed: receipts[i].GasUsed, reward: tx.EffectiveGasTipValue(block.BaseFee())}
+	}
+	sort.Sort(sorted)
+
+	var index int
+	sum := sorted[0].gasUsed
+	for i, p := range percentiles {
+		threshold := uint64(float64(block.GasUsed()) * p / 100)
+		for sum < threshold && index < len(sorted)-1 {
+			index++
+			sum += sorted[index].gasUsed
+		}
+		reward[i] = sorted[index].reward
+	}
+	return reward
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule/misc"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/ddmin/ddmapi"
 	"github.com/ddmchain/go-ddmchain/part"
@@ -55,13 +56,35 @@ func NewOracle(backend ddmapi.Backend, params Config) *Oracle {
 	}
 }
 
+// SuggestPrice returns a gas price for legacy transactions, which after the
+// London fork is the suggested tip on top of the next block's base fee.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	tip, err := gpo.SuggestTipCap(ctx)
+	if err != nil {
+		return tip, err
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if config := gpo.backend.ChainConfig(); config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		return new(big.Int).Add(tip, misc.CalcBaseFee(config, head)), nil
+	}
+	return tip, nil
+}
+
+// SuggestTipCap returns the configured percentile of the smallest tips paid
+// in recent blocks. Before the London fork tips are whole gas prices.
+func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	gpo.cacheLock.RLock()
 	lastHead := gpo.lastHead
 	lastPrice := gpo.lastPrice
 	gpo.cacheLock.RUnlock()
 
-	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return lastPrice, err
+	}
 	headHash := head.Hash()
 	if headHash == lastHead {
 		return lastPrice, nil
@@ -132,11 +155,16 @@ type getBlockPricesResult struct {
 	err   error
 }
 
-type transactionsByGasPrice []*types.Transaction
+type transactionsByGasPrice struct {
+	txs     []*types.Transaction
+	baseFee *big.Int
+}
 
-func (t transactionsByGasPrice) Len() int           { return len(t) }
-func (t transactionsByGasPrice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
-func (t transactionsByGasPrice) Less(i, j int) bool { return t[i].GasPrice().Cmp(t[j].GasPrice()) < 0 }
+func (t transactionsByGasPrice) Len() int      { return len(t.txs) }
+func (t transactionsByGasPrice) Swap(i, j int) { t.txs[i], t.txs[j] = t.txs[j], t.txs[i] }
+func (t transactionsByGasPrice) Less(i, j int) bool {
+	return t.txs[i].EffectiveGasTipValue(t.baseFee).Cmp(t.txs[j].EffectiveGasTipValue(t.baseFee)) < 0
+}
 
 func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, blockNum uint64, ch chan getBlockPricesResult) {
 	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNum))
@@ -148,12 +176,13 @@ func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, bloc
 	blockTxs := block.Transactions()
 	txs := make([]*types.Transaction, len(blockTxs))
 	copy(txs, blockTxs)
-	sort.Sort(transactionsByGasPrice(txs))
+	baseFee := block.BaseFee()
+	sort.Sort(transactionsByGasPrice{txs, baseFee})
 
 	for _, tx := range txs {
 		sender, err := types.Sender(signer, tx)
 		if err == nil && sender != block.Coinbase() {
-			ch <- getBlockPricesResult{tx.GasPrice(), nil}
+			ch <- getBlockPricesResult{tx.EffectiveGasTipValue(baseFee), nil}
 			return
 		}
 	}
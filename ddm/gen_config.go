@@ -3,6 +3,7 @@ package ddm
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/general/hexutil"
@@ -10,6 +11,7 @@ import (
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/ddm/downloader"
 	"github.com/ddmchain/go-ddmchain/ddm/gasprice"
+	"github.com/ddmchain/go-ddmchain/part"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -19,16 +21,21 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
+		SyncCheckpoint          *params.TrustedCheckpoint `toml:",omitempty"`
 		LightServ               int  `toml:",omitempty"`
 		LightPeers              int  `toml:",omitempty"`
 		SkipBcVersionCheck      bool `toml:"-"`
 		DatabaseHandles         int  `toml:"-"`
 		DatabaseCache           int
+		DatabaseFreezer         string
 		DDMXbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
 		DDMhash                  ddmhash.Config
+		DPosSnapshots           uint64
+		DPosSigner              string        `toml:",omitempty"`
+		DPosSignerTimeout       time.Duration `toml:",omitempty"`
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
@@ -38,16 +45,21 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.SyncCheckpoint = c.SyncCheckpoint
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.DatabaseFreezer = c.DatabaseFreezer
 	enc.DDMXbase = c.DDMXbase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
 	enc.DDMhash = c.DDMhash
+	enc.DPosSnapshots = c.DPosSnapshots
+	enc.DPosSigner = c.DPosSigner
+	enc.DPosSignerTimeout = c.DPosSignerTimeout
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
@@ -60,16 +72,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
+		SyncCheckpoint          *params.TrustedCheckpoint `toml:",omitempty"`
 		LightServ               *int  `toml:",omitempty"`
 		LightPeers              *int  `toml:",omitempty"`
 		SkipBcVersionCheck      *bool `toml:"-"`
 		DatabaseHandles         *int  `toml:"-"`
 		DatabaseCache           *int
+		DatabaseFreezer         *string
 		DDMXbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
 		DDMhash                  *ddmhash.Config
+		DPosSnapshots           *uint64
+		DPosSigner              *string        `toml:",omitempty"`
+		DPosSignerTimeout       *time.Duration `toml:",omitempty"`
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
@@ -88,6 +105,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.SyncCheckpoint != nil {
+		c.SyncCheckpoint = dec.SyncCheckpoint
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -103,6 +123,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.DatabaseFreezer != nil {
+		c.DatabaseFreezer = *dec.DatabaseFreezer
+	}
 	if dec.DDMXbase != nil {
 		c.DDMXbase = *dec.DDMXbase
 	}
@@ -118,6 +141,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DDMhash != nil {
 		c.DDMhash = *dec.DDMhash
 	}
+	if dec.DPosSnapshots != nil {
+		c.DPosSnapshots = *dec.DPosSnapshots
+	}
+	if dec.DPosSigner != nil {
+		c.DPosSigner = *dec.DPosSigner
+	}
+	if dec.DPosSignerTimeout != nil {
+		c.DPosSignerTimeout = *dec.DPosSignerTimeout
+	}
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/rule/dpos"
 	"github.com/ddmchain/go-ddmchain/rule/misc"
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/major/types"
@@ -32,6 +33,8 @@ const (
 	estHeaderRlpSize  = 500             
 
 	txChanSize = 4096 *10	
+
+	chainHeadChanSize = 10
 )
 
 var (
@@ -48,6 +51,7 @@ type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 
+	snapSync  uint32
 	acceptTxs uint32 
 
 	txpool      txPool
@@ -66,6 +70,10 @@ type ProtocolManager struct {
 	txSub         event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
+	finality     *dpos.Dpos
+	chainHeadCh  chan core.ChainHeadEvent
+	chainHeadSub event.Subscription
+
 	newPeerCh   chan *peer
 	txsyncCh    chan *txsync
 	quitSync    chan struct{}
@@ -90,18 +98,21 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		quitSync:    make(chan struct{}),
 	}
 
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
 
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 
-		if mode == downloader.FastSync && version < ddm63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < ddm63 {
 			continue
 		}
 
@@ -136,6 +147,11 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return nil, errIncompatibleConfig
 	}
 
+	if engine, ok := engine.(*dpos.Dpos); ok && config.DPos != nil && config.DPos.FinalityBlock != nil {
+		manager.finality = engine
+		manager.finality.SetFinalized(blockchain.CurrentFinalizedBlock().NumberU64())
+	}
+
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
 	validator := func(header *types.Header) error {
@@ -186,6 +202,12 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	if pm.finality != nil {
+		pm.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+		pm.chainHeadSub = pm.blockchain.SubscribeChainHeadEvent(pm.chainHeadCh)
+		go pm.finalityLoop()
+	}
+
 	go pm.syncer()
 	go pm.txsyncLoop()
 }
@@ -195,6 +217,9 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txSub.Unsubscribe()         
 	pm.minedBlockSub.Unsubscribe() 
+	if pm.chainHeadSub != nil {
+		pm.chainHeadSub.Unsubscribe()
+	}
 
 	pm.noMorePeers <- struct{}{}
 
@@ -489,7 +514,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 
-	case p.version >= ddm63 && msg.Code == GetNodeDataMsg:
+	case p.version >= ddm63 && p.version < ddm65 && msg.Code == GetNodeDataMsg:
 
 		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
 		if _, err := msgStream.List(); err != nil {
@@ -517,7 +542,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		return p.SendNodeData(data)
 
-	case p.version >= ddm63 && msg.Code == NodeDataMsg:
+	case p.version >= ddm63 && p.version < ddm65 && msg.Code == NodeDataMsg:
 
 		var data [][]byte
 		if err := msg.Decode(&data); err != nil {
@@ -576,6 +601,90 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Failed to deliver receipts", "err", err)
 		}
 
+	case p.version >= ddm65 && msg.Code == GetAccountRangeMsg:
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendAccountRange(pm.serveAccountRange(&req))
+
+	case p.version >= ddm65 && msg.Code == AccountRangeMsg:
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([]common.Hash, len(res.Accounts))
+		accounts := make([][]byte, len(res.Accounts))
+		for i, account := range res.Accounts {
+			if account == nil {
+				return errResp(ErrDecode, "account %d is nil", i)
+			}
+			hashes[i], accounts[i] = account.Hash, account.Body
+		}
+		if err := pm.downloader.DeliverAccountRange(p.id, res.ID, hashes, accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case p.version >= ddm65 && msg.Code == GetStorageRangesMsg:
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendStorageRanges(pm.serveStorageRanges(&req))
+
+	case p.version >= ddm65 && msg.Code == StorageRangesMsg:
+		var res storageRangesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		hashes := make([][]common.Hash, len(res.Slots))
+		slots := make([][][]byte, len(res.Slots))
+		for i, account := range res.Slots {
+			hashes[i] = make([]common.Hash, len(account))
+			slots[i] = make([][]byte, len(account))
+			for j, slot := range account {
+				if slot == nil {
+					return errResp(ErrDecode, "slot %d of account %d is nil", j, i)
+				}
+				hashes[i][j], slots[i][j] = slot.Hash, slot.Body
+			}
+		}
+		if err := pm.downloader.DeliverStorageRanges(p.id, res.ID, hashes, slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage ranges", "err", err)
+		}
+
+	case p.version >= ddm65 && msg.Code == GetByteCodesMsg:
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendByteCodes(pm.serveByteCodes(&req))
+
+	case p.version >= ddm65 && msg.Code == ByteCodesMsg:
+		var res byteCodesData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverByteCodes(p.id, res.ID, res.Codes); err != nil {
+			log.Debug("Failed to deliver contract codes", "err", err)
+		}
+
+	case p.version >= ddm65 && msg.Code == GetTrieNodesMsg:
+		var req getTrieNodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendTrieNodes(pm.serveTrieNodes(&req))
+
+	case p.version >= ddm65 && msg.Code == TrieNodesMsg:
+		var data [][]byte
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverNodeData(p.id, data); err != nil {
+			log.Debug("Failed to deliver node state data", "err", err)
+		}
+
 	case msg.Code == NewBlockHashesMsg:
 		var announces newBlockHashesData
 		if err := msg.Decode(&announces); err != nil {
@@ -643,12 +752,72 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txpool.AddRemotes(txs)
 
+	case p.version >= ddm64 && msg.Code == FinalityVoteMsg:
+
+		if pm.finality == nil {
+			break
+		}
+		var votes []*dpos.FinalityVote
+		if err := msg.Decode(&votes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, vote := range votes {
+
+			if vote == nil {
+				return errResp(ErrDecode, "finality vote %d is nil", i)
+			}
+			p.MarkFinalityVote(vote.ID())
+			pm.handleFinalityVote(vote)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
 	return nil
 }
 
+func (pm *ProtocolManager) handleFinalityVote(vote *dpos.FinalityVote) {
+	finalized, err := pm.finality.AddFinalityVote(pm.blockchain, vote)
+	if err != nil {
+		log.Trace("Discarded finality vote", "number", vote.Number, "hash", vote.Hash, "err", err)
+		return
+	}
+	pm.BroadcastFinalityVote(vote)
+
+	if finalized {
+		if err := pm.blockchain.SetFinalizedBlock(vote.Hash, vote.Number); err != nil {
+			log.Warn("Failed to finalize block", "number", vote.Number, "hash", vote.Hash, "err", err)
+		}
+	}
+}
+
+func (pm *ProtocolManager) BroadcastFinalityVote(vote *dpos.FinalityVote) {
+	id := vote.ID()
+	peers := pm.peers.PeersWithoutFinalityVote(id)
+
+	for _, peer := range peers {
+		peer.SendFinalityVotes([]*dpos.FinalityVote{vote})
+	}
+	log.Trace("Broadcast finality vote", "number", vote.Number, "hash", vote.Hash, "recipients", len(peers))
+}
+
+func (pm *ProtocolManager) finalityLoop() {
+	for {
+		select {
+		case ev := <-pm.chainHeadCh:
+			vote, err := pm.finality.SignFinalityVote(pm.blockchain, ev.Block.Header())
+			if err != nil {
+				continue
+			}
+			log.Debug("Signed finality vote", "number", vote.Number, "hash", vote.Hash)
+			pm.handleFinalityVote(vote)
+
+		case <-pm.chainHeadSub.Err():
+			return
+		}
+	}
+}
+
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 	hash := block.Hash()
 	peers := pm.peers.PeersWithoutBlock(hash)
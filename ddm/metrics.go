@@ -73,6 +73,8 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 
 	case rw.version >= ddm63 && msg.Code == NodeDataMsg:
 		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
+	case rw.version >= ddm65 && (msg.Code == AccountRangeMsg || msg.Code == StorageRangesMsg || msg.Code == ByteCodesMsg || msg.Code == TrieNodesMsg):
+		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
 	case rw.version >= ddm63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
 
@@ -100,6 +102,8 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 
 	case rw.version >= ddm63 && msg.Code == NodeDataMsg:
 		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
+	case rw.version >= ddm65 && (msg.Code == AccountRangeMsg || msg.Code == StorageRangesMsg || msg.Code == ByteCodesMsg || msg.Code == TrieNodesMsg):
+		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
 	case rw.version >= ddm63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
 
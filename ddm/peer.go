@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule/dpos"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/discover"
 	"github.com/ddmchain/go-ddmchain/ptl"
@@ -25,6 +26,7 @@ var (
 const (
 	maxKnownTxs      = 32768 
 	maxKnownBlocks   = 1024  
+	maxKnownVotes    = 1024
 	handshakeTimeout = 5 * time.Second
 )
 
@@ -49,6 +51,7 @@ type peer struct {
 
 	knownTxs    *set.Set 
 	knownBlocks *set.Set 
+	knownVotes  *set.Set
 	superNode	bool
 }
 
@@ -62,6 +65,7 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		id:          fmt.Sprintf("%x", id[:8]),
 		knownTxs:    set.New(),
 		knownBlocks: set.New(),
+		knownVotes:  set.New(),
 	}
 }
 
@@ -107,6 +111,21 @@ func (p *peer) MarkTransaction(hash common.Hash) {
 	p.knownTxs.Add(hash)
 }
 
+func (p *peer) MarkFinalityVote(id common.Hash) {
+
+	for p.knownVotes.Size() >= maxKnownVotes {
+		p.knownVotes.Pop()
+	}
+	p.knownVotes.Add(id)
+}
+
+func (p *peer) SendFinalityVotes(votes []*dpos.FinalityVote) error {
+	for _, vote := range votes {
+		p.knownVotes.Add(vote.ID())
+	}
+	return p2p.Send(p.rw, FinalityVoteMsg, votes)
+}
+
 func (p *peer) SendTransactions(txs types.Transactions) error {
 	for _, tx := range txs {
 		p.knownTxs.Add(tx.Hash())
@@ -151,6 +170,22 @@ func (p *peer) SendReceiptsRLP(receipts []rlp.RawValue) error {
 	return p2p.Send(p.rw, ReceiptsMsg, receipts)
 }
 
+func (p *peer) SendAccountRange(res *accountRangeData) error {
+	return p2p.Send(p.rw, AccountRangeMsg, res)
+}
+
+func (p *peer) SendStorageRanges(res *storageRangesData) error {
+	return p2p.Send(p.rw, StorageRangesMsg, res)
+}
+
+func (p *peer) SendByteCodes(res *byteCodesData) error {
+	return p2p.Send(p.rw, ByteCodesMsg, res)
+}
+
+func (p *peer) SendTrieNodes(data [][]byte) error {
+	return p2p.Send(p.rw, TrieNodesMsg, data)
+}
+
 func (p *peer) RequestOneHeader(hash common.Hash) error {
 	p.Log().Debug("Fetching single header", "hash", hash)
 	return p2p.Send(p.rw, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Hash: hash}, Amount: uint64(1), Skip: uint64(0), Reverse: false})
@@ -173,9 +208,27 @@ func (p *peer) RequestBodies(hashes []common.Hash) error {
 
 func (p *peer) RequestNodeData(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of state data", "count", len(hashes))
+	if p.version >= ddm65 {
+		return p2p.Send(p.rw, GetTrieNodesMsg, &getTrieNodesData{Hashes: hashes, Bytes: softResponseLimit})
+	}
 	return p2p.Send(p.rw, GetNodeDataMsg, hashes)
 }
 
+func (p *peer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "root", root, "origin", origin, "limit", limit, "bytes", bytes)
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes})
+}
+
+func (p *peer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin []byte, bytes uint64) error {
+	p.Log().Debug("Fetching ranges of storage slots", "root", root, "accounts", len(accounts), "origin", common.BytesToHash(origin), "bytes", bytes)
+	return p2p.Send(p.rw, GetStorageRangesMsg, &getStorageRangesData{ID: id, Root: root, Accounts: accounts, Origin: origin, Bytes: bytes})
+}
+
+func (p *peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching batch of contract codes", "count", len(hashes))
+	return p2p.Send(p.rw, GetByteCodesMsg, &getByteCodesData{ID: id, Hashes: hashes, Bytes: bytes})
+}
+
 func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of receipts", "count", len(hashes))
 	return p2p.Send(p.rw, GetReceiptsMsg, hashes)
@@ -332,6 +385,19 @@ func (ps *peerSet) PeersWithoutBlock(hash common.Hash) []*peer {
 	return list
 }
 
+func (ps *peerSet) PeersWithoutFinalityVote(id common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= ddm64 && !p.knownVotes.Has(id) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
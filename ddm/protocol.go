@@ -16,13 +16,15 @@ import (
 const (
 	ddm62 = 62
 	ddm63 = 63
+	ddm64 = 64
+	ddm65 = 65
 )
 
 var ProtocolName = "ddm"
 
-var ProtocolVersions = []uint{ddm63, ddm62}
+var ProtocolVersions = []uint{ddm65, ddm64, ddm63, ddm62}
 
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{26, 18, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 
 
@@ -41,6 +43,17 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	FinalityVoteMsg = 0x11
+
+	GetAccountRangeMsg  = 0x12
+	AccountRangeMsg     = 0x13
+	GetStorageRangesMsg = 0x14
+	StorageRangesMsg    = 0x15
+	GetByteCodesMsg     = 0x16
+	ByteCodesMsg        = 0x17
+	GetTrieNodesMsg     = 0x18
+	TrieNodesMsg        = 0x19
 )
 
 type errCode int
@@ -79,6 +92,8 @@ type txPool interface {
 
 	Pending() (map[common.Address]types.Transactions, error)
 
+	Private(hash common.Hash) bool
+
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
 }
 
@@ -145,3 +160,69 @@ type blockBody struct {
 }
 
 type blockBodiesData []*blockBody
+
+// getAccountRangeData asks for the accounts of the state with the given root
+// from Origin on, up to the first one past Limit or Bytes worth of them.
+type getAccountRangeData struct {
+	ID     uint64
+	Root   common.Hash
+	Origin common.Hash
+	Limit  common.Hash
+	Bytes  uint64
+}
+
+// accountRangeData holds the accounts asked for, as stored in the state trie,
+// with the proof of the paths to the origin and to the last account. No
+// accounts and no proof means the state is not available.
+type accountRangeData struct {
+	ID       uint64
+	Accounts []*accountData
+	Proof    [][]byte
+}
+
+type accountData struct {
+	Hash common.Hash
+	Body rlp.RawValue
+}
+
+// getStorageRangesData asks for the storage slots of accounts of the state
+// with the given root. Origin only applies to the first account.
+type getStorageRangesData struct {
+	ID       uint64
+	Root     common.Hash
+	Accounts []common.Hash
+	Origin   []byte
+	Bytes    uint64
+}
+
+// storageRangesData holds the slots of the accounts asked for, in order, as
+// far as they fit. Only the slots of the last account can be incomplete or
+// start past the origin, in which case they come with a proof.
+type storageRangesData struct {
+	ID    uint64
+	Slots [][]*storageData
+	Proof [][]byte
+}
+
+type storageData struct {
+	Hash common.Hash
+	Body rlp.RawValue
+}
+
+type getByteCodesData struct {
+	ID     uint64
+	Hashes []common.Hash
+	Bytes  uint64
+}
+
+type byteCodesData struct {
+	ID    uint64
+	Codes [][]byte
+}
+
+// getTrieNodesData asks for state trie nodes by hash, it replaces
+// GetNodeDataMsg from ddm/65 on.
+type getTrieNodesData struct {
+	Hashes []common.Hash
+	Bytes  uint64
+}
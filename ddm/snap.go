@@ -0,0 +1,215 @@
+
+package ddm
+
+import (
+	"bytes"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
+	"github.com/ddmchain/go-ddmchain/ddm/downloader"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+// maxStorageAccounts caps the number of accounts served in one storage
+// ranges response.
+const maxStorageAccounts = 256
+
+// proofList collects the nodes of range proofs, once each.
+type proofList struct {
+	seen  map[string]struct{}
+	nodes [][]byte
+}
+
+func newProofList() *proofList {
+	return &proofList{seen: make(map[string]struct{})}
+}
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	if _, ok := l.seen[string(key)]; !ok {
+		l.seen[string(key)] = struct{}{}
+		l.nodes = append(l.nodes, common.CopyBytes(value))
+	}
+	return nil
+}
+
+// trieLeafIterator walks the leaves of a trie like a snapshot iterator, for
+// the states without a complete snapshot.
+type trieLeafIterator struct {
+	it *trie.Iterator
+}
+
+func (it *trieLeafIterator) Next() bool        { return it.it.Next() }
+func (it *trieLeafIterator) Error() error      { return it.it.Err }
+func (it *trieLeafIterator) Hash() common.Hash { return common.BytesToHash(it.it.Key) }
+func (it *trieLeafIterator) Value() []byte     { return it.it.Value }
+func (it *trieLeafIterator) Release()          {}
+
+// leafIterator iterates the leaves of tr from origin on, through the snapshot
+// if it has the state, which is much faster than walking the trie.
+func (pm *ProtocolManager) leafIterator(tr *trie.Trie, origin common.Hash, fromSnapshot func(*snapshot.Tree) (snapshot.Iterator, error)) snapshot.Iterator {
+	if snaps := pm.blockchain.StateCache().Snapshots(); snaps != nil {
+		if it, err := fromSnapshot(snaps); err == nil {
+			return it
+		}
+	}
+	return &trieLeafIterator{trie.NewIterator(tr.NodeIterator(origin[:]))}
+}
+
+func responseLimit(bytes uint64) uint64 {
+	if bytes == 0 || bytes > softResponseLimit {
+		return softResponseLimit
+	}
+	return bytes
+}
+
+func (pm *ProtocolManager) serveAccountRange(req *getAccountRangeData) *accountRangeData {
+	res := &accountRangeData{ID: req.ID}
+
+	tr, err := trie.New(req.Root, pm.blockchain.StateCache().TrieDB())
+	if err != nil {
+		return res
+	}
+	it := pm.leafIterator(tr, req.Origin, func(snaps *snapshot.Tree) (snapshot.Iterator, error) {
+		return snaps.AccountIterator(req.Root, req.Origin)
+	})
+	defer it.Release()
+
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for size < limit && it.Next() {
+		hash := it.Hash()
+		res.Accounts = append(res.Accounts, &accountData{Hash: hash, Body: common.CopyBytes(it.Value())})
+		size += uint64(common.HashLength + len(it.Value()))
+
+		if bytes.Compare(hash[:], req.Limit[:]) >= 0 {
+			break
+		}
+	}
+	if it.Error() != nil {
+		return &accountRangeData{ID: req.ID}
+	}
+	proof := newProofList()
+	if err := tr.Prove(req.Origin[:], 0, proof); err != nil {
+		return &accountRangeData{ID: req.ID}
+	}
+	if len(res.Accounts) > 0 {
+		if err := tr.Prove(res.Accounts[len(res.Accounts)-1].Hash[:], 0, proof); err != nil {
+			return &accountRangeData{ID: req.ID}
+		}
+	}
+	res.Proof = proof.nodes
+	return res
+}
+
+func (pm *ProtocolManager) serveStorageRanges(req *getStorageRangesData) *storageRangesData {
+	res := &storageRangesData{ID: req.ID}
+
+	triedb := pm.blockchain.StateCache().TrieDB()
+	accounts, err := trie.New(req.Root, triedb)
+	if err != nil {
+		return res
+	}
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, hash := range req.Accounts {
+		if size >= limit || i >= maxStorageAccounts {
+			break
+		}
+		blob, err := accounts.TryGet(hash[:])
+		if err != nil || blob == nil {
+			break
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			break
+		}
+		storage, err := trie.New(account.Root, triedb)
+		if err != nil {
+			break
+		}
+		var origin common.Hash
+		if i == 0 {
+			origin = common.BytesToHash(req.Origin)
+		}
+		it := pm.leafIterator(storage, origin, func(snaps *snapshot.Tree) (snapshot.Iterator, error) {
+			return snaps.StorageIterator(req.Root, hash, origin)
+		})
+		var (
+			slots []*storageData
+			more  bool
+		)
+		for it.Next() {
+			if size >= limit {
+				more = true
+				break
+			}
+			slots = append(slots, &storageData{Hash: it.Hash(), Body: common.CopyBytes(it.Value())})
+			size += uint64(common.HashLength + len(it.Value()))
+		}
+		err = it.Error()
+		it.Release()
+		if err != nil {
+			break
+		}
+		res.Slots = append(res.Slots, slots)
+
+		// A partial range has to be proven, and ends the response
+		if more || origin != (common.Hash{}) {
+			proof := newProofList()
+			if err := storage.Prove(origin[:], 0, proof); err != nil {
+				return &storageRangesData{ID: req.ID}
+			}
+			if len(slots) > 0 {
+				if err := storage.Prove(slots[len(slots)-1].Hash[:], 0, proof); err != nil {
+					return &storageRangesData{ID: req.ID}
+				}
+			}
+			res.Proof = proof.nodes
+			break
+		}
+	}
+	return res
+}
+
+func (pm *ProtocolManager) serveByteCodes(req *getByteCodesData) *byteCodesData {
+	res := &byteCodesData{ID: req.ID}
+
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, hash := range req.Hashes {
+		if size >= limit || i >= downloader.MaxStateFetch {
+			break
+		}
+		if code, err := pm.blockchain.TrieNode(hash); err == nil && len(code) > 0 {
+			res.Codes = append(res.Codes, code)
+			size += uint64(len(code))
+		}
+	}
+	return res
+}
+
+func (pm *ProtocolManager) serveTrieNodes(req *getTrieNodesData) [][]byte {
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+		nodes [][]byte
+	)
+	for i, hash := range req.Hashes {
+		if size >= limit || i >= downloader.MaxStateFetch {
+			break
+		}
+		if node, err := pm.blockchain.TrieNode(hash); err == nil {
+			nodes = append(nodes, node)
+			size += uint64(len(node))
+		}
+	}
+	return nodes
+}
@@ -29,7 +29,11 @@ func (pm *ProtocolManager) syncTransactions(p *peer) {
 	var txs types.Transactions
 	pending, _ := pm.txpool.Pending()
 	for _, batch := range pending {
-		txs = append(txs, batch...)
+		for _, tx := range batch {
+			if !pm.txpool.Private(tx.Hash()) {
+				txs = append(txs, tx)
+			}
+		}
 	}
 	if len(txs) == 0 {
 		return
@@ -159,6 +163,9 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		atomic.StoreUint32(&pm.fastSync, 1)
 		mode = downloader.FastSync
 	}
+	if mode == downloader.FastSync && atomic.LoadUint32(&pm.snapSync) == 1 {
+		mode = downloader.SnapSync
+	}
 
 	if err := pm.downloader.Synchronise(peer.id, pHead, pTd, mode); err != nil {
 
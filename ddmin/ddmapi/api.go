@@ -17,6 +17,7 @@ import (
 	"github.com/ddmchain/go-ddmchain/general/math"
 	"github.com/ddmchain/go-ddmchain/rule"
 	"github.com/ddmchain/go-ddmchain/rule/ddmhash"
+	"github.com/ddmchain/go-ddmchain/rule/misc"
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/major/vm"
@@ -26,8 +27,7 @@ import (
 	"github.com/ddmchain/go-ddmchain/part"
 	"github.com/ddmchain/go-ddmchain/ptl"
 	"github.com/ddmchain/go-ddmchain/control"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
 )
 
 const (
@@ -46,6 +46,50 @@ func (s *PublicDDMchainAPI) GasPrice(ctx context.Context) (*big.Int, error) {
 	return s.b.SuggestPrice(ctx)
 }
 
+func (s *PublicDDMchainAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.b.SuggestTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(tip), nil
+}
+
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the base fees, gas used ratios and the tips paid at the
+// given percentiles of gas used for blockCount blocks up to lastBlock.
+func (s *PublicDDMchainAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	oldest, reward, baseFee, gasUsed, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	results := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: gasUsed,
+	}
+	if reward != nil {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, w := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				results.Reward[i][j] = (*hexutil.Big)(v)
+			}
+		}
+	}
+	if baseFee != nil {
+		results.BaseFee = make([]*hexutil.Big, len(baseFee))
+		for i, v := range baseFee {
+			results.BaseFee[i] = (*hexutil.Big)(v)
+		}
+	}
+	return results, nil
+}
+
 func (s *PublicDDMchainAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
 }
@@ -311,7 +355,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxFeePerGas == nil {
 		return nil, fmt.Errorf("gasPrice not specified")
 	}
 	if args.Nonce == nil {
@@ -321,7 +365,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -404,8 +448,12 @@ func (s *PublicBlockChainAPI) NewAccount(password string) (common.Address, error
 	return common.Address{}, err
 }
 
-func (s *PublicBlockChainAPI) GetAccountInfo(ctx context.Context, address common.Address) (map[string]interface{}, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, -1)
+func (s *PublicBlockChainAPI) GetAccountInfo(ctx context.Context, address common.Address, blockNr *rpc.BlockNumber) (map[string]interface{}, error) {
+	number := rpc.LatestBlockNumber
+	if blockNr != nil {
+		number = *blockNr
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, number)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -433,16 +481,20 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.
 
 }
 
+func (s *PublicBlockChainAPI) GetFinalizedBlock(ctx context.Context, fullTx bool) (map[string]interface{}, error) {
+	return s.GetBlockByNumber(ctx, rpc.FinalizedBlockNumber, fullTx)
+}
+
 func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool) (map[string]interface{}, error) {
 
 	return s.GetBlockReceiptsByHash(ctx, blockHash, fullTx);
 
 }
 
-func newRPCTransactionSimple(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
+func newRPCTransactionSimple(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.NewLondonSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 	v, r, s := tx.RawSignatureValues()
@@ -456,10 +508,23 @@ func newRPCTransactionSimple(tx *types.Transaction, blockHash common.Hash, block
 		Nonce:    hexutil.Uint64(tx.Nonce()),
 		To:       tx.To(),
 		Value:    (*hexutil.Big)(tx.Value()),
+		Type:     hexutil.Uint64(tx.Type()),
 		V:        (*hexutil.Big)(v),
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
 	}
+	if tx.Type() != types.LegacyTxType {
+		al := tx.AccessList()
+		result.Accesses = &al
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		result.GasFeeCap = (*hexutil.Big)(tx.GasFeeCap())
+		result.GasTipCap = (*hexutil.Big)(tx.GasTipCap())
+		if baseFee != nil && blockHash != (common.Hash{}) {
+			result.GasPrice = (*hexutil.Big)(tx.EffectiveGasPrice(baseFee))
+		}
+	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
 		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
@@ -513,6 +578,9 @@ func (s *PublicBlockChainAPI) rpcOutputBlockReceipts(b *types.Block, receipts ty
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
 	}
+	if head.BaseFee != nil {
+		fields["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) (interface{}, error) {
@@ -521,7 +589,7 @@ func (s *PublicBlockChainAPI) rpcOutputBlockReceipts(b *types.Block, receipts ty
 
 		if fullTx {
 			formatTx = func(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) (interface{}, error) {
-				return newRPCTransactionSimple(tx, blockHash, blockNumber, index), nil
+				return newRPCTransactionSimple(tx, blockHash, blockNumber, index, b.BaseFee()), nil
 			}
 		}
 
@@ -681,6 +749,11 @@ type CallArgs struct {
 	GasPrice hexutil.Big     `json:"gasPrice"`
 	Value    hexutil.Big     `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
+
+	AccessList *types.AccessList `json:"accessList"`
+
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
 }
 
 func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config) ([]byte, uint64, bool, error) {
@@ -704,11 +777,30 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	if gas == 0 {
 		gas = 50000000
 	}
-	if gasPrice.Sign() == 0 {
-		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	gasFeeCap, gasTipCap := gasPrice, gasPrice
+	switch {
+	case header.BaseFee == nil:
+		if gasPrice.Sign() == 0 {
+			gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+			gasFeeCap, gasTipCap = gasPrice, gasPrice
+		}
+	case args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil:
+		gasFeeCap, gasTipCap = new(big.Int), new(big.Int)
+		if args.MaxFeePerGas != nil {
+			gasFeeCap = args.MaxFeePerGas.ToInt()
+		}
+		if args.MaxPriorityFeePerGas != nil {
+			gasTipCap = args.MaxPriorityFeePerGas.ToInt()
+		}
+		gasPrice = math.BigMin(new(big.Int).Add(gasTipCap, header.BaseFee), gasFeeCap)
 	}
+	vmCfg.NoBaseFee = true
 
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, gasFeeCap, gasTipCap, args.Data, accessList, false)
 
 	var cancel context.CancelFunc
 	if vmCfg.DisableGasMetering {
@@ -865,6 +957,9 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
 	}
+	if head.BaseFee != nil {
+		fields["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -910,15 +1005,20 @@ type RPCTransaction struct {
 	To               *common.Address `json:"to"`
 	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
 	Value            *hexutil.Big    `json:"value"`
-	V                *hexutil.Big    `json:"v"`
-	R                *hexutil.Big    `json:"r"`
-	S                *hexutil.Big    `json:"s"`
+	Type             hexutil.Uint64    `json:"type"`
+	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	Accesses         *types.AccessList `json:"accessList,omitempty"`
+	GasFeeCap        *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	GasTipCap        *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	V                *hexutil.Big      `json:"v"`
+	R                *hexutil.Big      `json:"r"`
+	S                *hexutil.Big      `json:"s"`
 }
 
-func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
+func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.NewLondonSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 	v, r, s := tx.RawSignatureValues()
@@ -932,10 +1032,23 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		Nonce:    hexutil.Uint64(tx.Nonce()),
 		To:       tx.To(),
 		Value:    (*hexutil.Big)(tx.Value()),
+		Type:     hexutil.Uint64(tx.Type()),
 		V:        (*hexutil.Big)(v),
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
 	}
+	if tx.Type() != types.LegacyTxType {
+		al := tx.AccessList()
+		result.Accesses = &al
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		result.GasFeeCap = (*hexutil.Big)(tx.GasFeeCap())
+		result.GasTipCap = (*hexutil.Big)(tx.GasTipCap())
+		if baseFee != nil && blockHash != (common.Hash{}) {
+			result.GasPrice = (*hexutil.Big)(tx.EffectiveGasPrice(baseFee))
+		}
+	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
 		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
@@ -945,7 +1058,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 }
 
 func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
-	return newRPCTransaction(tx, common.Hash{}, 0, 0)
+	return newRPCTransaction(tx, common.Hash{}, 0, 0, nil)
 }
 
 func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransaction {
@@ -953,7 +1066,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransacti
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index)
+	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index, b.BaseFee())
 }
 
 func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.Bytes {
@@ -961,7 +1074,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -1039,7 +1152,11 @@ func (s *PublicTransactionPoolAPI) GetNonce(ctx context.Context, address common.
 func (s *PublicTransactionPoolAPI) GetTxByHash(ctx context.Context, hash common.Hash) *RPCTransaction {
 
 	if tx, blockHash, blockNumber, index := core.GetTransaction(s.b.ChainDb(), hash); tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index)
+		var baseFee *big.Int
+		if header := core.GetHeader(s.b.ChainDb(), blockHash, blockNumber); header != nil {
+			baseFee = header.BaseFee
+		}
+		return newRPCTransaction(tx, blockHash, blockNumber, index, baseFee)
 	}
 
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
@@ -1059,7 +1176,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 		}
 	}
 
-	return rlp.EncodeToBytes(tx)
+	return tx.MarshalBinary()
 }
 
 func (s *PublicTransactionPoolAPI) GetTxReceiptByHash(hash common.Hash) (map[string]interface{}, error) {
@@ -1075,7 +1192,7 @@ func (s *PublicTransactionPoolAPI) GetTxReceiptByHash(hash common.Hash) (map[str
 
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.NewLondonSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -1086,6 +1203,7 @@ func (s *PublicTransactionPoolAPI) GetTxReceiptByHash(hash common.Hash) (map[str
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
+		"type":              hexutil.Uint(tx.Type()),
 		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
 		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
 		"contractAddress":   nil,
@@ -1105,6 +1223,9 @@ func (s *PublicTransactionPoolAPI) GetTxReceiptByHash(hash common.Hash) (map[str
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	if header := core.GetHeader(s.b.ChainDb(), blockHash, blockNumber); header != nil {
+		fields["effectiveGasPrice"] = (*hexutil.Big)(tx.EffectiveGasPrice(header.BaseFee))
+	}
 	return fields, nil
 }
 
@@ -1164,7 +1285,7 @@ func (s *PublicTransactionPoolAPI) SignTxWithPassword(ctx context.Context, args
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxFeePerGas == nil {
 		return nil, fmt.Errorf("gasPrice not specified")
 	}
 	if args.Nonce == nil {
@@ -1174,7 +1295,7 @@ func (s *PublicTransactionPoolAPI) SignTxWithPassword(ctx context.Context, args
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1191,6 +1312,12 @@ type SendTxArgs struct {
 
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
 func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
@@ -1198,7 +1325,12 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = new(hexutil.Uint64)
 		*(*uint64)(args.Gas) = 90000
 	}
-	if args.GasPrice == nil {
+	dynamic := args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil
+	if dynamic {
+		if err := args.setFeeDefaults(ctx, b); err != nil {
+			return err
+		}
+	} else if args.GasPrice == nil {
 		price, err := b.SuggestPrice(ctx)
 		if err != nil {
 			return err
@@ -1218,6 +1350,45 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
 		return errors.New(`Both "data" and "input" are set and not equal. Please use "input" to pass transaction call data.`)
 	}
+	if args.AccessList != nil || dynamic {
+		config := b.ChainConfig()
+		if !config.IsBerlin(new(big.Int).Add(b.CurrentBlock().Number(), big.NewInt(1))) {
+			return types.ErrTxTypeNotSupported
+		}
+		if args.ChainID == nil {
+			args.ChainID = (*hexutil.Big)(config.ChainId)
+		} else if args.ChainID.ToInt().Cmp(config.ChainId) != 0 {
+			return fmt.Errorf("chainId does not match node's (have=%v, want=%v)", args.ChainID, config.ChainId)
+		}
+	}
+	return nil
+}
+
+// setFeeDefaults fills in the fee caps of a dynamic fee transaction, leaving
+// room for the base fee to double before the transaction is included.
+func (args *SendTxArgs) setFeeDefaults(ctx context.Context, b Backend) error {
+	if args.GasPrice != nil {
+		return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	head := b.CurrentBlock().Header()
+	config := b.ChainConfig()
+	if !config.IsLondon(new(big.Int).Add(head.Number, big.NewInt(1))) {
+		return types.ErrTxTypeNotSupported
+	}
+	if args.MaxPriorityFeePerGas == nil {
+		tip, err := b.SuggestTipCap(ctx)
+		if err != nil {
+			return err
+		}
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tip)
+	}
+	if args.MaxFeePerGas == nil {
+		feeCap := new(big.Int).Mul(misc.CalcBaseFee(config, head), big.NewInt(2))
+		args.MaxFeePerGas = (*hexutil.Big)(feeCap.Add(feeCap, args.MaxPriorityFeePerGas.ToInt()))
+	}
+	if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+		return fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+	}
 	return nil
 }
 
@@ -1228,6 +1399,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Input != nil {
 		input = *args.Input
 	}
+	if args.MaxFeePerGas != nil {
+		var al types.AccessList
+		if args.AccessList != nil {
+			al = *args.AccessList
+		}
+		return types.NewDynamicFeeTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.MaxPriorityFeePerGas), (*big.Int)(args.MaxFeePerGas), input, al)
+	}
+	if args.AccessList != nil {
+		return types.NewAccessListTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input, *args.AccessList)
+	}
 	if args.To == nil {
 		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 	}
@@ -1286,12 +1467,47 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 
 func (s *PublicTransactionPoolAPI) SendRawTx(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 	return submitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateTransaction adds a signed transaction to the local pool without
+// announcing it to peers, so only blocks sealed by this node include it.
+func (s *PublicTransactionPoolAPI) SendPrivateTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.b.SendPrivateTx(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SendBundle submits signed transactions that blocks sealed by this node
+// include at their top, all together and in order, or not at all. The bundle
+// is never announced to peers. The returned hash identifies the bundle by the
+// hashes of its transactions.
+func (s *PublicTransactionPoolAPI) SendBundle(ctx context.Context, encodedTxs []hexutil.Bytes) (common.Hash, error) {
+	var (
+		txs    = make(types.Transactions, len(encodedTxs))
+		hashes = make([][]byte, len(encodedTxs))
+	)
+	for i, encodedTx := range encodedTxs {
+		txs[i] = new(types.Transaction)
+		if err := txs[i].UnmarshalBinary(encodedTx); err != nil {
+			return common.Hash{}, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		hashes[i] = txs[i].Hash().Bytes()
+	}
+	if err := s.b.SendBundle(ctx, txs); err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(hashes...), nil
+}
+
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
 
 	account := accounts.Account{Address: addr}
@@ -1317,7 +1533,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxFeePerGas == nil {
 		return nil, fmt.Errorf("gasPrice not specified")
 	}
 	if args.Nonce == nil {
@@ -1330,7 +1546,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1347,7 +1563,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	for _, tx := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if tx.Protected() {
-			signer = types.NewEIP155Signer(tx.ChainId())
+			signer = types.NewLondonSigner(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, err := s.b.AccountManager().Find(accounts.Account{Address: from}); err == nil {
@@ -1373,7 +1589,7 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	for _, p := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if p.Protected() {
-			signer = types.NewEIP155Signer(p.ChainId())
+			signer = types.NewLondonSigner(p.ChainId())
 		}
 		wantSigHash := signer.Hash(matchTx)
 
@@ -1444,11 +1660,9 @@ func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
 }
 
 func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
+	ldb, ok := ddmdb.LevelDB(api.b.ChainDb())
 	if !ok {
-		return "", fmt.Errorf("chaindbProperty does not work for memory databases")
+		return "", fmt.Errorf("chaindbProperty only works for leveldb databases")
 	}
 	if property == "" {
 		property = "leveldb.stats"
@@ -1459,15 +1673,10 @@ func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
 }
 
 func (api *PrivateDebugAPI) ChaindbCompact() error {
-	ldb, ok := api.b.ChainDb().(interface {
-		LDB() *leveldb.DB
-	})
-	if !ok {
-		return fmt.Errorf("chaindbCompact does not work for memory databases")
-	}
+	db := api.b.ChainDb()
 	for b := byte(0); b < 255; b++ {
 		log.Info("Compacting chain database", "range", fmt.Sprintf("0x%0.2X-0x%0.2X", b, b+1))
-		err := ldb.LDB().CompactRange(util.Range{Start: []byte{b}, Limit: []byte{b + 1}})
+		err := db.Compact([]byte{b}, []byte{b + 1})
 		if err != nil {
 			log.Error("Database compaction failed", "err", err)
 			return err
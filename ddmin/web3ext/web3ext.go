@@ -63,6 +63,12 @@ web3._extend({
 			call: 'dpos_getSignersAtHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getSignerStatus',
+			call: 'dpos_getSignerStatus',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'propose',
 			call: 'dpos_propose',
@@ -79,6 +85,10 @@ web3._extend({
 			name: 'proposals',
 			getter: 'dpos_proposals'
 		}),
+		new web3._extend.Property({
+			name: 'paramProposals',
+			getter: 'dpos_paramProposals'
+		}),
 	]
 });
 `
@@ -344,6 +354,28 @@ web3._extend({
 			params: 2,
 			inputFormatter:[null, null],
 		}),
+		new web3._extend.Method({
+			name: 'getStateDiff',
+			call: 'debug_getStateDiff',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockWitness',
+			call: 'debug_getBlockWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'trustedCheckpoint',
+			call: 'debug_trustedCheckpoint',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'trustedAnchor',
+			call: 'debug_trustedAnchor',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -390,8 +422,34 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getFinalizedBlock',
+			call: 'ddm_getFinalizedBlock',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sendPrivateTransaction',
+			call: 'ddm_sendPrivateTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'ddm_sendBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'ddm_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.toHex, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'ddm_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'ddm_pendingTransactions',
@@ -0,0 +1,908 @@
+
+package ddmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/prometheus/prometheus/util/flock"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	bitcaskMarker       = "BITCASK"
+	bitcaskSegmentSize  = 64 * 1024 * 1024
+	bitcaskFrameHeader  = 8
+	bitcaskLocationSize = 16
+	bitcaskRewriteBatch = 1024 * 1024
+
+	bitcaskIndexGarbage = 1024 * 1024
+)
+
+const (
+	bitcaskOpPut byte = iota
+	bitcaskOpDelete
+)
+
+var (
+	errBitcaskCorrupted = errors.New("corrupted record")
+	bitcaskCRCTable     = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// bitcaskLocation points at a value within a segment.
+type bitcaskLocation struct {
+	segment uint32
+	offset  int64
+	size    uint32
+}
+
+func (loc bitcaskLocation) encode() []byte {
+	enc := make([]byte, bitcaskLocationSize)
+	binary.BigEndian.PutUint32(enc, loc.segment)
+	binary.BigEndian.PutUint64(enc[4:], uint64(loc.offset))
+	binary.BigEndian.PutUint32(enc[12:], loc.size)
+	return enc
+}
+
+func decodeBitcaskLocation(enc []byte) bitcaskLocation {
+	return bitcaskLocation{
+		segment: binary.BigEndian.Uint32(enc),
+		offset:  int64(binary.BigEndian.Uint64(enc[4:])),
+		size:    binary.BigEndian.Uint32(enc[12:]),
+	}
+}
+
+// bitcaskSegment is one file of the log. Only the newest one is written to.
+type bitcaskSegment struct {
+	id         uint32
+	file       *os.File
+	size       int64  // Bytes written to the file
+	live       int64  // Bytes of the keys and values the index still points at
+	tombstones int    // Deletions recorded in the segment
+	refs       int    // Snapshots still reading from the segment
+	hint       []byte // Index entries of the records, saved once the segment is full
+}
+
+// BitcaskDatabase appends every batch as a checksummed record to the newest
+// segment file and keeps an in-memory index of where each key's value is.
+type BitcaskDatabase struct {
+	dir   string
+	flock flock.Releaser
+
+	index    *memdb.DB
+	garbage  int // Index entries overwritten or deleted since it was built
+	segments map[uint32]*bitcaskSegment
+	retired  map[uint32]*bitcaskSegment // Dropped segments still used by snapshots
+	active   *bitcaskSegment
+
+	compacting  int32
+	compactLock sync.Mutex
+	closed      bool
+	wg          sync.WaitGroup
+	lock        sync.RWMutex
+
+	log log.Logger
+}
+
+// NewBitcaskDatabase opens the database in the given directory, creating it if needed.
+func NewBitcaskDatabase(dir string) (*BitcaskDatabase, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	release, _, err := flock.New(filepath.Join(dir, "LOCK"))
+	if err != nil {
+		return nil, err
+	}
+	db := &BitcaskDatabase{
+		dir:      dir,
+		flock:    release,
+		index:    memdb.New(comparer.DefaultComparer, 0),
+		segments: make(map[uint32]*bitcaskSegment),
+		retired:  make(map[uint32]*bitcaskSegment),
+		log:      log.New("database", dir),
+	}
+	if err := db.open(); err != nil {
+		db.closeFiles()
+		return nil, err
+	}
+	db.log.Info("Opened bitcask database", "segments", len(db.segments), "keys", db.index.Len())
+	return db, nil
+}
+
+func (db *BitcaskDatabase) open() error {
+	marker := filepath.Join(db.dir, bitcaskMarker)
+	if _, err := os.Stat(marker); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(marker, []byte(EngineBitcask+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	files, err := ioutil.ReadDir(db.dir)
+	if err != nil {
+		return err
+	}
+	var ids []uint32
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".seg") {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), ".seg"), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for i, id := range ids {
+		seg, err := db.openSegment(id)
+		if err != nil {
+			return err
+		}
+		if i == len(ids)-1 {
+			if err := db.replay(seg, true); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := db.loadHint(seg); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			db.log.Warn("Replaying segment with unusable hint", "segment", id, "err", err)
+		}
+		if err := db.replay(seg, false); err != nil {
+			return err
+		}
+		db.writeHint(seg)
+	}
+	if len(ids) == 0 {
+		seg, err := db.openSegment(0)
+		if err != nil {
+			return err
+		}
+		db.active = seg
+	} else {
+		db.active = db.segments[ids[len(ids)-1]]
+	}
+	return nil
+}
+
+func (db *BitcaskDatabase) segmentPath(id uint32) string {
+	return filepath.Join(db.dir, fmt.Sprintf("%08d.seg", id))
+}
+
+func (db *BitcaskDatabase) hintPath(id uint32) string {
+	return filepath.Join(db.dir, fmt.Sprintf("%08d.hint", id))
+}
+
+func (db *BitcaskDatabase) openSegment(id uint32) (*bitcaskSegment, error) {
+	file, err := os.OpenFile(db.segmentPath(id), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	seg := &bitcaskSegment{id: id, file: file}
+	db.segments[id] = seg
+	return seg, nil
+}
+
+// replay loads the records of a segment into the index. Only the last segment
+// may end in a torn record, which is cut off.
+func (db *BitcaskDatabase) replay(seg *bitcaskSegment, last bool) error {
+	data, err := ioutil.ReadAll(seg.file)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	for offset < int64(len(data)) {
+		payload, err := decodeBitcaskFrame(data[offset:])
+		if err == nil {
+			err = db.apply(seg, offset, payload)
+		}
+		if err != nil {
+			if !last {
+				return fmt.Errorf("segment %d at offset %d: %v", seg.id, offset, err)
+			}
+			db.log.Warn("Truncating damaged log tail", "segment", seg.id, "offset", offset, "size", len(data), "err", err)
+			if err := seg.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		offset += bitcaskFrameHeader + int64(len(payload))
+	}
+	seg.size = offset
+	return nil
+}
+
+// writeHint saves the index entries of a full segment. A missing hint only
+// costs a replay of the segment.
+func (db *BitcaskDatabase) writeHint(seg *bitcaskSegment) {
+	hint := encodeBitcaskFrame(seg.hint)
+	seg.hint = nil
+	if err := ioutil.WriteFile(db.hintPath(seg.id), hint, 0644); err != nil {
+		db.log.Warn("Failed to write segment hint", "segment", seg.id, "err", err)
+	}
+}
+
+// loadHint loads the index entries of a full segment from its hint file.
+func (db *BitcaskDatabase) loadHint(seg *bitcaskSegment) error {
+	data, err := ioutil.ReadFile(db.hintPath(seg.id))
+	if err != nil {
+		return err
+	}
+	hint, err := decodeBitcaskFrame(data)
+	if err != nil {
+		return err
+	}
+	stat, err := seg.file.Stat()
+	if err != nil {
+		return err
+	}
+	valid := true
+	if err := decodeBitcaskHint(hint, func(op byte, key []byte, loc bitcaskLocation) {
+		valid = valid && (op == bitcaskOpDelete || loc.segment == seg.id && loc.offset+int64(loc.size) <= stat.Size())
+	}); err != nil {
+		return err
+	}
+	if !valid {
+		return errBitcaskCorrupted
+	}
+	seg.size = stat.Size()
+	return decodeBitcaskHint(hint, func(op byte, key []byte, loc bitcaskLocation) {
+		if op == bitcaskOpDelete {
+			db.update(key, nil)
+			seg.tombstones++
+			return
+		}
+		db.update(key, &loc)
+	})
+}
+
+func appendBitcaskHint(hint []byte, op byte, key []byte, loc bitcaskLocation) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	hint = append(hint, op)
+	hint = append(hint, buf[:binary.PutUvarint(buf[:], uint64(len(key)))]...)
+	if op == bitcaskOpPut {
+		hint = append(hint, loc.encode()...)
+	}
+	return append(hint, key...)
+}
+
+func decodeBitcaskHint(hint []byte, fn func(op byte, key []byte, loc bitcaskLocation)) error {
+	for pos := 0; pos < len(hint); {
+		op := hint[pos]
+		pos++
+		keySize, n := binary.Uvarint(hint[pos:])
+		if n <= 0 {
+			return errBitcaskCorrupted
+		}
+		pos += n
+		var loc bitcaskLocation
+		switch op {
+		case bitcaskOpPut:
+			if len(hint)-pos < bitcaskLocationSize {
+				return errBitcaskCorrupted
+			}
+			loc = decodeBitcaskLocation(hint[pos:])
+			pos += bitcaskLocationSize
+		case bitcaskOpDelete:
+		default:
+			return errBitcaskCorrupted
+		}
+		if uint64(len(hint)-pos) < keySize {
+			return errBitcaskCorrupted
+		}
+		fn(op, hint[pos:pos+int(keySize)], loc)
+		pos += int(keySize)
+	}
+	return nil
+}
+
+func decodeBitcaskFrame(data []byte) ([]byte, error) {
+	if len(data) < bitcaskFrameHeader {
+		return nil, errBitcaskCorrupted
+	}
+	size := binary.BigEndian.Uint32(data[4:])
+	if uint64(len(data)) < bitcaskFrameHeader+uint64(size) {
+		return nil, errBitcaskCorrupted
+	}
+	payload := data[bitcaskFrameHeader : bitcaskFrameHeader+size]
+	if crc32.Checksum(payload, bitcaskCRCTable) != binary.BigEndian.Uint32(data) {
+		return nil, errBitcaskCorrupted
+	}
+	return payload, nil
+}
+
+// decodeBitcaskOps calls fn with every operation of a record and its value offset.
+func decodeBitcaskOps(payload []byte, fn func(op byte, key []byte, offset, size int)) error {
+	for pos := 0; pos < len(payload); {
+		op := payload[pos]
+		pos++
+		keySize, n := binary.Uvarint(payload[pos:])
+		if n <= 0 {
+			return errBitcaskCorrupted
+		}
+		pos += n
+		var valueSize uint64
+		switch op {
+		case bitcaskOpPut:
+			if valueSize, n = binary.Uvarint(payload[pos:]); n <= 0 {
+				return errBitcaskCorrupted
+			}
+			pos += n
+		case bitcaskOpDelete:
+		default:
+			return errBitcaskCorrupted
+		}
+		if uint64(len(payload)-pos) < keySize+valueSize {
+			return errBitcaskCorrupted
+		}
+		key := payload[pos : pos+int(keySize)]
+		pos += int(keySize)
+		fn(op, key, pos, int(valueSize))
+		pos += int(valueSize)
+	}
+	return nil
+}
+
+// apply updates the index with a record, unless it is malformed.
+func (db *BitcaskDatabase) apply(seg *bitcaskSegment, offset int64, payload []byte) error {
+	if err := decodeBitcaskOps(payload, func(byte, []byte, int, int) {}); err != nil {
+		return err
+	}
+	return decodeBitcaskOps(payload, func(op byte, key []byte, pos, size int) {
+		if op == bitcaskOpDelete {
+			db.update(key, nil)
+			seg.tombstones++
+			seg.hint = appendBitcaskHint(seg.hint, op, key, bitcaskLocation{})
+			return
+		}
+		loc := bitcaskLocation{segment: seg.id, offset: offset + bitcaskFrameHeader + int64(pos), size: uint32(size)}
+		db.update(key, &loc)
+		seg.hint = appendBitcaskHint(seg.hint, op, key, loc)
+	})
+}
+
+// update points the index entry of key at loc, or deletes it if loc is nil.
+func (db *BitcaskDatabase) update(key []byte, loc *bitcaskLocation) {
+	if enc, err := db.index.Get(key); err == nil {
+		prev := decodeBitcaskLocation(enc)
+		if seg := db.segments[prev.segment]; seg != nil {
+			seg.live -= int64(len(key)) + int64(prev.size)
+		}
+		db.garbage++
+	}
+	if loc == nil {
+		db.index.Delete(key)
+		return
+	}
+	db.index.Put(key, loc.encode())
+	db.segments[loc.segment].live += int64(len(key)) + int64(loc.size)
+}
+
+func (db *BitcaskDatabase) Path() string {
+	return db.dir
+}
+
+func (db *BitcaskDatabase) Put(key []byte, value []byte) error {
+	b := db.NewBatch()
+	b.Put(key, value)
+	return b.Write()
+}
+
+func (db *BitcaskDatabase) Delete(key []byte) error {
+	b := db.NewBatch()
+	b.Delete(key)
+	return b.Write()
+}
+
+func (db *BitcaskDatabase) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return false, errClosed
+	}
+	return db.index.Contains(key), nil
+}
+
+func (db *BitcaskDatabase) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, errClosed
+	}
+	return readBitcask(db.index, db.segments, key)
+}
+
+func readBitcask(index *memdb.DB, segments map[uint32]*bitcaskSegment, key []byte) ([]byte, error) {
+	enc, err := index.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	loc := decodeBitcaskLocation(enc)
+	value := make([]byte, loc.size)
+	if _, err := segments[loc.segment].file.ReadAt(value, loc.offset); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// write appends a record to the log and applies it to the index.
+func (db *BitcaskDatabase) write(payload []byte) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	frame := encodeBitcaskFrame(payload)
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.append(frame)
+}
+
+func encodeBitcaskFrame(payload []byte) []byte {
+	frame := make([]byte, bitcaskFrameHeader+len(payload))
+	binary.BigEndian.PutUint32(frame, crc32.Checksum(payload, bitcaskCRCTable))
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(payload)))
+	copy(frame[bitcaskFrameHeader:], payload)
+	return frame
+}
+
+// append writes a frame to the active segment and applies it to the index.
+func (db *BitcaskDatabase) append(frame []byte) error {
+	if db.closed {
+		return errClosed
+	}
+	if db.active.size > 0 && db.active.size+int64(len(frame)) > bitcaskSegmentSize {
+		if err := db.rotate(); err != nil {
+			return err
+		}
+	}
+	seg := db.active
+	if _, err := seg.file.WriteAt(frame, seg.size); err != nil {
+		return err
+	}
+	if err := db.apply(seg, seg.size, frame[bitcaskFrameHeader:]); err != nil {
+		return err
+	}
+	seg.size += int64(len(frame))
+	return nil
+}
+
+// rotate starts a new active segment, compacting the old ones if worth it.
+func (db *BitcaskDatabase) rotate() error {
+	if err := db.active.file.Sync(); err != nil {
+		return err
+	}
+	db.writeHint(db.active)
+
+	seg, err := db.openSegment(db.active.id + 1)
+	if err != nil {
+		return err
+	}
+	db.active = seg
+
+	if len(db.compactable()) > 0 && atomic.CompareAndSwapInt32(&db.compacting, 0, 1) {
+		db.wg.Add(1)
+		go db.compactBackground()
+	}
+	return nil
+}
+
+// compactable returns the segments that are more than half garbage, except for
+// those left with only deletions.
+func (db *BitcaskDatabase) compactable() map[uint32]bool {
+	ids := make(map[uint32]bool)
+	for id, seg := range db.segments {
+		if seg != db.active && seg.live*2 < seg.size && (seg.live > 0 || seg.tombstones == 0) {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+func (db *BitcaskDatabase) compactBackground() {
+	defer db.wg.Done()
+	defer atomic.StoreInt32(&db.compacting, 0)
+
+	db.compactLock.Lock()
+	defer db.compactLock.Unlock()
+
+	db.lock.RLock()
+	ids := db.compactable()
+	move := false
+	for id := range ids {
+		move = move || db.segments[id].live > 0
+	}
+	db.lock.RUnlock()
+
+	var err error
+	if move {
+		err = db.rewrite(nil, nil, func(seg *bitcaskSegment) bool { return ids[seg.id] })
+	}
+	if err == nil {
+		err = db.dropSegments(false)
+	}
+	if err != nil && err != errClosed {
+		db.log.Error("Background compaction failed", "err", err)
+	}
+}
+
+// Compact moves the live values of the key range out of the older segments.
+func (db *BitcaskDatabase) Compact(start, limit []byte) error {
+	db.compactLock.Lock()
+	defer db.compactLock.Unlock()
+
+	db.lock.Lock()
+	if db.closed {
+		db.lock.Unlock()
+		return errClosed
+	}
+	if db.active.size > 0 {
+		if err := db.rotate(); err != nil {
+			db.lock.Unlock()
+			return err
+		}
+	}
+	active := db.active.id
+	db.lock.Unlock()
+
+	if err := db.rewrite(start, limit, func(seg *bitcaskSegment) bool { return seg.id < active }); err != nil {
+		return err
+	}
+	return db.dropSegments(true)
+}
+
+// rewrite copies the values of the key range in the picked segments to the active one.
+func (db *BitcaskDatabase) rewrite(start, limit []byte, pick func(*bitcaskSegment) bool) error {
+	db.lock.RLock()
+	index := db.index
+	db.lock.RUnlock()
+
+	type moved struct {
+		key   []byte
+		prev  []byte
+		value []byte
+	}
+	var (
+		entries []moved
+		size    int
+	)
+	flush := func() error {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		// Keys written to since they were read are left out, a stale copy
+		// logged after their new value would win once the log is replayed.
+		var payload []byte
+		for _, entry := range entries {
+			if enc, err := db.index.Get(entry.key); err == nil && string(enc) == string(entry.prev) {
+				payload = appendBitcaskOp(payload, bitcaskOpPut, entry.key, entry.value)
+			}
+		}
+		entries, size = entries[:0], 0
+		if len(payload) == 0 {
+			return nil
+		}
+		return db.append(encodeBitcaskFrame(payload))
+	}
+	it := index.NewIterator(&util.Range{Start: start, Limit: limit})
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+
+		db.lock.RLock()
+		if db.closed {
+			db.lock.RUnlock()
+			return errClosed
+		}
+		enc, err := db.index.Get(key)
+		if err != nil {
+			db.lock.RUnlock()
+			continue
+		}
+		loc := decodeBitcaskLocation(enc)
+		if seg := db.segments[loc.segment]; seg == db.active || !pick(seg) {
+			db.lock.RUnlock()
+			continue
+		}
+		value, err := readBitcask(db.index, db.segments, key)
+		db.lock.RUnlock()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, moved{key: key, prev: enc, value: value})
+		size += len(key) + len(value)
+
+		if size >= bitcaskRewriteBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// dropSegments deletes the segments without live data. Deletions are kept as long
+// as an older segment may hold the deleted values, or replaying would revive them.
+func (db *BitcaskDatabase) dropSegments(force bool) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return errClosed
+	}
+	ids := make([]uint32, 0, len(db.segments))
+	for id := range db.segments {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var (
+		kept   bool
+		synced bool
+		freed  int64
+		count  int
+	)
+	for _, id := range ids {
+		seg := db.segments[id]
+		if seg == db.active || seg.live > 0 || (seg.tombstones > 0 && kept) {
+			kept = true
+			continue
+		}
+		if !synced {
+			if err := db.active.file.Sync(); err != nil {
+				return err
+			}
+			synced = true
+		}
+		delete(db.segments, id)
+		if seg.refs == 0 {
+			db.removeSegment(seg)
+		} else {
+			db.retired[id] = seg
+		}
+		freed += seg.size
+		count++
+	}
+	if count > 0 {
+		db.log.Info("Compacted bitcask database", "dropped", count, "freed", freed, "segments", len(db.segments))
+	}
+	if force && db.garbage > 0 || db.garbage > bitcaskIndexGarbage && db.garbage > db.index.Len() {
+		index := memdb.New(comparer.DefaultComparer, db.index.Size())
+		it := db.index.NewIterator(nil)
+		for it.Next() {
+			index.Put(it.Key(), it.Value())
+		}
+		it.Release()
+		db.index, db.garbage = index, 0
+	}
+	return nil
+}
+
+func (db *BitcaskDatabase) removeSegment(seg *bitcaskSegment) {
+	seg.file.Close()
+	if err := os.Remove(db.hintPath(seg.id)); err != nil && !os.IsNotExist(err) {
+		db.log.Error("Failed to delete segment hint", "segment", seg.id, "err", err)
+	}
+	if err := os.Remove(db.segmentPath(seg.id)); err != nil {
+		db.log.Error("Failed to delete segment", "segment", seg.id, "err", err)
+	}
+}
+
+// NewIterator may or may not see the writes made while iterating.
+func (db *BitcaskDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithRange(nil, nil)
+}
+
+func (db *BitcaskDatabase) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return &bitcaskIterator{Iterator: db.index.NewIterator(&util.Range{Start: start, Limit: limit}), get: db.Get}
+}
+
+func (db *BitcaskDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	r := util.BytesPrefix(prefix)
+	return db.NewIteratorWithRange(r.Start, r.Limit)
+}
+
+// NewSnapshot copies the index, which is expensive on large databases.
+func (db *BitcaskDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return nil, errClosed
+	}
+	snap := &bitcaskSnapshot{
+		db:       db,
+		index:    memdb.New(comparer.DefaultComparer, db.index.Size()),
+		segments: make(map[uint32]*bitcaskSegment, len(db.segments)),
+	}
+	it := db.index.NewIterator(nil)
+	for it.Next() {
+		snap.index.Put(it.Key(), it.Value())
+	}
+	it.Release()
+
+	for id, seg := range db.segments {
+		seg.refs++
+		snap.segments[id] = seg
+	}
+	return snap, nil
+}
+
+func (db *BitcaskDatabase) Close() {
+	db.lock.Lock()
+	db.closed = true
+	db.lock.Unlock()
+
+	db.wg.Wait()
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.closeFiles()
+	db.log.Info("Database closed")
+}
+
+func (db *BitcaskDatabase) closeFiles() {
+	if db.active != nil {
+		if err := db.active.file.Sync(); err != nil {
+			db.log.Error("Failed to sync active segment", "segment", db.active.id, "err", err)
+		}
+	}
+	for _, seg := range db.segments {
+		if err := seg.file.Close(); err != nil {
+			db.log.Error("Failed to close segment", "segment", seg.id, "err", err)
+		}
+	}
+	for _, seg := range db.retired {
+		db.removeSegment(seg)
+	}
+	if err := db.flock.Release(); err != nil {
+		db.log.Error("Failed to release database lock", "err", err)
+	}
+}
+
+func (db *BitcaskDatabase) NewBatch() Batch {
+	return &bitcaskBatch{db: db}
+}
+
+func appendBitcaskOp(payload []byte, op byte, key, value []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	payload = append(payload, op)
+	payload = append(payload, buf[:binary.PutUvarint(buf[:], uint64(len(key)))]...)
+	if op == bitcaskOpPut {
+		payload = append(payload, buf[:binary.PutUvarint(buf[:], uint64(len(value)))]...)
+	}
+	payload = append(payload, key...)
+	return append(payload, value...)
+}
+
+type bitcaskBatch struct {
+	db      *BitcaskDatabase
+	payload []byte
+	size    int
+}
+
+func (b *bitcaskBatch) Put(key, value []byte) error {
+	b.payload = appendBitcaskOp(b.payload, bitcaskOpPut, key, value)
+	b.size += len(value)
+	return nil
+}
+
+func (b *bitcaskBatch) Delete(key []byte) error {
+	b.payload = appendBitcaskOp(b.payload, bitcaskOpDelete, key, nil)
+	b.size += 1
+	return nil
+}
+
+func (b *bitcaskBatch) Write() error {
+	return b.db.write(b.payload)
+}
+
+func (b *bitcaskBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *bitcaskBatch) Reset() {
+	b.payload = b.payload[:0]
+	b.size = 0
+}
+
+// bitcaskIterator walks the index, reading each value from the log when asked.
+type bitcaskIterator struct {
+	iterator.Iterator
+	get func(key []byte) ([]byte, error)
+	err error
+}
+
+func (it *bitcaskIterator) Value() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	value, err := it.get(it.Key())
+	if err != nil && it.err == nil {
+		it.err = err
+	}
+	return value
+}
+
+func (it *bitcaskIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iterator.Error()
+}
+
+type bitcaskSnapshot struct {
+	db       *BitcaskDatabase
+	index    *memdb.DB
+	segments map[uint32]*bitcaskSegment
+	released bool
+}
+
+func (s *bitcaskSnapshot) Get(key []byte) ([]byte, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed || s.released {
+		return nil, errClosed
+	}
+	return readBitcask(s.index, s.segments, key)
+}
+
+func (s *bitcaskSnapshot) Has(key []byte) (bool, error) {
+	return s.index.Contains(key), nil
+}
+
+func (s *bitcaskSnapshot) NewIterator() iterator.Iterator {
+	return s.NewIteratorWithRange(nil, nil)
+}
+
+func (s *bitcaskSnapshot) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	return &bitcaskIterator{Iterator: s.index.NewIterator(&util.Range{Start: start, Limit: limit}), get: s.Get}
+}
+
+func (s *bitcaskSnapshot) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	r := util.BytesPrefix(prefix)
+	return s.NewIteratorWithRange(r.Start, r.Limit)
+}
+
+// Release lets go of the segments, deleting those already compacted away.
+func (s *bitcaskSnapshot) Release() {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	if s.released {
+		return
+	}
+	s.released = true
+	for id, seg := range s.segments {
+		seg.refs--
+		if s.db.retired[id] == seg && seg.refs == 0 && !s.db.closed {
+			delete(s.db.retired, id)
+			s.db.removeSegment(seg)
+		}
+	}
+}
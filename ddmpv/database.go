@@ -14,6 +14,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -125,6 +126,26 @@ func (db *LDBDatabase) NewIterator() iterator.Iterator {
 	return db.db.NewIterator(nil, nil)
 }
 
+func (db *LDBDatabase) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	return db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (db *LDBDatabase) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
+func (db *LDBDatabase) Compact(start, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
 func (db *LDBDatabase) Close() {
 
 	db.quitLock.Lock()
@@ -253,6 +274,12 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += 1
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -266,6 +293,34 @@ func (b *ldbBatch) Reset() {
 	b.size = 0
 }
 
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(key, nil)
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) NewIterator() iterator.Iterator {
+	return s.snap.NewIterator(nil, nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	return s.snap.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -298,6 +353,91 @@ func (dt *table) Close() {
 
 }
 
+func (dt *table) NewIterator() iterator.Iterator {
+	return dt.NewIteratorWithRange(nil, nil)
+}
+
+func (dt *table) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	start, limit = prefixRange(dt.prefix, start, limit)
+	return &tableIterator{dt.db.NewIteratorWithRange(start, limit), dt.prefix}
+}
+
+func (dt *table) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return &tableIterator{dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)), dt.prefix}
+}
+
+func (dt *table) NewSnapshot() (Snapshot, error) {
+	snap, err := dt.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{snap: snap, prefix: dt.prefix}, nil
+}
+
+func (dt *table) Compact(start, limit []byte) error {
+	start, limit = prefixRange(dt.prefix, start, limit)
+	return dt.db.Compact(start, limit)
+}
+
+// prefixRange maps a key range of a table onto the underlying database.
+func prefixRange(prefix string, start, limit []byte) ([]byte, []byte) {
+	if limit == nil {
+		limit = util.BytesPrefix([]byte(prefix)).Limit
+	} else {
+		limit = append([]byte(prefix), limit...)
+	}
+	return append([]byte(prefix), start...), limit
+}
+
+// tableIterator strips the table prefix from the keys of an iterator over the
+// underlying database.
+type tableIterator struct {
+	iterator.Iterator
+	prefix string
+}
+
+func (it *tableIterator) Seek(key []byte) bool {
+	return it.Iterator.Seek(append([]byte(it.prefix), key...))
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
+type tableSnapshot struct {
+	snap   Snapshot
+	prefix string
+}
+
+func (ts *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return ts.snap.Get(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) Has(key []byte) (bool, error) {
+	return ts.snap.Has(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) NewIterator() iterator.Iterator {
+	return ts.NewIteratorWithRange(nil, nil)
+}
+
+func (ts *tableSnapshot) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	start, limit = prefixRange(ts.prefix, start, limit)
+	return &tableIterator{ts.snap.NewIteratorWithRange(start, limit), ts.prefix}
+}
+
+func (ts *tableSnapshot) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return &tableIterator{ts.snap.NewIteratorWithPrefix(append([]byte(ts.prefix), prefix...)), ts.prefix}
+}
+
+func (ts *tableSnapshot) Release() {
+	ts.snap.Release()
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -315,6 +455,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
@@ -0,0 +1,68 @@
+
+package ddmdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	EngineLevelDB = "leveldb"
+	EngineBitcask = "bitcask"
+)
+
+// Engines lists the supported on-disk database engines.
+var Engines = []string{EngineLevelDB, EngineBitcask}
+
+func IsEngine(name string) bool {
+	for _, engine := range Engines {
+		if name == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectEngine returns the engine of the database stored in dir, or an empty
+// string if there is none.
+func DetectEngine(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, bitcaskMarker)); err == nil {
+		return EngineBitcask
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err == nil {
+		return EngineLevelDB
+	}
+	return ""
+}
+
+// NewDiskDatabase opens the database stored in dir with the given engine. An
+// empty engine stands for the one the database was created with, or leveldb
+// for a new database.
+func NewDiskDatabase(engine string, dir string, cache int, handles int) (Database, error) {
+	existing := DetectEngine(dir)
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = EngineLevelDB
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s uses %s, not %s", dir, existing, engine)
+	}
+	switch engine {
+	case EngineLevelDB:
+		db, err := NewLDBDatabase(dir, cache, handles)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case EngineBitcask:
+		db, err := NewBitcaskDatabase(dir)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+	return nil, fmt.Errorf("unknown database engine %q", engine)
+}
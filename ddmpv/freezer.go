@@ -0,0 +1,273 @@
+
+package ddmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/ddmchain/go-ddmchain/sign"
+)
+
+const (
+	FreezerHeaderTable     = "headers"
+	FreezerHashTable       = "hashes"
+	FreezerBodiesTable     = "bodies"
+	FreezerReceiptTable    = "receipts"
+	FreezerDifficultyTable = "diffs"
+)
+
+// freezerNoCompression lists the tables not worth compressing.
+var freezerNoCompression = map[string]bool{
+	FreezerHeaderTable:     false,
+	FreezerHashTable:       true,
+	FreezerBodiesTable:     false,
+	FreezerReceiptTable:    false,
+	FreezerDifficultyTable: true,
+}
+
+// freezerTailFile holds the number of the first block in the freezer, if it
+// doesn't start at genesis.
+const freezerTailFile = "TAIL"
+
+var (
+	errUnknownTable    = errors.New("unknown table")
+	errFreezerNotEmpty = errors.New("freezer not empty")
+)
+
+// Freezer keeps the immutable part of the chain in append-only flat files, one
+// table per kind of data, each indexed by block number. A chain without the
+// history before some block has its freezer start there, at the tail.
+type Freezer struct {
+	frozen uint64
+	tail   uint64
+
+	datadir string
+	tables  map[string]*freezerTable
+}
+
+// NewFreezer opens the freezer in the given directory, creating it if needed.
+// Tables left behind longer than the others by a crash are cut back.
+func NewFreezer(datadir string) (*Freezer, error) {
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	freezer := &Freezer{datadir: datadir, tables: make(map[string]*freezerTable)}
+	if blob, err := ioutil.ReadFile(filepath.Join(datadir, freezerTailFile)); err == nil && len(blob) == 8 {
+		freezer.tail = binary.BigEndian.Uint64(blob)
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for name, noCompression := range freezerNoCompression {
+		table, err := newFreezerTable(datadir, name, noCompression)
+		if err != nil {
+			freezer.Close()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	frozen := uint64(0)
+	for i, name := range freezer.tableNames() {
+		if items := atomic.LoadUint64(&freezer.tables[name].items); i == 0 || items < frozen {
+			frozen = items
+		}
+	}
+	if err := freezer.truncate(frozen); err != nil {
+		freezer.Close()
+		return nil, err
+	}
+	freezer.frozen = freezer.tail + frozen
+
+	log.Info("Opened ancient database", "database", datadir, "tail", freezer.tail, "frozen", freezer.frozen)
+	return freezer, nil
+}
+
+func (f *Freezer) tableNames() []string {
+	return []string{FreezerHashTable, FreezerHeaderTable, FreezerBodiesTable, FreezerReceiptTable, FreezerDifficultyTable}
+}
+
+// Ancient returns an item of the given table.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	if table := f.tables[kind]; table != nil {
+		tail := atomic.LoadUint64(&f.tail)
+		if number < tail {
+			return nil, errOutOfBounds
+		}
+		return table.Retrieve(number - tail)
+	}
+	return nil, errUnknownTable
+}
+
+func (f *Freezer) HasAncient(kind string, number uint64) (bool, error) {
+	if table := f.tables[kind]; table != nil {
+		tail := atomic.LoadUint64(&f.tail)
+		return number >= tail && table.has(number-tail), nil
+	}
+	return false, errUnknownTable
+}
+
+// Ancients returns the number of the next block to freeze, one past the last
+// frozen one.
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// AncientTail returns the number of the first frozen block.
+func (f *Freezer) AncientTail() uint64 {
+	return atomic.LoadUint64(&f.tail)
+}
+
+// SetAncientTail moves the start of an empty freezer to the given block, for a
+// chain that has no history before it.
+func (f *Freezer) SetAncientTail(tail uint64) error {
+	if atomic.LoadUint64(&f.frozen) != atomic.LoadUint64(&f.tail) {
+		return errFreezerNotEmpty
+	}
+	blob := make([]byte, 8)
+	binary.BigEndian.PutUint64(blob, tail)
+
+	file, err := os.OpenFile(filepath.Join(f.datadir, freezerTailFile), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(blob); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&f.tail, tail)
+	atomic.StoreUint64(&f.frozen, tail)
+
+	log.Info("Moved ancient database tail", "tail", tail)
+	return nil
+}
+
+// AncientSize returns the disk space used by the given table.
+func (f *Freezer) AncientSize(kind string) (uint64, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.size(), nil
+	}
+	return 0, errUnknownTable
+}
+
+// AppendAncient adds the next block to the freezer. Either all of its parts
+// are stored or none are.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
+	if frozen := atomic.LoadUint64(&f.frozen); number != frozen {
+		return fmt.Errorf("appending unexpected block: want %d, have %d", frozen, number)
+	}
+	tail := atomic.LoadUint64(&f.tail)
+	defer func() {
+		if err != nil {
+			if rerr := f.truncate(number - tail); rerr != nil {
+				log.Error("Failed to roll back ancient block", "number", number, "err", rerr)
+			}
+		}
+	}()
+	items := map[string][]byte{
+		FreezerHashTable:       hash,
+		FreezerHeaderTable:     header,
+		FreezerBodiesTable:     body,
+		FreezerReceiptTable:    receipts,
+		FreezerDifficultyTable: td,
+	}
+	for _, name := range f.tableNames() {
+		if err := f.tables[name].Append(number-tail, items[name]); err != nil {
+			return err
+		}
+	}
+	atomic.AddUint64(&f.frozen, 1)
+	return nil
+}
+
+// TruncateAncients drops every block from the given number on.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	if atomic.LoadUint64(&f.frozen) <= items {
+		return nil
+	}
+	tail := atomic.LoadUint64(&f.tail)
+	if items < tail {
+		items = tail
+	}
+	if err := f.truncate(items - tail); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+func (f *Freezer) truncate(items uint64) error {
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes all tables to disk.
+func (f *Freezer) Sync() error {
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// FreezerDatabase is a key value database whose immutable blocks have been
+// moved out to a freezer.
+type FreezerDatabase struct {
+	Database
+	*Freezer
+}
+
+// NewFreezerDatabase opens the freezer in the given directory on top of db.
+func NewFreezerDatabase(db Database, freezer string) (*FreezerDatabase, error) {
+	frdb, err := NewFreezer(freezer)
+	if err != nil {
+		return nil, err
+	}
+	return &FreezerDatabase{Database: db, Freezer: frdb}, nil
+}
+
+func (db *FreezerDatabase) Close() {
+	if err := db.Freezer.Close(); err != nil {
+		log.Error("Failed to close ancient database", "err", err)
+	}
+	db.Database.Close()
+}
+
+// LevelDB returns the leveldb database backing db, if any.
+func LevelDB(db Database) (*LDBDatabase, bool) {
+	switch db := db.(type) {
+	case *LDBDatabase:
+		return db, true
+	case *FreezerDatabase:
+		return LevelDB(db.Database)
+	}
+	return nil, false
+}
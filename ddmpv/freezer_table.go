@@ -0,0 +1,249 @@
+
+package ddmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/golang/snappy"
+)
+
+const indexEntrySize = 8
+
+var (
+	errOutOfBounds = errors.New("out of bounds")
+	errClosed      = errors.New("closed")
+)
+
+// freezerTable is an append-only table of items numbered from zero. The items
+// are stored back to back in a data file, the index file holds the offset at
+// which each of them ends.
+type freezerTable struct {
+	items uint64
+
+	noCompression bool
+	index         *os.File
+	data          *os.File
+	head          uint64
+
+	lock   sync.RWMutex
+	logger log.Logger
+}
+
+func newFreezerTable(dir, name string, noCompression bool) (*freezerTable, error) {
+	ext := "c"
+	if noCompression {
+		ext = "r"
+	}
+	index, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s.%sidx", name, ext)), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s.%sdat", name, ext)), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	tab := &freezerTable{
+		noCompression: noCompression,
+		index:         index,
+		data:          data,
+		logger:        log.New("table", name),
+	}
+	if err := tab.repair(); err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+// repair drops whatever was left behind by an append interrupted midway, data
+// not covered by the index or index entries pointing past the data.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	indexSize := stat.Size() - stat.Size()%indexEntrySize
+
+	if stat, err = t.data.Stat(); err != nil {
+		return err
+	}
+	dataSize := uint64(stat.Size())
+
+	var end uint64
+	for ; indexSize > 0; indexSize -= indexEntrySize {
+		if end, err = t.readOffset(uint64(indexSize/indexEntrySize) - 1); err != nil {
+			return err
+		}
+		if end <= dataSize {
+			break
+		}
+	}
+	if indexSize == 0 {
+		end = 0
+	}
+	if err := t.index.Truncate(indexSize); err != nil {
+		return err
+	}
+	if end < dataSize {
+		t.logger.Warn("Truncating dangling table data", "size", dataSize, "end", end)
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.items, t.head = uint64(indexSize/indexEntrySize), end
+	return nil
+}
+
+func (t *freezerTable) readOffset(item uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// Append adds the next item to the table. Nothing is flushed to disk until
+// Sync is called.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if items := atomic.LoadUint64(&t.items); item != items {
+		return fmt.Errorf("appending unexpected item: want %d, have %d", items, item)
+	}
+	if !t.noCompression {
+		blob = snappy.Encode(nil, blob)
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.head)); err != nil {
+		return err
+	}
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], t.head+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return err
+	}
+	t.head += uint64(len(blob))
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve returns the item with the given number.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= atomic.LoadUint64(&t.items) {
+		return nil, errOutOfBounds
+	}
+	var (
+		start uint64
+		err   error
+	)
+	if item > 0 {
+		if start, err = t.readOffset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.readOffset(item)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	if t.noCompression {
+		return blob, nil
+	}
+	return snappy.Decode(nil, blob)
+}
+
+// has reports whether the item with the given number is in the table.
+func (t *freezerTable) has(item uint64) bool {
+	return atomic.LoadUint64(&t.items) > item
+}
+
+// truncate drops every item from the given number on.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	var (
+		end uint64
+		err error
+	)
+	if items > 0 {
+		if end, err = t.readOffset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.head = end
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// size returns the disk space used by the table.
+func (t *freezerTable) size() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.head + atomic.LoadUint64(&t.items)*indexEntrySize
+}
+
+// Sync flushes the data file and then the index, so a crash never leaves the
+// index pointing at data that was not written.
+func (t *freezerTable) Sync() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	for _, f := range []*os.File{t.index, t.data} {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.index, t.data = nil, nil
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
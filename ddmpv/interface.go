@@ -1,6 +1,8 @@
 
 package ddmdb
 
+import "github.com/syndtr/goleveldb/leveldb/iterator"
+
 const IdealBatchSize = 100 * 1024
 
 type Putter interface {
@@ -14,12 +16,62 @@ type Database interface {
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+	Iteratee
+	Snapshotter
+	Compacter
 }
 
 type Batch interface {
 	Putter
+	Delete(key []byte) error
 	ValueSize() int 
 	Write() error
 
 	Reset()
 }
+
+// Iteratee is implemented by stores that can walk their contents in key order.
+// A range covers the keys from start up to but excluding limit, a nil bound
+// leaves that side open.
+type Iteratee interface {
+	NewIterator() iterator.Iterator
+	NewIteratorWithRange(start, limit []byte) iterator.Iterator
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
+// Snapshot is a read only view of a database frozen at the moment it was taken.
+// It must be released once no longer needed.
+type Snapshot interface {
+	Iteratee
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	Release()
+}
+
+type Snapshotter interface {
+	NewSnapshot() (Snapshot, error)
+}
+
+// Compacter is implemented by databases that can reclaim the disk space held
+// by overwritten and deleted data within a key range.
+type Compacter interface {
+	Compact(start, limit []byte) error
+}
+
+// AncientReader is implemented by databases keeping the immutable part of the
+// chain in a freezer.
+type AncientReader interface {
+	Ancient(kind string, number uint64) ([]byte, error)
+	HasAncient(kind string, number uint64) (bool, error)
+	Ancients() uint64
+	AncientTail() uint64
+	AncientSize(kind string) (uint64, error)
+}
+
+type AncientStore interface {
+	AncientReader
+	AppendAncient(number uint64, hash, header, body, receipts, td []byte) error
+	TruncateAncients(items uint64) error
+	SetAncientTail(tail uint64) error
+	Sync() error
+}
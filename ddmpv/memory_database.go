@@ -2,10 +2,15 @@
 package ddmdb
 
 import (
+	"bytes"
 	"errors"
 	"sync"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type MemDatabase struct {
@@ -62,6 +67,47 @@ func (db *MemDatabase) Keys() [][]byte {
 	return keys
 }
 
+// NewIterator returns an iterator over a point in time copy of the database
+// contents, ordered by key.
+func (db *MemDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithRange(nil, nil)
+}
+
+func (db *MemDatabase) NewIteratorWithRange(start, limit []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := memdb.New(comparer.DefaultComparer, 0)
+	for key, value := range db.db {
+		if bytes.Compare([]byte(key), start) < 0 || (limit != nil && bytes.Compare([]byte(key), limit) >= 0) {
+			continue
+		}
+		snap.Put([]byte(key), value)
+	}
+	return snap.NewIterator(nil)
+}
+
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	r := util.BytesPrefix(prefix)
+	return db.NewIteratorWithRange(r.Start, r.Limit)
+}
+
+// NewSnapshot returns a copy of the database contents.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := &MemDatabase{db: make(map[string][]byte, len(db.db))}
+	for key, value := range db.db {
+		snap.db[key] = value
+	}
+	return memSnapshot{snap}, nil
+}
+
+func (db *MemDatabase) Compact(start, limit []byte) error {
+	return nil
+}
+
 func (db *MemDatabase) Delete(key []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -78,7 +124,16 @@ func (db *MemDatabase) NewBatch() Batch {
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
-type kv struct{ k, v []byte }
+type memSnapshot struct {
+	*MemDatabase
+}
+
+func (s memSnapshot) Release() {}
+
+type kv struct {
+	k, v []byte
+	del  bool
+}
 
 type memBatch struct {
 	db     *MemDatabase
@@ -87,16 +142,26 @@ type memBatch struct {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
@@ -15,6 +15,7 @@ import (
 	"github.com/ddmchain/go-ddmchain/general/mclock"
 	"github.com/ddmchain/go-ddmchain/rule"
 	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/major/vm"
 	"github.com/ddmchain/go-ddmchain/black"
@@ -33,6 +34,10 @@ var (
 	blockInsertTimer = metrics.NewTimer("chain/inserts")
 
 	ErrNoGenesis = errors.New("Genesis not found in chain")
+
+	ErrReorgFinalized = errors.New("reorg below finalized block")
+
+	ErrRewindFinalized = errors.New("rewind below finalized block")
 )
 
 const (
@@ -48,8 +53,12 @@ const (
 
 type CacheConfig struct {
 	Disabled      bool          
+	Pruning       bool          
+	Snapshot      bool          
+	History       bool          
 	TrieNodeLimit int           
 	TrieTimeLimit time.Duration 
+	ParallelTxs   bool          
 }
 
 type BlockChain struct {
@@ -60,6 +69,8 @@ type BlockChain struct {
 	triegc *prque.Prque   
 	gcproc time.Duration  
 
+	pruneCh chan *pruneJob
+
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
 	chainFeed     event.Feed
@@ -77,7 +88,12 @@ type BlockChain struct {
 	currentBlock     *types.Block 
 	currentFastBlock *types.Block 
 
+	currentFinalizedBlock *types.Block
+
+	trusted *params.TrustedCheckpoint
+
 	stateCache   state.Database 
+	snaps        *snapshot.Tree 
 	bodyCache    *lru.Cache     
 	bodyRLPCache *lru.Cache     
 	blockCache   *lru.Cache     
@@ -110,12 +126,19 @@ func NewBlockChain(db ddmdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 
+	stateCache := state.NewDatabase(db)
+	if cacheConfig.Snapshot {
+		var err error
+		if stateCache, err = state.NewDatabaseWithSnapshots(db); err != nil {
+			return nil, err
+		}
+	}
 	bc := &BlockChain{
 		chainConfig:  chainConfig,
 		cacheConfig:  cacheConfig,
 		db:           db,
 		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
+		stateCache:   stateCache,
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
@@ -153,7 +176,25 @@ func NewBlockChain(db ddmdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			}
 		}
 	}
+	if bc.snaps = bc.stateCache.Snapshots(); bc.snaps != nil {
+		bc.snaps.Load(bc.CurrentBlock().Root())
+	}
 
+	if store, ok := bc.db.(ddmdb.AncientStore); ok {
+		if head := bc.hc.CurrentHeader().Number.Uint64(); store.Ancients() > head+1 {
+			log.Warn("Truncating ancient blocks above head", "frozen", store.Ancients(), "head", head)
+			if err := store.TruncateAncients(head + 1); err != nil {
+				return nil, err
+			}
+		}
+		bc.wg.Add(1)
+		go bc.freeze(store)
+	}
+	if bc.cacheConfig.Pruning && !bc.cacheConfig.Disabled {
+		bc.pruneCh = make(chan *pruneJob, 1)
+		bc.wg.Add(1)
+		go bc.prune()
+	}
 	go bc.update()
 	return bc, nil
 }
@@ -203,6 +244,13 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	bc.currentFinalizedBlock = bc.genesisBlock
+	if head := GetFinalizedBlockHash(bc.db); head != (common.Hash{}) {
+		if block := bc.GetBlockByHash(head); block != nil && block.NumberU64() <= bc.currentBlock.NumberU64() && GetCanonicalHash(bc.db, block.NumberU64()) == head {
+			bc.currentFinalizedBlock = block
+		}
+	}
+
 	headerTd := bc.GetTd(currentHeader.Hash(), currentHeader.Number.Uint64())
 	blockTd := bc.GetTd(bc.currentBlock.Hash(), bc.currentBlock.NumberU64())
 	fastTd := bc.GetTd(bc.currentFastBlock.Hash(), bc.currentFastBlock.NumberU64())
@@ -210,6 +258,9 @@ func (bc *BlockChain) loadLastState() error {
 	log.Info("Loaded most recent local header", "number", currentHeader.Number, "hash", currentHeader.Hash(), "td", headerTd)
 	log.Info("Loaded most recent local full block", "number", bc.currentBlock.Number(), "hash", bc.currentBlock.Hash(), "td", blockTd)
 	log.Info("Loaded most recent local fast block", "number", bc.currentFastBlock.Number(), "hash", bc.currentFastBlock.Hash(), "td", fastTd)
+	if bc.currentFinalizedBlock.NumberU64() > 0 {
+		log.Info("Loaded most recent finalized block", "number", bc.currentFinalizedBlock.Number(), "hash", bc.currentFinalizedBlock.Hash())
+	}
 
 	return nil
 }
@@ -220,12 +271,28 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	if bc.currentFinalizedBlock != nil && head < bc.currentFinalizedBlock.NumberU64() {
+		log.Warn("Refusing rewind below finalized block", "target", head, "finalized", bc.currentFinalizedBlock.Number())
+		return ErrRewindFinalized
+	}
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.db, hash, num)
 	}
+	rewound := make(map[uint64]common.Hash)
+	if bc.cacheConfig.History && bc.currentBlock != nil {
+		for n := bc.currentBlock.NumberU64(); n > head; n-- {
+			rewound[n] = GetCanonicalHash(bc.db, n)
+		}
+	}
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	if store, ok := bc.db.(ddmdb.AncientStore); ok {
+		if err := store.TruncateAncients(currentHeader.Number.Uint64() + 1); err != nil {
+			return err
+		}
+	}
+
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
 	bc.blockCache.Purge()
@@ -257,7 +324,16 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	if err := WriteHeadFastBlockHash(bc.db, bc.currentFastBlock.Hash()); err != nil {
 		log.Crit("Failed to reset head fast block", "err", err)
 	}
-	return bc.loadLastState()
+	if err := bc.loadLastState(); err != nil {
+		return err
+	}
+	if bc.cacheConfig.History {
+		bc.rewindStateHistory(rewound)
+	}
+	if bc.snaps != nil && bc.snaps.Snapshot(bc.currentBlock.Root()) == nil {
+		bc.snaps.Rebuild(bc.currentBlock.Root())
+	}
+	return nil
 }
 
 func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
@@ -274,6 +350,9 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.currentBlock = block
 	bc.mu.Unlock()
 
+	if bc.snaps != nil {
+		bc.snaps.Rebuild(block.Root())
+	}
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -299,6 +378,36 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock
 }
 
+func (bc *BlockChain) CurrentFinalizedBlock() *types.Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.currentFinalizedBlock
+}
+
+func (bc *BlockChain) SetFinalizedBlock(hash common.Hash, number uint64) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.currentFinalizedBlock != nil && number <= bc.currentFinalizedBlock.NumberU64() {
+		return nil
+	}
+	if GetCanonicalHash(bc.db, number) != hash {
+		return fmt.Errorf("non-canonical block [%x…] cannot be finalized", hash[:4])
+	}
+	block := bc.GetBlock(hash, number)
+	if block == nil {
+		return fmt.Errorf("non existent block [%x…]", hash[:4])
+	}
+	if err := WriteFinalizedBlockHash(bc.db, hash); err != nil {
+		return err
+	}
+	bc.currentFinalizedBlock = block
+
+	log.Info("Finalized block", "number", number, "hash", hash)
+	return nil
+}
+
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
 	defer bc.procmu.Unlock()
@@ -328,7 +437,11 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 }
 
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	statedb, err := state.New(root, bc.stateCache)
+	if err == nil && bc.cacheConfig.History {
+		statedb.TrackDiffs()
+	}
+	return statedb, err
 }
 
 func (bc *BlockChain) Reset() error {
@@ -337,6 +450,14 @@ func (bc *BlockChain) Reset() error {
 
 func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 
+	bc.mu.Lock()
+	if err := WriteFinalizedBlockHash(bc.db, bc.genesisBlock.Hash()); err != nil {
+		bc.mu.Unlock()
+		return err
+	}
+	bc.currentFinalizedBlock = bc.genesisBlock
+	bc.mu.Unlock()
+
 	if err := bc.SetHead(0); err != nil {
 		return err
 	}
@@ -457,8 +578,12 @@ func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 	if bc.blockCache.Contains(hash) {
 		return true
 	}
-	ok, _ := bc.db.Has(blockBodyKey(hash, number))
-	return ok
+	return HasBody(bc.db, hash, number)
+}
+
+func (bc *BlockChain) HasTransaction(hash common.Hash) bool {
+	blockHash, _, _ := GetTxLookupEntry(bc.db, hash)
+	return blockHash != (common.Hash{})
 }
 
 func (bc *BlockChain) HasState(hash common.Hash) bool {
@@ -532,6 +657,10 @@ func (bc *BlockChain) TrieNode(hash common.Hash) ([]byte, error) {
 	return bc.stateCache.TrieDB().Node(hash)
 }
 
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 func (bc *BlockChain) Stop() {
 	if !atomic.CompareAndSwapInt32(&bc.running, 0, 1) {
 		return
@@ -543,6 +672,9 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	if bc.snaps != nil {
+		bc.persistSnapshot()
+	}
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 		if number := bc.CurrentBlock().NumberU64(); number >= triesInMemory {
@@ -551,6 +683,8 @@ func (bc *BlockChain) Stop() {
 			log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
 			if err := triedb.Commit(recent.Root(), true); err != nil {
 				log.Error("Failed to commit recent state trie", "err", err)
+			} else if bc.cacheConfig.Pruning {
+				WriteFlushedStateRoots(bc.db, append(GetFlushedStateRoots(bc.db), recent.Root()))
 			}
 		}
 		for !bc.triegc.Empty() {
@@ -563,6 +697,24 @@ func (bc *BlockChain) Stop() {
 	log.Info("Blockchain manager stopped")
 }
 
+// persistSnapshot flattens the state snapshot into the disk layer of the state
+// the chain resumes from after a restart and stops its generation.
+func (bc *BlockChain) persistSnapshot() {
+	root := bc.CurrentBlock().Root()
+	if !bc.cacheConfig.Disabled {
+		root = common.Hash{}
+		if number := bc.CurrentBlock().NumberU64(); number >= triesInMemory {
+			root = bc.GetBlockByNumber(number - triesInMemory + 1).Root()
+		}
+	}
+	if root != (common.Hash{}) && bc.snaps.Snapshot(root) != nil {
+		if err := bc.snaps.Cap(root, 0); err != nil {
+			log.Error("Failed to persist state snapshot", "err", err)
+		}
+	}
+	bc.snaps.Stop()
+}
+
 func (bc *BlockChain) procFutureBlocks() {
 	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
 	for _, hash := range bc.futureBlocks.Keys() {
@@ -768,6 +920,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	if err != nil {
 		return NonStatTy, err
 	}
+	stateDiff, err := bc.recordStateDiff(batch, block, state)
+	if err != nil {
+		return NonStatTy, err
+	}
+	if bc.snaps != nil && bc.snaps.Snapshot(root) != nil {
+		// Keep the disk layer one block above the oldest state held in memory,
+		// so that it can still be generated from the trie until the next block.
+		if err := bc.snaps.Cap(root, triesInMemory-1); err != nil {
+			log.Warn("Failed to cap state snapshot", "root", root, "err", err)
+		}
+	}
 	triedb := bc.stateCache.TrieDB()
 
 	if bc.cacheConfig.Disabled {
@@ -783,6 +946,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 
 			header := bc.GetHeaderByNumber(current - triesInMemory)
 			chosen := header.Number.Uint64()
+			flushed := false
 
 			var (
 				size  = triedb.Size()
@@ -803,6 +967,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 					triedb.Commit(header.Root, true)
 					lastWrite = chosen
 					bc.gcproc = 0
+					flushed = true
 				}
 			}
 
@@ -814,6 +979,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				}
 				triedb.Dereference(root.(common.Hash), common.Hash{})
 			}
+			if flushed && bc.cacheConfig.Pruning {
+				bc.schedulePrune(header.Root)
+			}
 		}
 	}
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
@@ -836,6 +1004,11 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		if err := WriteTxLookupEntries(batch, block); err != nil {
 			return NonStatTy, err
 		}
+		if stateDiff != nil {
+			if err := bc.writeCanonStateHistory(batch, block.NumberU64(), stateDiff); err != nil {
+				return NonStatTy, err
+			}
+		}
 
 		if err := WritePreimages(bc.db, block.NumberU64(), state.Preimages()); err != nil {
 			return NonStatTy, err
@@ -850,6 +1023,11 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 
 	if status == CanonStatTy {
 		bc.insert(block)
+
+		if bc.snaps != nil && bc.snaps.Snapshot(root) == nil {
+			log.Warn("State snapshot detached from chain head, rebuilding", "number", block.Number(), "root", root)
+			bc.snaps.Rebuild(root)
+		}
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
@@ -980,7 +1158,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
-		state, err := state.New(parent.Root(), bc.stateCache)
+		state, err := bc.StateAt(parent.Root())
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
@@ -1139,6 +1317,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 	}
 
+	if bc.currentFinalizedBlock != nil && commonBlock.NumberU64() < bc.currentFinalizedBlock.NumberU64() {
+		log.Warn("Refusing reorg below finalized block", "number", commonBlock.Number(), "hash", commonBlock.Hash(), "finalized", bc.currentFinalizedBlock.Number())
+		return ErrReorgFinalized
+	}
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
 		if len(oldChain) > 63 {
@@ -1150,6 +1332,11 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.Number(), "oldhash", oldBlock.Hash(), "newnum", newBlock.Number(), "newhash", newBlock.Hash())
 	}
 
+	if bc.cacheConfig.History {
+		if err := bc.reorgStateHistory(oldChain, newChain); err != nil {
+			return err
+		}
+	}
 	var addedTxs types.Transactions
 	for i := len(newChain) - 1; i >= 0; i-- {
 
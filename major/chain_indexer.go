@@ -285,6 +285,16 @@ func (c *ChainIndexer) processSection(section uint64, lastHead common.Hash) (com
 		return common.Hash{}, err
 	}
 
+	// Sections before a trusted checkpoint the chain was synced from have no
+	// history to index, they are skipped.
+	if first := section * c.sectionSize; GetCanonicalHash(c.chainDb, first) == (common.Hash{}) {
+		if anchor := GetTrustedAnchor(c.chainDb); anchor != (common.Hash{}) {
+			if number := GetBlockNumber(c.chainDb, anchor); number != missingNumber && first < number {
+				c.log.Debug("Skipping section before trusted anchor", "section", section, "anchor", number)
+				return GetCanonicalHash(c.chainDb, (section+1)*c.sectionSize-1), nil
+			}
+		}
+	}
 	for number := section * c.sectionSize; number < (section+1)*c.sectionSize; number++ {
 		hash := GetCanonicalHash(c.chainDb, number)
 		if hash == (common.Hash{}) {
@@ -293,7 +303,7 @@ func (c *ChainIndexer) processSection(section uint64, lastHead common.Hash) (com
 		header := GetHeader(c.chainDb, hash, number)
 		if header == nil {
 			return common.Hash{}, fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
-		} else if header.ParentHash != lastHead {
+		} else if header.ParentHash != lastHead && lastHead != (common.Hash{}) {
 			return common.Hash{}, fmt.Errorf("chain reorged during section processing")
 		}
 		c.backend.Process(header)
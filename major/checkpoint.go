@@ -0,0 +1,122 @@
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+var (
+	errNoTrustedCheckpoint = errors.New("no trusted checkpoint")
+	errCheckpointMismatch  = errors.New("headers don't end at the trusted checkpoint")
+	errCheckpointPassed    = errors.New("chain already past the trusted checkpoint")
+)
+
+// checkpointEngine is implemented by the consensus engines which need more than
+// the parent header to verify the chain on from a trusted checkpoint.
+type checkpointEngine interface {
+	TrustCheckpoint(header *types.Header, ancestors []*types.Header, signers []common.Address, snapshot []byte) error
+}
+
+// SetTrustedCheckpoint sets the checkpoint the chain may be anchored at when it
+// is synced from scratch.
+func (bc *BlockChain) SetTrustedCheckpoint(cp *params.TrustedCheckpoint) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.trusted = cp
+	if cp != nil {
+		log.Info("Loaded trusted checkpoint", "number", cp.Number, "hash", cp.Hash, "signers", len(cp.Signers))
+	}
+}
+
+// TrustedCheckpoint returns the checkpoint the chain may be anchored at.
+func (bc *BlockChain) TrustedCheckpoint() *params.TrustedCheckpoint {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.trusted
+}
+
+// TrustedAnchor returns the header of the checkpoint the chain was synced from,
+// or nil if it was synced from genesis.
+func (bc *BlockChain) TrustedAnchor() *types.Header {
+	hash := GetTrustedAnchor(bc.db)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return bc.GetHeaderByHash(hash)
+}
+
+// InsertCheckpoint anchors the chain at the trusted checkpoint. The headers,
+// which end at the checkpoint, are taken as canonical without their history and
+// the head header is moved to the checkpoint, from where the chain is extended
+// as usual.
+func (bc *BlockChain) InsertCheckpoint(headers []*types.Header) error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	cp := bc.trusted
+	if cp == nil {
+		return errNoTrustedCheckpoint
+	}
+	if len(headers) == 0 {
+		return errCheckpointMismatch
+	}
+	last := headers[len(headers)-1]
+	if last.Number.Uint64() != cp.Number || last.Hash() != cp.Hash {
+		return errCheckpointMismatch
+	}
+	for i := 1; i < len(headers); i++ {
+		if headers[i].Number.Uint64() != headers[i-1].Number.Uint64()+1 || headers[i].ParentHash != headers[i-1].Hash() {
+			return fmt.Errorf("non contiguous checkpoint ancestors: item %d is #%d, item %d is #%d", i-1, headers[i-1].Number, i, headers[i].Number)
+		}
+	}
+	if bc.hc.CurrentHeader().Number.Uint64() >= cp.Number {
+		return errCheckpointPassed
+	}
+	if engine, ok := bc.engine.(checkpointEngine); ok {
+		if err := engine.TrustCheckpoint(last, headers[:len(headers)-1], cp.Signers, cp.Snapshot); err != nil {
+			return err
+		}
+	}
+	var (
+		batch = bc.db.NewBatch()
+		td    = new(big.Int).Set(cp.Td)
+	)
+	for i := len(headers) - 1; i >= 0 && headers[i].Number.Sign() > 0; i-- {
+		header := headers[i]
+		if err := WriteHeader(batch, header); err != nil {
+			return err
+		}
+		if err := WriteTd(batch, header.Hash(), header.Number.Uint64(), td); err != nil {
+			return err
+		}
+		if err := WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64()); err != nil {
+			return err
+		}
+		td = new(big.Int).Sub(td, header.Difficulty)
+	}
+	if err := WriteTrustedAnchor(batch, cp.Hash); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.hc.SetCurrentHeader(last)
+
+	log.Info("Anchored chain at trusted checkpoint", "number", cp.Number, "hash", cp.Hash, "td", cp.Td, "ancestors", len(headers)-1)
+	return nil
+}
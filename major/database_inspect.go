@@ -0,0 +1,137 @@
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+)
+
+// DatabaseStat is the number and total size of the entries of one kind.
+type DatabaseStat struct {
+	Category string
+	Count    uint64
+	Size     common.StorageSize
+}
+
+// inspectCategories lists the kinds of entries told apart by InspectDatabase,
+// in the order they are reported. The dpos and upgrade marker keys are owned
+// by other packages and mirrored here.
+var inspectCategories = []string{
+	"Headers", "Total difficulties", "Canonical hashes", "Header numbers", "Bodies", "Receipts",
+	"Transaction lookups", "Bloombits", "Bloombits index", "State diffs", "Account history",
+	"Storage history", "History code", "Snapshot accounts", "Snapshot storage", "DPoS snapshots",
+	"Trie nodes and code", "Preimages", "Chain configs", "Legacy entries", "Metadata", "Unaccounted",
+}
+
+var inspectMetadataKeys = [][]byte{
+	headHeaderKey, headBlockKey, headFastKey, headFinalizedKey, trustedAnchorKey, flushedRootsKey, stateHistoryTailKey,
+	snapshot.RootKey, snapshot.GeneratorKey, []byte("LastFinalityVote"), []byte("dbUpgrade_20170714deduplicateData"),
+}
+
+// inspectCategory tells the kind of an entry from its key.
+func inspectCategory(key []byte) string {
+	var (
+		size   = len(key)
+		number = len(headerPrefix) + 8
+	)
+	switch {
+	case bytes.HasPrefix(key, oldReceiptsPrefix) && size == len(oldReceiptsPrefix)+common.HashLength:
+		return "Legacy entries"
+	case bytes.HasPrefix(key, []byte(preimagePrefix)):
+		return "Preimages"
+	case bytes.HasPrefix(key, configPrefix):
+		return "Chain configs"
+	case bytes.HasPrefix(key, []byte("dpos-")):
+		return "DPoS snapshots"
+	case bytes.HasPrefix(key, BloomBitsIndexPrefix):
+		return "Bloombits index"
+	case bytes.HasPrefix(key, headerPrefix) && size == number+common.HashLength:
+		return "Headers"
+	case bytes.HasPrefix(key, headerPrefix) && size == number+common.HashLength+len(tdSuffix) && bytes.HasSuffix(key, tdSuffix):
+		return "Total difficulties"
+	case bytes.HasPrefix(key, headerPrefix) && size == number+len(numSuffix) && bytes.HasSuffix(key, numSuffix):
+		return "Canonical hashes"
+	case bytes.HasPrefix(key, blockHashPrefix) && size == len(blockHashPrefix)+common.HashLength:
+		return "Header numbers"
+	case bytes.HasPrefix(key, bodyPrefix) && size == number+common.HashLength:
+		return "Bodies"
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && size == number+common.HashLength:
+		return "Receipts"
+	case bytes.HasPrefix(key, lookupPrefix) && size == len(lookupPrefix)+common.HashLength:
+		return "Transaction lookups"
+	case bytes.HasPrefix(key, bloomBitsPrefix) && size == len(bloomBitsPrefix)+10+common.HashLength:
+		return "Bloombits"
+	case bytes.HasPrefix(key, stateDiffPrefix) && size == len(stateDiffPrefix)+8+common.HashLength:
+		return "State diffs"
+	case bytes.HasPrefix(key, accountHistoryPrefix) && size == len(accountHistoryPrefix)+common.HashLength+8:
+		return "Account history"
+	case bytes.HasPrefix(key, storageHistoryPrefix) && size == len(storageHistoryPrefix)+2*common.HashLength+8:
+		return "Storage history"
+	case bytes.HasPrefix(key, historyCodePrefix) && size == len(historyCodePrefix)+common.HashLength:
+		return "History code"
+	case bytes.HasPrefix(key, snapshot.AccountPrefix) && size == 1+common.HashLength:
+		return "Snapshot accounts"
+	case bytes.HasPrefix(key, snapshot.StoragePrefix) && size == 1+2*common.HashLength:
+		return "Snapshot storage"
+	case size == common.HashLength:
+		return "Trie nodes and code"
+	case size == common.HashLength+len(oldTxMetaSuffix) && bytes.HasSuffix(key, oldTxMetaSuffix):
+		return "Legacy entries"
+	}
+	for _, meta := range inspectMetadataKeys {
+		if bytes.Equal(key, meta) {
+			return "Metadata"
+		}
+	}
+	return "Unaccounted"
+}
+
+// InspectDatabase walks the whole database and tallies its entries by kind,
+// followed by the tables of the ancient store if there is one.
+func InspectDatabase(db ddmdb.Database) ([]DatabaseStat, error) {
+	var (
+		it     = db.NewIterator()
+		tally  = make(map[string]*DatabaseStat)
+		start  = time.Now()
+		logged = time.Now()
+		count  uint64
+	)
+	defer it.Release()
+
+	for _, category := range inspectCategories {
+		tally[category] = &DatabaseStat{Category: category}
+	}
+	for it.Next() {
+		stat := tally[inspectCategory(it.Key())]
+		stat.Count++
+		stat.Size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if count++; time.Since(logged) > 8*time.Second {
+			log.Info("Inspecting database", "entries", count, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	stats := make([]DatabaseStat, 0, len(inspectCategories))
+	for _, category := range inspectCategories {
+		stats = append(stats, *tally[category])
+	}
+	if ancients, ok := db.(ddmdb.AncientReader); ok {
+		for _, table := range []string{ddmdb.FreezerHeaderTable, ddmdb.FreezerBodiesTable, ddmdb.FreezerReceiptTable, ddmdb.FreezerDifficultyTable, ddmdb.FreezerHashTable} {
+			size, err := ancients.AncientSize(table)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, DatabaseStat{Category: fmt.Sprintf("Ancient %s", table), Count: ancients.Ancients() - ancients.AncientTail(), Size: common.StorageSize(size)})
+		}
+	}
+	return stats, nil
+}
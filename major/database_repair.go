@@ -0,0 +1,154 @@
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+)
+
+// RepairHead walks back from the head block marker to the most recent block
+// whose header, body, receipts and state are all on disk, then points the head
+// markers and the canonical chain at it. Candidates are checked by walking
+// their whole state, which takes a while on a large one. It returns the new
+// head and the number of blocks rewound.
+func RepairHead(db ddmdb.Database) (*types.Block, uint64, error) {
+	hash := GetHeadBlockHash(db)
+	number := GetBlockNumber(db, hash)
+	if number == missingNumber {
+		log.Warn("Head block marker is broken, trying the head header", "hash", hash)
+		hash = GetHeadHeaderHash(db)
+		number = GetBlockNumber(db, hash)
+	}
+	if number == missingNumber {
+		number = highestCanonicalNumber(db)
+		hash = GetCanonicalHash(db, number)
+		log.Warn("Head header marker is broken, starting from the canonical chain", "number", number, "hash", hash)
+	}
+	var (
+		start   = number
+		statedb = state.NewDatabase(db)
+		block   *types.Block
+	)
+	for {
+		if block = completeBlock(db, statedb, hash, number); block != nil {
+			break
+		}
+		if number == 0 {
+			return nil, 0, errors.New("no block with complete data found")
+		}
+		if header := GetHeader(db, hash, number); header != nil {
+			hash = header.ParentHash
+		} else {
+			hash = GetCanonicalHash(db, number-1)
+		}
+		number--
+	}
+	var (
+		batch   = db.NewBatch()
+		reorged = number + 1
+	)
+	for header := block.Header(); header != nil && GetCanonicalHash(db, header.Number.Uint64()) != header.Hash(); {
+		WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64())
+		reorged = header.Number.Uint64()
+		if reorged == 0 {
+			break
+		}
+		header = GetHeader(db, header.ParentHash, header.Number.Uint64()-1)
+	}
+	repairMarkers(db, batch, block, reorged)
+	for n := number + 1; n <= start || GetCanonicalHash(db, n) != (common.Hash{}); n++ {
+		if hash := GetCanonicalHash(db, n); hash != (common.Hash{}) {
+			if diff := GetStateDiff(db, hash, n); diff != nil {
+				DeleteStateHistory(batch, n, diff)
+			}
+		}
+		DeleteCanonicalHash(batch, n)
+		if batch.ValueSize() >= ddmdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return nil, 0, err
+			}
+			batch.Reset()
+		}
+	}
+	WriteHeadHeaderHash(batch, block.Hash())
+	WriteHeadBlockHash(batch, block.Hash())
+	WriteHeadFastBlockHash(batch, block.Hash())
+	if err := batch.Write(); err != nil {
+		return nil, 0, err
+	}
+	return block, start - number, nil
+}
+
+// repairMarkers clamps the markers that must not point past the repaired head:
+// the finalized block is moved back to its last ancestor still canonical, and
+// the snapshot and the state history tail are dropped if they are ahead of the
+// head state. The old canonical chain is still in db, below reorged it is kept.
+func repairMarkers(db ddmdb.Database, batch ddmdb.Batch, block *types.Block, reorged uint64) {
+	if finalized := GetFinalizedBlockHash(db); finalized != (common.Hash{}) {
+		n := GetBlockNumber(db, finalized)
+		if n == missingNumber || n >= reorged {
+			n = 0
+			if reorged > 0 {
+				n = reorged - 1
+			}
+			hash := GetCanonicalHash(db, n)
+			log.Warn("Rewinding finalized block", "number", n, "hash", hash, "finalized", finalized)
+			WriteFinalizedBlockHash(batch, hash)
+		}
+	}
+	if root, _ := db.Get(snapshot.RootKey); len(root) > 0 && common.BytesToHash(root) != block.Root() {
+		log.Warn("Dropping state snapshot ahead of the head", "root", common.BytesToHash(root))
+		batch.Delete(snapshot.RootKey)
+		batch.Delete(snapshot.GeneratorKey)
+	}
+	if tail, ok := GetStateHistoryTail(db); ok && block.NumberU64()+1 < tail {
+		DeleteStateHistoryTail(batch)
+	}
+}
+
+// completeBlock returns the block with the given hash if it and its state are
+// complete.
+func completeBlock(db ddmdb.Database, statedb state.Database, hash common.Hash, number uint64) *types.Block {
+	block := GetBlock(db, hash, number)
+	if block == nil {
+		log.Warn("Block missing", "number", number, "hash", hash)
+		return nil
+	}
+	if len(block.Transactions()) > 0 && GetBlockReceipts(db, hash, number) == nil {
+		log.Warn("Block receipts missing", "number", number, "hash", hash)
+		return nil
+	}
+	if _, err := statedb.OpenTrie(block.Root()); err != nil {
+		log.Warn("Block state missing", "number", number, "hash", hash, "root", block.Root())
+		return nil
+	}
+	log.Info("Checking block state", "number", number, "hash", hash, "root", block.Root())
+	if _, err := state.CheckState(statedb, block.Root()); err != nil {
+		log.Warn("Block state incomplete", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return block
+}
+
+// highestCanonicalNumber finds the end of the canonical chain, which has no gaps.
+func highestCanonicalNumber(db ddmdb.Database) uint64 {
+	hi := uint64(1)
+	for GetCanonicalHash(db, hi) != (common.Hash{}) {
+		hi *= 2
+	}
+	lo := uint64(0)
+	for lo+1 < hi {
+		if mid := (lo + hi) / 2; GetCanonicalHash(db, mid) != (common.Hash{}) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
@@ -31,6 +31,14 @@ var (
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
 
+	headFinalizedKey = []byte("LastFinalized")
+
+	trustedAnchorKey = []byte("TrustedAnchor")
+
+	flushedRootsKey = []byte("FlushedStateRoots")
+
+	stateHistoryTailKey = []byte("StateHistoryTail")
+
 	headerPrefix        = []byte("h") 
 	tdSuffix            = []byte("t") 
 	numSuffix           = []byte("n") 
@@ -40,6 +48,11 @@ var (
 	lookupPrefix        = []byte("l") 
 	bloomBitsPrefix     = []byte("B") 
 
+	stateDiffPrefix      = []byte("D") 
+	accountHistoryPrefix = []byte("A") 
+	storageHistoryPrefix = []byte("P") 
+	historyCodePrefix    = []byte("C") 
+
 	preimagePrefix = "secure-key-"              
 	configPrefix   = []byte("ddmchain-config-") 
 
@@ -68,12 +81,38 @@ func encodeBlockNumber(number uint64) []byte {
 
 func GetCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, ddmdb.FreezerHashTable, number)
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
 	return common.BytesToHash(data)
 }
 
+// readAncient returns an item of a frozen block, if db keeps a freezer.
+func readAncient(db DatabaseReader, kind string, number uint64) []byte {
+	if store, ok := db.(ddmdb.AncientReader); ok {
+		data, _ := store.Ancient(kind, number)
+		return data
+	}
+	return nil
+}
+
+// hasAncientBlock reports whether the block with the given hash is frozen.
+func hasAncientBlock(db DatabaseReader, hash common.Hash, number uint64) bool {
+	return bytes.Equal(readAncient(db, ddmdb.FreezerHashTable, number), hash[:])
+}
+
+// readAncientBlock returns an item of the block with the given hash, if it is
+// frozen. Only canonical blocks are, so the hash has to match.
+func readAncientBlock(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	if !hasAncientBlock(db, hash, number) {
+		return nil
+	}
+	return readAncient(db, kind, number)
+}
+
 const missingNumber = uint64(0xffffffffffffffff)
 
 func GetBlockNumber(db DatabaseReader, hash common.Hash) uint64 {
@@ -108,8 +147,44 @@ func GetHeadFastBlockHash(db DatabaseReader) common.Hash {
 	return common.BytesToHash(data)
 }
 
+func GetFinalizedBlockHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(headFinalizedKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// GetTrustedAnchor returns the hash of the trusted checkpoint the chain was
+// synced from, below which it has no history.
+func GetTrustedAnchor(db DatabaseReader) common.Hash {
+	data, _ := db.Get(trustedAnchorKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// GetFlushedStateRoots returns the state roots persisted to disk by a pruning
+// node that have not been garbage collected yet.
+func GetFlushedStateRoots(db DatabaseReader) []common.Hash {
+	data, _ := db.Get(flushedRootsKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var roots []common.Hash
+	if err := rlp.DecodeBytes(data, &roots); err != nil {
+		log.Error("Invalid flushed state root list", "err", err)
+		return nil
+	}
+	return roots
+}
+
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(hash, number))
+	if len(data) == 0 {
+		data = readAncientBlock(db, ddmdb.FreezerHeaderTable, hash, number)
+	}
 	return data
 }
 
@@ -128,9 +203,29 @@ func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header
 
 func GetBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(blockBodyKey(hash, number))
+	if len(data) == 0 {
+		data = readAncientBlock(db, ddmdb.FreezerBodiesTable, hash, number)
+	}
 	return data
 }
 
+// HasHeader reports whether the header with the given hash is stored, either
+// in the database or frozen.
+func HasHeader(db ddmdb.Database, hash common.Hash, number uint64) bool {
+	if ok, _ := db.Has(headerKey(hash, number)); ok {
+		return true
+	}
+	return hasAncientBlock(db, hash, number)
+}
+
+// HasBody is like HasHeader for block bodies.
+func HasBody(db ddmdb.Database, hash common.Hash, number uint64) bool {
+	if ok, _ := db.Has(blockBodyKey(hash, number)); ok {
+		return true
+	}
+	return hasAncientBlock(db, hash, number)
+}
+
 func headerKey(hash common.Hash, number uint64) []byte {
 	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
@@ -154,6 +249,9 @@ func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
 
 func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	if len(data) == 0 {
+		data = readAncientBlock(db, ddmdb.FreezerDifficultyTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -181,6 +279,9 @@ func GetBlock(db DatabaseReader, hash common.Hash, number uint64) *types.Block {
 
 func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		data = readAncientBlock(db, ddmdb.FreezerReceiptTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -307,6 +408,31 @@ func WriteHeadFastBlockHash(db ddmdb.Putter, hash common.Hash) error {
 	return nil
 }
 
+func WriteFinalizedBlockHash(db ddmdb.Putter, hash common.Hash) error {
+	if err := db.Put(headFinalizedKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last finalized block's hash", "err", err)
+	}
+	return nil
+}
+
+func WriteTrustedAnchor(db ddmdb.Putter, hash common.Hash) error {
+	if err := db.Put(trustedAnchorKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store trusted anchor hash", "err", err)
+	}
+	return nil
+}
+
+func WriteFlushedStateRoots(db ddmdb.Putter, roots []common.Hash) error {
+	data, err := rlp.EncodeToBytes(roots)
+	if err != nil {
+		return err
+	}
+	if err := db.Put(flushedRootsKey, data); err != nil {
+		log.Crit("Failed to store flushed state roots", "err", err)
+	}
+	return nil
+}
+
 func WriteHeader(db ddmdb.Putter, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
 	if err != nil {
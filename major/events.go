@@ -8,6 +8,22 @@ import (
 
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// Reasons reported in a TxDropEvent.
+const (
+	TxDropReplaced    = "replaced"
+	TxDropUnderpriced = "underpriced"
+	TxDropExpired     = "expired"
+	TxDropUnpayable   = "unpayable"
+	TxDropRateLimit   = "ratelimit"
+)
+
+// TxDropEvent is posted when the transaction pool discards a transaction it
+// had accepted, with the reason it was discarded.
+type TxDropEvent struct {
+	Tx     *types.Transaction
+	Reason string
+}
+
 type PendingLogsEvent struct {
 	Logs []*types.Log
 }
@@ -0,0 +1,149 @@
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+const (
+	freezerRecheckInterval = time.Minute
+	freezerBatchLimit      = 2048
+)
+
+// freeze keeps moving the blocks that are too old to be reorged from the key
+// value store to the freezer, until the chain is stopped.
+func (bc *BlockChain) freeze(store ddmdb.AncientStore) {
+	defer bc.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-bc.quit:
+			return
+		}
+		frozen, err := bc.freezeBlocks(store)
+		if err != nil {
+			log.Error("Failed to freeze blocks", "err", err)
+		}
+		if frozen == freezerBatchLimit {
+			timer.Reset(0)
+		} else {
+			timer.Reset(freezerRecheckInterval)
+		}
+	}
+}
+
+// freezeBlocks moves the next batch of immutable canonical blocks into the
+// freezer and deletes them, along with the side chains at their heights, from
+// the key value store. The genesis block is kept in both. A chain synced from a
+// trusted checkpoint has no history up to it, its freezer starts right after.
+// The blocks frozen can't be reorged, so the chain lock is only held to read
+// the head.
+func (bc *BlockChain) freezeBlocks(store ddmdb.AncientStore) (int, error) {
+	bc.mu.RLock()
+	head := bc.currentBlock.NumberU64()
+	final := bc.currentFinalizedBlock.NumberU64()
+	bc.mu.RUnlock()
+
+	if head <= params.ImmutabilityThreshold {
+		return 0, nil
+	}
+	limit := head - params.ImmutabilityThreshold
+	if final > 0 && final < limit {
+		limit = final
+	}
+	if anchor := bc.TrustedAnchor(); anchor != nil && store.Ancients() <= anchor.Number.Uint64() {
+		// Only the genesis block, which the key value store keeps, may be frozen
+		if store.Ancients() > 1 || store.AncientTail() > 0 {
+			return 0, fmt.Errorf("ancient blocks overlap the trusted anchor #%d", anchor.Number)
+		}
+		if err := store.TruncateAncients(0); err != nil {
+			return 0, err
+		}
+		if err := store.SetAncientTail(anchor.Number.Uint64() + 1); err != nil {
+			return 0, err
+		}
+	}
+	var (
+		start  = time.Now()
+		first  = store.Ancients()
+		hashes []common.Hash
+	)
+	for number := first; number <= limit && len(hashes) < freezerBatchLimit; number++ {
+		hash := GetCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			return len(hashes), fmt.Errorf("canonical hash of block #%d missing", number)
+		}
+		header := GetHeaderRLP(bc.db, hash, number)
+		body := GetBodyRLP(bc.db, hash, number)
+		receipts, _ := bc.db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+		td, _ := bc.db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+		if len(header) == 0 || len(body) == 0 || len(receipts) == 0 || len(td) == 0 {
+			return len(hashes), fmt.Errorf("block #%d [%x…] incomplete", number, hash[:4])
+		}
+		if err := store.AppendAncient(number, hash[:], header, body, receipts, td); err != nil {
+			return len(hashes), err
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	if err := store.Sync(); err != nil {
+		return 0, err
+	}
+	batch := bc.db.NewBatch()
+	for i, hash := range hashes {
+		number := first + uint64(i)
+		if number == 0 {
+			continue
+		}
+		DeleteCanonicalHash(batch, number)
+		batch.Delete(headerKey(hash, number))
+		DeleteBody(batch, hash, number)
+		DeleteBlockReceipts(batch, hash, number)
+		DeleteTd(batch, hash, number)
+	}
+	if err := bc.deleteSideBlocks(batch, first, hashes); err != nil {
+		return 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	log.Info("Moved blocks into ancient store", "count", len(hashes), "frozen", store.Ancients(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return len(hashes), nil
+}
+
+// deleteSideBlocks deletes the blocks at the heights of the frozen ones which
+// are not canonical, they can't become canonical anymore.
+func (bc *BlockChain) deleteSideBlocks(batch ddmdb.Batch, first uint64, hashes []common.Hash) error {
+	it := bc.db.NewIterator()
+	defer it.Release()
+
+	limit := append(append([]byte{}, headerPrefix...), encodeBlockNumber(first+uint64(len(hashes)))...)
+	for ok := it.Seek(append(append([]byte{}, headerPrefix...), encodeBlockNumber(first)...)); ok && bytes.Compare(it.Key(), limit) < 0; ok = it.Next() {
+		key := it.Key()
+		if len(key) != len(headerPrefix)+8+common.HashLength {
+			continue
+		}
+		var (
+			number = binary.BigEndian.Uint64(key[len(headerPrefix):])
+			hash   = common.BytesToHash(key[len(headerPrefix)+8:])
+		)
+		if number != 0 && hash != hashes[number-first] {
+			DeleteBlock(batch, hash, number)
+		}
+	}
+	return it.Error()
+}
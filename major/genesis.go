@@ -40,6 +40,7 @@ type Genesis struct {
 	Number     uint64      `json:"number"`
 	GasUsed    uint64      `json:"gasUsed"`
 	ParentHash common.Hash `json:"parentHash"`
+	BaseFee    *big.Int    `json:"baseFeePerGas"`
 }
 
 type GenesisAlloc map[common.Address]GenesisAccount
@@ -72,6 +73,7 @@ type genesisSpecMarshaling struct {
 	GasUsed    math.HexOrDecimal64
 	Number     math.HexOrDecimal64
 	Difficulty *math.HexOrDecimal256
+	BaseFee    *math.HexOrDecimal256
 	Alloc      map[common.UnprefixedAddress]GenesisAccount
 }
 
@@ -206,6 +208,13 @@ func (g *Genesis) ToBlock(db ddmdb.Database) *types.Block {
 	if g.Difficulty == nil {
 		head.Difficulty = params.GenesisDifficulty
 	}
+	if g.Config != nil && g.Config.IsLondon(head.Number) {
+		if g.BaseFee != nil {
+			head.BaseFee = g.BaseFee
+		} else {
+			head.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
 	statedb.Commit(false)
 	statedb.Database().TrieDB().Commit(root, true)
 
@@ -290,8 +299,8 @@ func DefaultRinkebyGenesisBlock() *Genesis {
 
 func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 
-	config := *params.AllDposProtocolChanges
-	config.Dpos.Period = period
+	config := *params.AllDPosProtocolChanges
+	config.DPos.Period = period
 
 	return &Genesis{
 		Config:     &config,
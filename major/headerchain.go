@@ -299,8 +299,7 @@ func (hc *HeaderChain) HasHeader(hash common.Hash, number uint64) bool {
 	if hc.numberCache.Contains(hash) || hc.headerCache.Contains(hash) {
 		return true
 	}
-	ok, _ := hc.chainDb.Has(headerKey(hash, number))
-	return ok
+	return HasHeader(hc.chainDb, hash, number)
 }
 
 func (hc *HeaderChain) GetHeaderByNumber(number uint64) *types.Header {
@@ -0,0 +1,127 @@
+
+package core
+
+import (
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/sign"
+)
+
+// pruneJob asks the pruner to delete the states flushed before root that are
+// no longer reachable from the states which were live when root was flushed.
+type pruneJob struct {
+	root common.Hash
+	live []common.Hash
+}
+
+// prune deletes stale flushed state in the background until the chain is
+// stopped.
+func (bc *BlockChain) prune() {
+	defer bc.wg.Done()
+
+	for {
+		select {
+		case job := <-bc.pruneCh:
+			bc.pruneFlushedState(job)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// schedulePrune records a freshly flushed root and hands it to the pruner,
+// replacing the job it hasn't picked up yet, if any. It is called with bc.mu
+// held.
+func (bc *BlockChain) schedulePrune(root common.Hash) {
+	WriteFlushedStateRoots(bc.db, append(GetFlushedStateRoots(bc.db), root))
+
+	select {
+	case <-bc.pruneCh:
+	default:
+	}
+	bc.pruneCh <- &pruneJob{root: root, live: append(bc.liveRoots(), root)}
+}
+
+// liveRoots returns the roots of the states held in memory and of the genesis
+// state. It is called with bc.mu held.
+func (bc *BlockChain) liveRoots() []common.Hash {
+	var (
+		live   = []common.Hash{bc.genesisBlock.Root()}
+		cached []interface{}
+		prios  []float32
+	)
+	for !bc.triegc.Empty() {
+		root, number := bc.triegc.Pop()
+		cached, prios = append(cached, root), append(prios, number)
+		live = append(live, root.(common.Hash))
+	}
+	for i := range cached {
+		bc.triegc.Push(cached[i], prios[i])
+	}
+	return live
+}
+
+// pruneFlushedState deletes from disk the states flushed before the job's root
+// that are not reachable from any live state. The live states of the job are
+// marked without holding the chain lock, skipping those released meanwhile.
+// The states created or flushed since derive from them, so marking those under
+// the lock only walks their new nodes, after which the stale states are swept
+// before another flush could write any of their nodes back.
+func (bc *BlockChain) pruneFlushedState(job *pruneJob) {
+	var stale []common.Hash
+	for _, root := range GetFlushedStateRoots(bc.db) {
+		if root == job.root {
+			break
+		}
+		stale = append(stale, root)
+	}
+	if len(stale) == 0 {
+		return
+	}
+	start := time.Now()
+	marker := state.NewStateMarker(bc.stateCache)
+	for _, root := range job.live {
+		select {
+		case <-bc.quit:
+			return
+		default:
+		}
+		if err := marker.Mark(root); err != nil {
+			log.Debug("Live state released before marking", "root", root, "err", err)
+		}
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	var (
+		flushed = GetFlushedStateRoots(bc.db)
+		swept   = make(map[common.Hash]struct{}, len(stale))
+		kept    []common.Hash
+	)
+	for _, root := range stale {
+		swept[root] = struct{}{}
+	}
+	for _, root := range flushed {
+		if _, ok := swept[root]; !ok {
+			kept = append(kept, root)
+		}
+	}
+	for _, root := range append(bc.liveRoots(), kept...) {
+		if err := marker.Mark(root); err != nil {
+			log.Error("Failed to mark live state, skipping pruning", "root", root, "err", err)
+			return
+		}
+	}
+	deleted := 0
+	for _, old := range stale {
+		count, err := marker.Sweep(old, bc.db)
+		if err != nil {
+			log.Warn("Failed to prune flushed state", "root", old, "err", err)
+		}
+		deleted += count
+	}
+	WriteFlushedStateRoots(bc.db, kept)
+	log.Info("Pruned stale state from disk", "roots", len(stale), "live", marker.Len(), "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+}
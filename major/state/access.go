@@ -0,0 +1,303 @@
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+var errNoAccessRecorder = errors.New("no access recorder attached")
+
+// AccessRecorder collects the accounts, storage slots and contract code read or
+// written through the states it is attached to. Copies of a state share its
+// recorder, so the transactions of a block may be run on several at once.
+type AccessRecorder struct {
+	accounts map[common.Address]map[common.Hash]struct{}
+	codes    map[common.Hash]struct{}
+	lock     sync.Mutex
+}
+
+func NewAccessRecorder() *AccessRecorder {
+	return &AccessRecorder{
+		accounts: make(map[common.Address]map[common.Hash]struct{}),
+		codes:    make(map[common.Hash]struct{}),
+	}
+}
+
+func (rec *AccessRecorder) addAccount(addr common.Address) {
+	if rec == nil {
+		return
+	}
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if _, ok := rec.accounts[addr]; !ok {
+		rec.accounts[addr] = make(map[common.Hash]struct{})
+	}
+}
+
+func (rec *AccessRecorder) addSlot(addr common.Address, key common.Hash) {
+	if rec == nil {
+		return
+	}
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	slots, ok := rec.accounts[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		rec.accounts[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+func (rec *AccessRecorder) addCode(codeHash common.Hash) {
+	if rec == nil {
+		return
+	}
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	rec.codes[codeHash] = struct{}{}
+}
+
+// Accounts returns the recorded accounts in order.
+func (rec *AccessRecorder) Accounts() []common.Address {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	addrs := make([]common.Address, 0, len(rec.accounts))
+	for addr := range rec.accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
+// Storage returns the recorded storage slots of an account in order.
+func (rec *AccessRecorder) Storage(addr common.Address) []common.Hash {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	keys := make([]common.Hash, 0, len(rec.accounts[addr]))
+	for key := range rec.accounts[addr] {
+		keys = append(keys, key)
+	}
+	sortHashes(keys)
+	return keys
+}
+
+// Codes returns the hashes of the recorded contract code in order.
+func (rec *AccessRecorder) Codes() []common.Hash {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	hashes := make([]common.Hash, 0, len(rec.codes))
+	for hash := range rec.codes {
+		hashes = append(hashes, hash)
+	}
+	sortHashes(hashes)
+	return hashes
+}
+
+func sortHashes(hashes []common.Hash) {
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+}
+
+// SetAccessRecorder attaches a recorder to the state, or detaches the current
+// one if rec is nil.
+func (self *StateDB) SetAccessRecorder(rec *AccessRecorder) {
+	self.recorder = rec
+	if rec != nil {
+		self.TrackDiffs()
+	}
+}
+
+func (self *StateDB) AccessRecorder() *AccessRecorder {
+	return self.recorder
+}
+
+// Witness holds the trie nodes and contract code needed to run a block on the
+// state root it starts from, without the rest of the database.
+type Witness struct {
+	Root  common.Hash
+	Nodes [][]byte
+	Codes [][]byte
+}
+
+type witnessNodes map[common.Hash][]byte
+
+func (nodes witnessNodes) Put(key []byte, value []byte) error {
+	nodes[common.BytesToHash(key)] = common.CopyBytes(value)
+	return nil
+}
+
+// Witness proves everything recorded by the access recorder of the state
+// against the root it was opened with. The trie of the state must be up to
+// date, so IntermediateRoot has to be called first.
+func (self *StateDB) Witness() (*Witness, error) {
+	rec := self.recorder
+	if rec == nil {
+		return nil, errNoAccessRecorder
+	}
+	prevTrie, err := self.db.OpenTrie(self.originalRoot)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		nodes   = make(witnessNodes)
+		removed = make(map[common.Hash]struct{})
+	)
+	for _, addr := range rec.Accounts() {
+		addrHash := crypto.Keccak256Hash(addr[:])
+		if err := prevTrie.Prove(addrHash[:], 0, nodes); err != nil {
+			return nil, err
+		}
+		prev, err := prevTrie.TryGet(addr[:])
+		if err != nil {
+			return nil, err
+		}
+		if len(prev) == 0 {
+			continue
+		}
+		post, err := self.trie.TryGet(addr[:])
+		if err != nil {
+			return nil, err
+		}
+		if len(post) == 0 {
+			removed[addrHash] = struct{}{}
+		}
+		if err := self.proveStorage(addr, addrHash, accountRoot(prev), len(post) > 0, rec.Storage(addr), nodes); err != nil {
+			return nil, err
+		}
+	}
+	if err := proveRemoved(prevTrie, removed, nodes); err != nil {
+		return nil, err
+	}
+	witness := &Witness{Root: self.originalRoot}
+	for _, hash := range rec.Codes() {
+		code, err := self.db.ContractCode(common.Hash{}, hash)
+		if err != nil {
+			return nil, err
+		}
+		witness.Codes = append(witness.Codes, code)
+	}
+	hashes := make([]common.Hash, 0, len(nodes))
+	for hash := range nodes {
+		hashes = append(hashes, hash)
+	}
+	sortHashes(hashes)
+	for _, hash := range hashes {
+		witness.Nodes = append(witness.Nodes, nodes[hash])
+	}
+	return witness, nil
+}
+
+// proveStorage proves the recorded slots of an account against its storage
+// root before the changes, and the neighbours of the slots the changes cleared
+// unless the old storage was dropped altogether.
+func (self *StateDB) proveStorage(addr common.Address, addrHash, root common.Hash, exists bool, keys []common.Hash, nodes witnessNodes) error {
+	if root == emptyRoot || len(keys) == 0 {
+		return nil
+	}
+	prevTrie, err := self.db.OpenStorageTrie(addrHash, root)
+	if err != nil {
+		return err
+	}
+	obj := self.stateObjects[addr]
+	if _, destructed := self.diffDestructs[addr]; destructed || !exists || obj == nil {
+		obj = nil
+	}
+	removed := make(map[common.Hash]struct{})
+	for _, key := range keys {
+		hash := crypto.Keccak256Hash(key[:])
+		if err := prevTrie.Prove(hash[:], 0, nodes); err != nil {
+			return err
+		}
+		if obj == nil || obj.data.Root == root {
+			continue
+		}
+		prev, err := prevTrie.TryGet(key[:])
+		if err != nil {
+			return err
+		}
+		post, err := obj.getTrie(self.db).TryGet(key[:])
+		if err != nil {
+			return err
+		}
+		if len(prev) > 0 && len(post) == 0 {
+			removed[hash] = struct{}{}
+		}
+	}
+	return proveRemoved(prevTrie, removed, nodes)
+}
+
+// proveRemoved proves the nearest remaining keys on both sides of each removed
+// one. Removing keys can collapse the branch they were in, which then needs
+// its last child, found on the path to one of these neighbours.
+func proveRemoved(tr Trie, removed map[common.Hash]struct{}, nodes witnessNodes) error {
+	for hash := range removed {
+		it := trie.NewIterator(tr.NodeIterator(hash[:]))
+		for it.Next() {
+			if _, ok := removed[common.BytesToHash(it.Key)]; !ok {
+				if err := tr.Prove(it.Key, 0, nodes); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if it.Err != nil {
+			return it.Err
+		}
+		for n := len(hash) - 1; n >= 0; n-- {
+			var (
+				start = make([]byte, len(hash))
+				last  []byte
+			)
+			copy(start, hash[:n])
+
+			it := trie.NewIterator(tr.NodeIterator(start))
+			for it.Next() && bytes.Compare(it.Key, hash[:]) < 0 {
+				if _, ok := removed[common.BytesToHash(it.Key)]; !ok {
+					last = common.CopyBytes(it.Key)
+				}
+			}
+			if it.Err != nil {
+				return it.Err
+			}
+			if last != nil {
+				if err := tr.Prove(last, 0, nodes); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// NewWitnessDatabase returns a state database holding only the contents of a
+// witness, enough to run the block it was made for on top of its root.
+func NewWitnessDatabase(witness *Witness) (Database, error) {
+	db, err := ddmdb.NewMemDatabase()
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range append(append([][]byte{}, witness.Nodes...), witness.Codes...) {
+		if err := db.Put(crypto.Keccak256(blob), blob); err != nil {
+			return nil, err
+		}
+	}
+	return NewDatabase(db), nil
+}
@@ -0,0 +1,49 @@
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black"
+)
+
+// CheckState walks every trie node and contract code of the state with the
+// given root, failing on the first one that is missing or damaged. It returns
+// the number of accounts in the state.
+func CheckState(db Database, root common.Hash) (int, error) {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		marker   = NewStateMarker(db)
+		accounts int
+	)
+	err = marker.walk(tr.NodeIterator(nil), nil, func(key []byte, account *Account) error {
+		accounts++
+		if hash := common.BytesToHash(account.CodeHash); !bytes.Equal(account.CodeHash, emptyCodeHash) && !marker.Marked(hash) {
+			code, err := db.ContractCode(common.BytesToHash(key), hash)
+			if err != nil {
+				return fmt.Errorf("missing code %x of account %x: %v", hash, key, err)
+			}
+			if crypto.Keccak256Hash(code) != hash {
+				return fmt.Errorf("corrupt code %x of account %x", hash, key)
+			}
+			marker.marked[hash] = struct{}{}
+		}
+		if account.Root == emptyRoot || marker.Marked(account.Root) {
+			return nil
+		}
+		st, err := db.OpenStorageTrie(common.BytesToHash(key), account.Root)
+		if err != nil {
+			return err
+		}
+		if err := marker.walk(st.NodeIterator(nil), nil, nil); err != nil {
+			return fmt.Errorf("storage of account %x: %v", key, err)
+		}
+		return nil
+	})
+	return accounts, err
+}
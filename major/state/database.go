@@ -7,6 +7,7 @@ import (
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
 	"github.com/ddmchain/go-ddmchain/tree"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -33,6 +34,8 @@ type Database interface {
 	ContractCodeSize(addrHash, codeHash common.Hash) (int, error)
 
 	TrieDB() *trie.Database
+
+	Snapshots() *snapshot.Tree
 }
 
 type Trie interface {
@@ -54,13 +57,50 @@ func NewDatabase(db ddmdb.Database) Database {
 	}
 }
 
+// NewDatabaseWithSnapshots creates a state database that also keeps a flat
+// snapshot of the state in db. The snapshot is empty until it is loaded.
+func NewDatabaseWithSnapshots(db ddmdb.Database) (Database, error) {
+	csc, _ := lru.New(codeSizeCacheSize)
+	triedb := trie.NewDatabase(db)
+	snaps, err := snapshot.New(db, triedb)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingDB{
+		db:            triedb,
+		snaps:         snaps,
+		codeSizeCache: csc,
+	}, nil
+}
+
 type cachingDB struct {
 	db            *trie.Database
+	snaps         *snapshot.Tree
 	mu            sync.Mutex
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
 }
 
+// forget drops the given trie nodes and contract codes, deleted from disk, from
+// the caches.
+func (db *cachingDB) forget(hashes []common.Hash) {
+	deleted := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		deleted[hash] = struct{}{}
+		db.codeSizeCache.Remove(hash)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tries := db.pastTries[:0]
+	for _, tr := range db.pastTries {
+		if _, ok := deleted[tr.Hash()]; !ok {
+			tries = append(tries, tr)
+		}
+	}
+	db.pastTries = tries
+}
+
 func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -127,6 +167,10 @@ func (db *cachingDB) TrieDB() *trie.Database {
 	return db.db
 }
 
+func (db *cachingDB) Snapshots() *snapshot.Tree {
+	return db.snaps
+}
+
 type cachedTrie struct {
 	*trie.SecureTrie
 	db *cachingDB
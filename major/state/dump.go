@@ -29,6 +29,12 @@ func (self *StateDB) RawDump() Dump {
 		Root:     fmt.Sprintf("%x", self.trie.Hash()),
 		Accounts: make(map[string]DumpAccount),
 	}
+	if self.snap != nil && self.snap.Root() == self.trie.Hash() {
+		if err := self.snapshotDump(&dump); err == nil {
+			return dump
+		}
+		dump.Accounts = make(map[string]DumpAccount)
+	}
 
 	it := trie.NewIterator(self.trie.NodeIterator(nil))
 	for it.Next() {
@@ -39,14 +45,7 @@ func (self *StateDB) RawDump() Dump {
 		}
 
 		obj := newObject(nil, common.BytesToAddress(addr), data, nil)
-		account := DumpAccount{
-			Balance:  data.Balance.String(),
-			Nonce:    data.Nonce,
-			Root:     common.Bytes2Hex(data.Root[:]),
-			CodeHash: common.Bytes2Hex(data.CodeHash),
-			Code:     common.Bytes2Hex(obj.Code(self.db)),
-			Storage:  make(map[string]string),
-		}
+		account := self.dumpAccount(obj)
 		storageIt := trie.NewIterator(obj.getTrie(self.db).NodeIterator(nil))
 		for storageIt.Next() {
 			account.Storage[common.Bytes2Hex(self.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(storageIt.Value)
@@ -56,6 +55,52 @@ func (self *StateDB) RawDump() Dump {
 	return dump
 }
 
+// snapshotDump fills the dump from the flat state snapshot, which is much
+// faster to iterate than the tries.
+func (self *StateDB) snapshotDump(dump *Dump) error {
+	root := self.snap.Root()
+	it, err := self.snaps.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer it.Release()
+
+	for it.Next() {
+		addr := self.trie.GetKey(it.Hash().Bytes())
+		var data Account
+		if err := rlp.DecodeBytes(it.Value(), &data); err != nil {
+			return err
+		}
+
+		obj := newObject(nil, common.BytesToAddress(addr), data, nil)
+		account := self.dumpAccount(obj)
+		storageIt, err := self.snaps.StorageIterator(root, it.Hash(), common.Hash{})
+		if err != nil {
+			return err
+		}
+		for storageIt.Next() {
+			account.Storage[common.Bytes2Hex(self.trie.GetKey(storageIt.Hash().Bytes()))] = common.Bytes2Hex(storageIt.Value())
+		}
+		storageIt.Release()
+		if err := storageIt.Error(); err != nil {
+			return err
+		}
+		dump.Accounts[common.Bytes2Hex(addr)] = account
+	}
+	return it.Error()
+}
+
+func (self *StateDB) dumpAccount(obj *stateObject) DumpAccount {
+	return DumpAccount{
+		Balance:  obj.data.Balance.String(),
+		Nonce:    obj.data.Nonce,
+		Root:     common.Bytes2Hex(obj.data.Root[:]),
+		CodeHash: common.Bytes2Hex(obj.data.CodeHash),
+		Code:     common.Bytes2Hex(obj.Code(self.db)),
+		Storage:  make(map[string]string),
+	}
+}
+
 func (self *StateDB) Dump() []byte {
 	json, err := json.MarshalIndent(self.RawDump(), "", "    ")
 	if err != nil {
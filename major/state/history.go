@@ -0,0 +1,125 @@
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+var errHistoryReadOnly = errors.New("historical state is read-only")
+
+// HistoryReader serves the state of a past block, keyed by the hashes the
+// state trie uses. Accounts and slots are RLP encoded as stored in the trie,
+// empty results mean missing entries.
+type HistoryReader interface {
+	Account(addrHash common.Hash) ([]byte, error)
+
+	Storage(addrHash, slotHash common.Hash) ([]byte, error)
+
+	Code(codeHash common.Hash) ([]byte, error)
+}
+
+// historyDatabase opens the state of a past block from a HistoryReader instead
+// of its tries, which may long be gone. Changes made on top of it are only
+// kept in memory and can't be committed.
+type historyDatabase struct {
+	Database
+	history HistoryReader
+}
+
+// NewHistoryDatabase returns a state database serving the tries of a past
+// block from history. Contract code is looked up in db first.
+func NewHistoryDatabase(db Database, history HistoryReader) Database {
+	return &historyDatabase{Database: db, history: history}
+}
+
+func (db *historyDatabase) OpenTrie(root common.Hash) (Trie, error) {
+	return &historyTrie{history: db.history, root: root, dirty: make(map[string][]byte)}, nil
+}
+
+func (db *historyDatabase) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
+	return &historyTrie{history: db.history, account: &addrHash, root: root, dirty: make(map[string][]byte)}, nil
+}
+
+func (db *historyDatabase) CopyTrie(t Trie) Trie {
+	return t.(*historyTrie).copy()
+}
+
+func (db *historyDatabase) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	if code, err := db.Database.ContractCode(addrHash, codeHash); err == nil {
+		return code, nil
+	}
+	return db.history.Code(codeHash)
+}
+
+func (db *historyDatabase) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+func (db *historyDatabase) Snapshots() *snapshot.Tree {
+	return nil
+}
+
+// historyTrie is the account trie, or the storage trie of account, of a past
+// block. Hash keeps reporting the root it was opened with.
+type historyTrie struct {
+	history HistoryReader
+	account *common.Hash
+	root    common.Hash
+	dirty   map[string][]byte
+}
+
+func (t *historyTrie) TryGet(key []byte) ([]byte, error) {
+	if value, ok := t.dirty[string(key)]; ok {
+		return value, nil
+	}
+	hash := crypto.Keccak256Hash(key)
+	if t.account == nil {
+		return t.history.Account(hash)
+	}
+	return t.history.Storage(*t.account, hash)
+}
+
+func (t *historyTrie) TryUpdate(key, value []byte) error {
+	t.dirty[string(key)] = common.CopyBytes(value)
+	return nil
+}
+
+func (t *historyTrie) TryDelete(key []byte) error {
+	t.dirty[string(key)] = nil
+	return nil
+}
+
+func (t *historyTrie) Commit(onleaf trie.LeafCallback) (common.Hash, error) {
+	return common.Hash{}, errHistoryReadOnly
+}
+
+func (t *historyTrie) Hash() common.Hash {
+	return t.root
+}
+
+func (t *historyTrie) NodeIterator(startKey []byte) trie.NodeIterator {
+	return new(trie.Trie).NodeIterator(startKey)
+}
+
+func (t *historyTrie) GetKey([]byte) []byte {
+	return nil
+}
+
+func (t *historyTrie) Prove(key []byte, fromLevel uint, proofDb ddmdb.Putter) error {
+	return errHistoryReadOnly
+}
+
+func (t *historyTrie) copy() *historyTrie {
+	cpy := &historyTrie{history: t.history, account: t.account, root: t.root, dirty: make(map[string][]byte, len(t.dirty))}
+	for key, value := range t.dirty {
+		cpy.dirty[key] = value
+	}
+	return cpy
+}
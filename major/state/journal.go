@@ -19,7 +19,11 @@ type (
 		account *common.Address
 	}
 	resetObjectChange struct {
-		prev *stateObject
+		prev         *stateObject
+		prevdestruct bool
+		prevstorage  map[common.Hash][]byte
+
+		prevdiffdestruct bool
 	}
 	suicideChange struct {
 		account     *common.Address
@@ -58,6 +62,14 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+
+	accessListAddAccountChange struct {
+		address *common.Address
+	}
+	accessListAddSlotChange struct {
+		address *common.Address
+		slot    *common.Hash
+	}
 )
 
 func (ch createObjectChange) undo(s *StateDB) {
@@ -67,6 +79,17 @@ func (ch createObjectChange) undo(s *StateDB) {
 
 func (ch resetObjectChange) undo(s *StateDB) {
 	s.setStateObject(ch.prev)
+	if !ch.prevdiffdestruct {
+		delete(s.diffDestructs, ch.prev.address)
+	}
+	if s.snap != nil {
+		if !ch.prevdestruct {
+			delete(s.snapDestructs, ch.prev.addrHash)
+		}
+		if ch.prevstorage != nil {
+			s.snapStorage[ch.prev.addrHash] = ch.prevstorage
+		}
+	}
 }
 
 func (ch suicideChange) undo(s *StateDB) {
@@ -121,3 +144,11 @@ func (ch addLogChange) undo(s *StateDB) {
 func (ch addPreimageChange) undo(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
+
+func (ch accessListAddAccountChange) undo(s *StateDB) {
+	s.accessList.DeleteAddress(*ch.address)
+}
+
+func (ch accessListAddSlotChange) undo(s *StateDB) {
+	s.accessList.DeleteSlot(*ch.address, *ch.slot)
+}
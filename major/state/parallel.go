@@ -0,0 +1,194 @@
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+)
+
+// readSet records what a transaction observed of the state it ran on, so that
+// it can be checked whether it would have behaved the same on another state.
+type readSet struct {
+	accounts map[common.Address]*accountReads
+}
+
+// accountReads holds an account as it was before the transaction along with
+// the parts of it the transaction depended on. Accounts which were only
+// credited are not observed, their balance changes are merged as deltas.
+type accountReads struct {
+	exists   bool
+	nonce    uint64
+	balance  *big.Int
+	codeHash []byte
+
+	observed    bool
+	balanceRead bool
+	emptyRead   bool
+	created     bool
+	dirty       bool
+
+	slots map[common.Hash]common.Hash
+}
+
+func (acc *accountReads) empty() bool {
+	return !acc.exists || (acc.nonce == 0 && acc.balance.Sign() == 0 && bytes.Equal(acc.codeHash, emptyCode[:]))
+}
+
+type observation int
+
+const (
+	observeAccount observation = iota
+	observeBalance
+	observeEmpty
+	observeCreate
+)
+
+// TrackReads starts recording the accounts and storage slots read by the
+// transaction about to run, which Conflicts and Merge rely on.
+func (self *StateDB) TrackReads() {
+	self.TrackDiffs()
+	self.reads = &readSet{accounts: make(map[common.Address]*accountReads)}
+	for addr, obj := range self.stateObjects {
+		if obj.deleted {
+			self.recordLoad(addr, nil)
+		} else {
+			self.recordLoad(addr, obj)
+		}
+	}
+}
+
+// recordLoad records the state of an account the first time it is loaded.
+func (self *StateDB) recordLoad(addr common.Address, obj *stateObject) {
+	if self.reads == nil {
+		return
+	}
+	if _, ok := self.reads.accounts[addr]; ok {
+		return
+	}
+	acc := &accountReads{balance: new(big.Int), codeHash: emptyCode.Bytes(), slots: make(map[common.Hash]common.Hash)}
+	if obj != nil {
+		acc.exists = true
+		acc.nonce = obj.Nonce()
+		acc.balance = new(big.Int).Set(obj.Balance())
+		acc.codeHash = common.CopyBytes(obj.CodeHash())
+	}
+	self.reads.accounts[addr] = acc
+}
+
+func (self *StateDB) observe(addr common.Address, kind observation) {
+	if self.reads == nil {
+		return
+	}
+	acc := self.reads.accounts[addr]
+	if acc == nil {
+		return
+	}
+	acc.observed = true
+
+	switch kind {
+	case observeBalance:
+		acc.balanceRead = true
+	case observeEmpty:
+		// Emptiness can only be checked against the account as it was before
+		// the transaction if the transaction did not modify it yet.
+		if obj := self.stateObjects[addr]; obj != nil && obj.onDirty == nil {
+			acc.balanceRead = true
+		} else {
+			acc.emptyRead = true
+		}
+	case observeCreate:
+		acc.balanceRead, acc.created = true, true
+	}
+}
+
+func (self *StateDB) observeSlot(addr common.Address, key, value common.Hash) {
+	if self.reads == nil {
+		return
+	}
+	if acc := self.reads.accounts[addr]; acc != nil {
+		if _, ok := acc.slots[key]; !ok {
+			acc.slots[key] = value
+		}
+	}
+}
+
+// Conflicts reports whether any account or storage slot read by the last
+// transaction run on other, since TrackReads, differs in this state. If not,
+// running the transaction here would have the same outcome.
+func (self *StateDB) Conflicts(other *StateDB) bool {
+	for addr, acc := range other.reads.accounts {
+		if acc.observed {
+			obj := self.getStateObject(addr)
+			if (obj != nil) != acc.exists {
+				return true
+			}
+			if obj != nil {
+				if obj.Nonce() != acc.nonce || !bytes.Equal(obj.CodeHash(), acc.codeHash) {
+					return true
+				}
+				if acc.balanceRead && obj.Balance().Cmp(acc.balance) != 0 {
+					return true
+				}
+			}
+			if acc.emptyRead && (obj == nil || obj.empty()) != acc.empty() {
+				return true
+			}
+		}
+		for key, value := range acc.slots {
+			if self.GetState(addr, key) != value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Merge applies the changes of the last transaction run on other onto this
+// state, along with its logs and preimages. The caller has to make sure there
+// are no conflicts, prepare the transaction beforehand and finalise it after.
+func (self *StateDB) Merge(other *StateDB) {
+	for addr, acc := range other.reads.accounts {
+		obj := other.stateObjects[addr]
+		if !acc.dirty || obj == nil {
+			continue
+		}
+		if obj.suicided {
+			self.Suicide(addr)
+			continue
+		}
+		var (
+			nonce    = acc.nonce
+			codeHash = acc.codeHash
+		)
+		if acc.created {
+			self.CreateAccount(addr)
+			nonce, codeHash = 0, emptyCode[:]
+		}
+		if obj.Nonce() != nonce {
+			self.SetNonce(addr, obj.Nonce())
+		}
+		if !bytes.Equal(obj.CodeHash(), codeHash) {
+			self.SetCode(addr, obj.Code(other.db))
+		}
+		for key := range other.diffSlots[addr] {
+			self.SetState(addr, key, obj.originStorage[key])
+		}
+		delta := new(big.Int).Sub(obj.Balance(), acc.balance)
+		if delta.Sign() >= 0 {
+			self.AddBalance(addr, delta)
+		} else {
+			self.SubBalance(addr, delta.Neg(delta))
+		}
+	}
+	for _, log := range other.logs[other.thash] {
+		cpy := *log
+		self.AddLog(&cpy)
+	}
+	for hash, preimage := range other.preimages {
+		if _, ok := self.preimages[hash]; !ok {
+			self.AddPreimage(hash, preimage)
+		}
+	}
+}
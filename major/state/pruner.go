@@ -0,0 +1,202 @@
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+// Deleter removes keys from a backing database.
+type Deleter interface {
+	Delete(key []byte) error
+}
+
+// StateMarker records every trie node and contract code reachable from a set
+// of live state roots, so that unreachable ones can be deleted from disk. A
+// subtree whose root is already marked is not walked again, keeping the cost
+// of marking many similar states close to the cost of marking one.
+type StateMarker struct {
+	db     Database
+	marked map[common.Hash]struct{}
+}
+
+func NewStateMarker(db Database) *StateMarker {
+	return &StateMarker{
+		db:     db,
+		marked: make(map[common.Hash]struct{}),
+	}
+}
+
+// Mark flags every node of the state with the given root as live. If the state
+// can't be walked completely, none of it is marked, as a partially marked state
+// would hide its unmarked nodes from later marks.
+func (m *StateMarker) Mark(root common.Hash) error {
+	var marked []common.Hash
+	err := m.mark(root, &marked)
+	if err != nil {
+		m.unmark(marked)
+	}
+	return err
+}
+
+func (m *StateMarker) mark(root common.Hash, marked *[]common.Hash) error {
+	tr, err := m.db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	return m.walk(tr.NodeIterator(nil), marked, func(key []byte, account *Account) error {
+		if hash := common.BytesToHash(account.CodeHash); !bytes.Equal(account.CodeHash, emptyCodeHash) && !m.Marked(hash) {
+			m.marked[hash] = struct{}{}
+			*marked = append(*marked, hash)
+		}
+		if account.Root == emptyRoot {
+			return nil
+		}
+		st, err := m.db.OpenStorageTrie(common.BytesToHash(key), account.Root)
+		if err != nil {
+			return err
+		}
+		return m.walk(st.NodeIterator(nil), marked, nil)
+	})
+}
+
+// Marked reports whether a node or contract code is reachable from a marked
+// root.
+func (m *StateMarker) Marked(hash common.Hash) bool {
+	_, ok := m.marked[hash]
+	return ok
+}
+
+// Len returns the number of marked trie nodes and contract codes.
+func (m *StateMarker) Len() int {
+	return len(m.marked)
+}
+
+// Sweep deletes every node and contract code of the state with the given root
+// that is not reachable from a marked root, returning the number of deleted
+// entries. Only the unmarked parts of the state are walked. Deleted entries
+// are flagged as marked so that sweeping several roots deletes them once, and
+// are dropped from the caches of the state database.
+func (m *StateMarker) Sweep(root common.Hash, db Deleter) (int, error) {
+	if m.Marked(root) {
+		return 0, nil
+	}
+	tr, err := m.db.OpenTrie(root)
+	if err != nil {
+		return 0, err
+	}
+	var deleted []common.Hash
+	err = m.walk(tr.NodeIterator(nil), &deleted, func(key []byte, account *Account) error {
+		if hash := common.BytesToHash(account.CodeHash); !bytes.Equal(account.CodeHash, emptyCodeHash) && !m.Marked(hash) {
+			m.marked[hash] = struct{}{}
+			deleted = append(deleted, hash)
+		}
+		if account.Root == emptyRoot || m.Marked(account.Root) {
+			return nil
+		}
+		st, err := m.db.OpenStorageTrie(common.BytesToHash(key), account.Root)
+		if err != nil {
+			return err
+		}
+		return m.walk(st.NodeIterator(nil), &deleted, nil)
+	})
+	if err != nil {
+		m.unmark(deleted)
+		return 0, err
+	}
+	for i, hash := range deleted {
+		if err := db.Delete(hash[:]); err != nil {
+			m.unmark(deleted[i:])
+			m.forget(deleted[:i])
+			return 0, err
+		}
+	}
+	m.forget(deleted)
+	return len(deleted), nil
+}
+
+// unmark clears the marks of entries a failed sweep did not delete, so they
+// are swept again next time.
+func (m *StateMarker) unmark(hashes []common.Hash) {
+	for _, hash := range hashes {
+		delete(m.marked, hash)
+	}
+}
+
+// forget drops deleted nodes and codes from the caches of the state database,
+// so that they aren't served after they are gone from disk.
+func (m *StateMarker) forget(deleted []common.Hash) {
+	if cdb, ok := m.db.(*cachingDB); ok && len(deleted) > 0 {
+		cdb.forget(deleted)
+	}
+}
+
+// walk iterates a trie, marking its nodes and skipping already marked
+// subtrees, and calls onAccount for every account leaf if set. Newly marked
+// nodes are also appended to collect if set, which is how Sweep finds the
+// nodes to delete while making sure nodes shared between swept tries are only
+// deleted once.
+func (m *StateMarker) walk(it trie.NodeIterator, collect *[]common.Hash, onAccount func(key []byte, account *Account) error) error {
+	for descend := true; it.Next(descend); {
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if m.Marked(hash) {
+				descend = false
+				continue
+			}
+			m.marked[hash] = struct{}{}
+			if collect != nil {
+				*collect = append(*collect, hash)
+			}
+		}
+		if it.Leaf() && onAccount != nil {
+			var account Account
+			if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+				return err
+			}
+			if err := onAccount(it.LeafKey(), &account); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// SweepDatabase deletes every trie node and contract code stored in db that is
+// not reachable from a marked root. Both are keyed by the hash of their value,
+// any other entry is left alone. It returns the number and size of the deleted
+// entries.
+func (m *StateMarker) SweepDatabase(db ddmdb.Database) (int, common.StorageSize, error) {
+	var (
+		it      = db.NewIterator()
+		deleted int
+		size    common.StorageSize
+	)
+	defer it.Release()
+
+	var swept []common.Hash
+	defer func() { m.forget(swept) }()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || m.Marked(common.BytesToHash(key)) {
+			continue
+		}
+		if crypto.Keccak256Hash(it.Value()) != common.BytesToHash(key) {
+			continue
+		}
+		entry := common.StorageSize(len(key) + len(it.Value()))
+		if err := db.Delete(key); err != nil {
+			return deleted, size, err
+		}
+		swept = append(swept, common.BytesToHash(key))
+		deleted++
+		size += entry
+	}
+	return deleted, size, it.Error()
+}
@@ -0,0 +1,91 @@
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// The snapshot entries and markers in the database. They are exported for
+// the database inspector and head repair.
+var (
+	AccountPrefix = []byte("a")
+	StoragePrefix = []byte("o")
+
+	RootKey      = []byte("SnapshotRoot")
+	GeneratorKey = []byte("SnapshotGenerator")
+)
+
+// Account is the consensus representation of an account, the same as the one
+// stored in the state trie. Snapshot entries hold its RLP encoding.
+type Account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// iteratee is implemented by databases that can iterate their entries in key
+// order, which the snapshot needs to wipe and iterate its ranges.
+type iteratee interface {
+	NewIterator() iterator.Iterator
+}
+
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, AccountPrefix...), hash[:]...)
+}
+
+func storageSnapshotPrefix(account common.Hash) []byte {
+	return append(append([]byte{}, StoragePrefix...), account[:]...)
+}
+
+func storageSnapshotKey(account, slot common.Hash) []byte {
+	return append(storageSnapshotPrefix(account), slot[:]...)
+}
+
+func readSnapshotRoot(db ddmdb.Database) common.Hash {
+	data, _ := db.Get(RootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// readSnapshotGenerator returns the hash of the last account generated before
+// the generator was interrupted, or nil if generation is complete.
+func readSnapshotGenerator(db ddmdb.Database) []byte {
+	if has, _ := db.Has(GeneratorKey); !has {
+		return nil
+	}
+	marker, _ := db.Get(GeneratorKey)
+	return append([]byte{}, marker...)
+}
+
+// wipeRange deletes every entry of the given prefix and key length that sorts
+// after start.
+func wipeRange(db ddmdb.Database, prefix, start []byte, keylen int) error {
+	it := db.(iteratee).NewIterator()
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for ok := it.Seek(start); ok && bytes.HasPrefix(it.Key(), prefix); ok = it.Next() {
+		if len(it.Key()) != keylen || bytes.Equal(it.Key(), start) {
+			continue
+		}
+		batch.Delete(common.CopyBytes(it.Key()))
+		if batch.ValueSize() >= ddmdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
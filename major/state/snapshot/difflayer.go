@@ -0,0 +1,110 @@
+
+package snapshot
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+// diffLayer holds the account and storage entries changed by one block on top
+// of its parent layer.
+type diffLayer struct {
+	parent snapshot
+	root   common.Hash
+	stale  uint32
+
+	destructSet map[common.Hash]struct{}
+	accountData map[common.Hash][]byte
+	storageData map[common.Hash]map[common.Hash][]byte
+
+	lock sync.RWMutex
+}
+
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	return &diffLayer{
+		parent:      parent,
+		root:        root,
+		destructSet: destructs,
+		accountData: accounts,
+		storageData: storage,
+	}
+}
+
+func (dl *diffLayer) Root() common.Hash {
+	return dl.root
+}
+
+func (dl *diffLayer) Parent() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.parent
+}
+
+func (dl *diffLayer) setParent(parent snapshot) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.parent = parent
+}
+
+func (dl *diffLayer) Stale() bool {
+	return atomic.LoadUint32(&dl.stale) != 0
+}
+
+func (dl *diffLayer) markStale() {
+	atomic.StoreUint32(&dl.stale, 1)
+}
+
+func (dl *diffLayer) Account(hash common.Hash) (*Account, error) {
+	data, err := dl.AccountRLP(hash)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	account := new(Account)
+	if err := rlp.DecodeBytes(data, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (dl *diffLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	if dl.Stale() {
+		return nil, ErrSnapshotStale
+	}
+	dl.lock.RLock()
+	if data, ok := dl.accountData[hash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	if _, ok := dl.destructSet[hash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.AccountRLP(hash)
+}
+
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	if dl.Stale() {
+		return nil, ErrSnapshotStale
+	}
+	dl.lock.RLock()
+	if data, ok := dl.storageData[accountHash][storageHash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	if _, ok := dl.destructSet[accountHash]; ok {
+		dl.lock.RUnlock()
+		return nil, nil
+	}
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	return parent.Storage(accountHash, storageHash)
+}
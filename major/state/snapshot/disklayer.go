@@ -0,0 +1,171 @@
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+// diskLayer is the flat state of one root persisted in the database.
+type diskLayer struct {
+	diskdb ddmdb.Database
+	triedb *trie.Database
+	root   common.Hash
+	stale  bool
+
+	genMarker  []byte
+	genPending chan struct{}
+	genAbort   chan chan []byte
+
+	lock sync.RWMutex
+}
+
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// generated reports whether every entry of the layer is on disk.
+func (dl *diskLayer) generated() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker == nil
+}
+
+// covered reports whether the entries of an account were generated already.
+// The caller must hold the lock.
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(hash[:], dl.genMarker) <= 0
+}
+
+func (dl *diskLayer) Account(hash common.Hash) (*Account, error) {
+	data, err := dl.AccountRLP(hash)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	account := new(Account)
+	if err := rlp.DecodeBytes(data, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (dl *diskLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	data, _ := dl.diskdb.Get(accountSnapshotKey(hash))
+	return data, nil
+}
+
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	data, _ := dl.diskdb.Get(storageSnapshotKey(accountHash, storageHash))
+	return data, nil
+}
+
+// flatten writes a diff layer into the database and returns the disk layer of
+// its root. Entries not generated yet are skipped.
+func (dl *diskLayer) flatten(diff *diffLayer) (*diskLayer, error) {
+	dl.stopGeneration()
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+	diff.markStale()
+
+	diff.lock.RLock()
+	defer diff.lock.RUnlock()
+
+	if err := dl.diskdb.Delete(RootKey); err != nil {
+		return nil, err
+	}
+	batch := dl.diskdb.NewBatch()
+	for hash := range diff.destructSet {
+		if !dl.covered(hash) {
+			continue
+		}
+		batch.Delete(accountSnapshotKey(hash))
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+		batch.Reset()
+		if err := wipeRange(dl.diskdb, storageSnapshotPrefix(hash), storageSnapshotPrefix(hash), len(StoragePrefix)+2*common.HashLength); err != nil {
+			return nil, err
+		}
+	}
+	for hash, data := range diff.accountData {
+		if !dl.covered(hash) {
+			continue
+		}
+		if len(data) > 0 {
+			batch.Put(accountSnapshotKey(hash), data)
+		} else {
+			batch.Delete(accountSnapshotKey(hash))
+		}
+	}
+	for account, slots := range diff.storageData {
+		if !dl.covered(account) {
+			continue
+		}
+		for hash, data := range slots {
+			if len(data) > 0 {
+				batch.Put(storageSnapshotKey(account, hash), data)
+			} else {
+				batch.Delete(storageSnapshotKey(account, hash))
+			}
+		}
+	}
+	batch.Put(RootKey, diff.root[:])
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	base := &diskLayer{
+		diskdb:    dl.diskdb,
+		triedb:    dl.triedb,
+		root:      diff.root,
+		genMarker: dl.genMarker,
+	}
+	if base.genMarker != nil {
+		base.startGeneration()
+	}
+	return base, nil
+}
@@ -0,0 +1,157 @@
+
+package snapshot
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+var (
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	maxHash = common.HexToHash("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+// startGeneration generates the entries after genMarker in the background. The
+// caller must hold the tree lock.
+func (dl *diskLayer) startGeneration() {
+	dl.genPending = make(chan struct{})
+	dl.genAbort = make(chan chan []byte)
+	go dl.generate(dl.genAbort, dl.genPending)
+}
+
+// stopGeneration aborts a running generation once its progress is persisted.
+// The caller must hold the tree lock.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	reply := make(chan []byte)
+	select {
+	case dl.genAbort <- reply:
+		<-reply
+	case <-dl.genPending:
+	}
+	dl.genAbort = nil
+}
+
+// generate writes the accounts after genMarker with all their slots. The marker
+// only advances past complete accounts; a missing trie node suspends it until a
+// newer root is flattened in.
+func (dl *diskLayer) generate(abort chan chan []byte, done chan struct{}) {
+	defer close(done)
+
+	dl.lock.RLock()
+	marker := dl.genMarker
+	dl.lock.RUnlock()
+
+	var (
+		start    = time.Now()
+		accounts int
+		slots    int
+		batch    = dl.diskdb.NewBatch()
+	)
+	persist := func(marker []byte) bool {
+		if marker == nil {
+			batch.Delete(GeneratorKey)
+		} else {
+			batch.Put(GeneratorKey, marker)
+		}
+		if err := batch.Write(); err != nil {
+			log.Error("Failed to write state snapshot", "err", err)
+			return false
+		}
+		batch.Reset()
+
+		dl.lock.Lock()
+		dl.genMarker = marker
+		dl.lock.Unlock()
+		return true
+	}
+	// Drop leftovers after the marker, like half generated storage
+	accountStart, storageStart := AccountPrefix, StoragePrefix
+	if len(marker) > 0 {
+		accountStart = accountSnapshotKey(common.BytesToHash(marker))
+		storageStart = storageSnapshotKey(common.BytesToHash(marker), maxHash)
+	}
+	if err := wipeRange(dl.diskdb, AccountPrefix, accountStart, len(AccountPrefix)+common.HashLength); err != nil {
+		log.Error("Failed to wipe state snapshot", "err", err)
+		return
+	}
+	if err := wipeRange(dl.diskdb, StoragePrefix, storageStart, len(StoragePrefix)+2*common.HashLength); err != nil {
+		log.Error("Failed to wipe state snapshot", "err", err)
+		return
+	}
+	accTrie, err := trie.New(dl.root, dl.triedb)
+	if err != nil {
+		log.Debug("State snapshot generation suspended", "root", dl.root, "err", err)
+		return
+	}
+	var (
+		it   = trie.NewIterator(accTrie.NodeIterator(marker))
+		last = marker
+	)
+	for it.Next() {
+		if bytes.Equal(it.Key, marker) {
+			continue
+		}
+		hash := common.BytesToHash(it.Key)
+		var account Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			log.Error("Invalid account in state trie", "hash", hash, "err", err)
+			return
+		}
+		if account.Root != emptyRoot {
+			storeTrie, err := trie.New(account.Root, dl.triedb)
+			if err != nil {
+				persist(last)
+				log.Debug("State snapshot generation suspended", "root", dl.root, "err", err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				batch.Put(storageSnapshotKey(hash, common.BytesToHash(storeIt.Key)), common.CopyBytes(storeIt.Value))
+				slots++
+				if batch.ValueSize() >= ddmdb.IdealBatchSize && !persist(last) {
+					return
+				}
+			}
+			if storeIt.Err != nil {
+				persist(last)
+				log.Debug("State snapshot generation suspended", "root", dl.root, "err", storeIt.Err)
+				return
+			}
+		}
+		batch.Put(accountSnapshotKey(hash), common.CopyBytes(it.Value))
+		accounts++
+		last = common.CopyBytes(hash[:])
+
+		select {
+		case reply := <-abort:
+			persist(last)
+			reply <- last
+			return
+		default:
+		}
+		if batch.ValueSize() >= ddmdb.IdealBatchSize {
+			if !persist(last) {
+				return
+			}
+			log.Trace("Generating state snapshot", "root", dl.root, "at", hash, "accounts", accounts, "slots", slots)
+		}
+	}
+	if it.Err != nil {
+		persist(last)
+		log.Debug("State snapshot generation suspended", "root", dl.root, "err", it.Err)
+		return
+	}
+	if persist(nil) {
+		log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+}
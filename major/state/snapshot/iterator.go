@@ -0,0 +1,128 @@
+
+package snapshot
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// Iterator walks the entries of a snapshot in hash order. Value returns the
+// RLP encoded account or storage slot, as stored in the state trie.
+type Iterator interface {
+	Next() bool
+	Error() error
+	Hash() common.Hash
+	Value() []byte
+	Release()
+}
+
+// layeredIterator merges the collapsed diff entries over the disk ones. An empty
+// diff entry hides the disk entry of the same hash.
+type layeredIterator struct {
+	prefix []byte
+	keys   []common.Hash
+	mem    map[common.Hash][]byte
+
+	disk      iterator.Iterator
+	diskValid bool
+
+	hash  common.Hash
+	value []byte
+}
+
+func newLayeredIterator(db ddmdb.Database, prefix []byte, seek common.Hash, mem map[common.Hash][]byte, useDisk bool) *layeredIterator {
+	it := &layeredIterator{
+		prefix: prefix,
+		mem:    mem,
+	}
+	for hash := range mem {
+		if bytes.Compare(hash[:], seek[:]) >= 0 {
+			it.keys = append(it.keys, hash)
+		}
+	}
+	sort.Slice(it.keys, func(i, j int) bool {
+		return bytes.Compare(it.keys[i][:], it.keys[j][:]) < 0
+	})
+	if useDisk {
+		it.disk = db.(iteratee).NewIterator()
+		it.diskValid = it.disk.Seek(append(append([]byte{}, prefix...), seek[:]...))
+		it.skipDisk()
+	}
+	return it
+}
+
+// skipDisk moves the disk iterator past entries of other lengths sharing the
+// prefix, invalidating it once it leaves the prefix.
+func (it *layeredIterator) skipDisk() {
+	for it.diskValid {
+		key := it.disk.Key()
+		if !bytes.HasPrefix(key, it.prefix) {
+			it.diskValid = false
+			return
+		}
+		if len(key) == len(it.prefix)+common.HashLength {
+			return
+		}
+		it.diskValid = it.disk.Next()
+	}
+}
+
+func (it *layeredIterator) nextDisk() {
+	it.diskValid = it.disk.Next()
+	it.skipDisk()
+}
+
+func (it *layeredIterator) Next() bool {
+	for {
+		var diskHash common.Hash
+		if it.diskValid {
+			diskHash = common.BytesToHash(it.disk.Key()[len(it.prefix):])
+		}
+		switch {
+		case len(it.keys) > 0 && (!it.diskValid || bytes.Compare(it.keys[0][:], diskHash[:]) <= 0):
+			hash := it.keys[0]
+			it.keys = it.keys[1:]
+			if it.diskValid && hash == diskHash {
+				it.nextDisk()
+			}
+			if len(it.mem[hash]) == 0 {
+				continue
+			}
+			it.hash, it.value = hash, it.mem[hash]
+			return true
+
+		case it.diskValid:
+			it.hash, it.value = diskHash, common.CopyBytes(it.disk.Value())
+			it.nextDisk()
+			return true
+
+		default:
+			return false
+		}
+	}
+}
+
+func (it *layeredIterator) Error() error {
+	if it.disk == nil {
+		return nil
+	}
+	return it.disk.Error()
+}
+
+func (it *layeredIterator) Hash() common.Hash {
+	return it.hash
+}
+
+func (it *layeredIterator) Value() []byte {
+	return it.value
+}
+
+func (it *layeredIterator) Release() {
+	if it.disk != nil {
+		it.disk.Release()
+	}
+}
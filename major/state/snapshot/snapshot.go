@@ -0,0 +1,300 @@
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+var (
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	errNotIterable = errors.New("database does not support iteration")
+)
+
+// Snapshot is a flat view of the accounts and storage of one state root. On
+// error the trie has to be consulted instead.
+type Snapshot interface {
+	Root() common.Hash
+
+	Account(hash common.Hash) (*Account, error)
+
+	AccountRLP(hash common.Hash) ([]byte, error)
+
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal view of a layer, linking it to the one below.
+type snapshot interface {
+	Snapshot
+
+	Parent() snapshot
+
+	Stale() bool
+}
+
+// Tree is a disk layer in the database with an in-memory diff layer per block
+// on top of it.
+type Tree struct {
+	diskdb ddmdb.Database
+	triedb *trie.Database
+	layers map[common.Hash]snapshot
+	lock   sync.RWMutex
+}
+
+// New creates an empty snapshot tree, to be filled by Load or Rebuild.
+func New(diskdb ddmdb.Database, triedb *trie.Database) (*Tree, error) {
+	if _, ok := diskdb.(iteratee); !ok {
+		return nil, errNotIterable
+	}
+	return &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: make(map[common.Hash]snapshot),
+	}, nil
+}
+
+// Load opens the persisted snapshot if it belongs to root, else rebuilds it.
+func (t *Tree) Load(root common.Hash) {
+	if readSnapshotRoot(t.diskdb) != root {
+		log.Info("Persisted state snapshot is stale, rebuilding", "root", root)
+		t.Rebuild(root)
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	base := &diskLayer{
+		diskdb:    t.diskdb,
+		triedb:    t.triedb,
+		root:      root,
+		genMarker: readSnapshotGenerator(t.diskdb),
+	}
+	t.layers = map[common.Hash]snapshot{root: base}
+	if base.genMarker != nil {
+		log.Info("Resuming state snapshot generation", "root", root, "at", common.BytesToHash(base.genMarker))
+		base.startGeneration()
+	}
+}
+
+// Rebuild drops all layers and regenerates the snapshot of root in the background.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if base := t.disk(); base != nil {
+		base.stopGeneration()
+	}
+	for _, layer := range t.layers {
+		markStale(layer)
+	}
+	batch := t.diskdb.NewBatch()
+	batch.Put(RootKey, root[:])
+	batch.Put(GeneratorKey, []byte{})
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to reset state snapshot", "err", err)
+	}
+	base := &diskLayer{
+		diskdb:    t.diskdb,
+		triedb:    t.triedb,
+		root:      root,
+		genMarker: []byte{},
+	}
+	t.layers = map[common.Hash]snapshot{root: base}
+	base.startGeneration()
+}
+
+// Stop aborts a running generation, persisting its progress.
+func (t *Tree) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if base := t.disk(); base != nil {
+		base.stopGeneration()
+	}
+}
+
+// Snapshot returns the snapshot of root, or nil if there is none.
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if layer, ok := t.layers[root]; ok {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a diff layer for blockRoot on top of the snapshot of parentRoot.
+// Destructs apply before the account and storage changes.
+func (t *Tree) Update(blockRoot, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if blockRoot == parentRoot {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[blockRoot]; ok {
+		return nil
+	}
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent snapshot [%#x] missing", parentRoot)
+	}
+	t.layers[blockRoot] = newDiffLayer(parent, blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Cap flattens the diff layers below root into the disk layer until at most
+// layers remain, dropping the branches no longer leading to it.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var chain []*diffLayer
+	for {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		chain = append(chain, diff)
+		layer = diff.Parent()
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	base := layer.(*diskLayer)
+	for i := len(chain) - 1; i >= layers; i-- {
+		var err error
+		if base, err = base.flatten(chain[i]); err != nil {
+			return err
+		}
+	}
+	if layers > 0 {
+		chain[layers-1].setParent(base)
+	}
+	remaining := map[common.Hash]snapshot{base.root: base}
+	for hash, layer := range t.layers {
+		if _, ok := layer.(*diskLayer); ok {
+			continue
+		}
+		if reaches(layer, base) {
+			remaining[hash] = layer
+		} else {
+			markStale(layer)
+		}
+	}
+	t.layers = remaining
+	return nil
+}
+
+// AccountIterator iterates the accounts of a fully generated snapshot from seek.
+func (t *Tree) AccountIterator(root common.Hash, seek common.Hash) (Iterator, error) {
+	diffs, err := t.complete(root)
+	if err != nil {
+		return nil, err
+	}
+	mem := make(map[common.Hash][]byte)
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diffs[i].lock.RLock()
+		for hash := range diffs[i].destructSet {
+			mem[hash] = nil
+		}
+		for hash, data := range diffs[i].accountData {
+			mem[hash] = data
+		}
+		diffs[i].lock.RUnlock()
+	}
+	return newLayeredIterator(t.diskdb, AccountPrefix, seek, mem, true), nil
+}
+
+// StorageIterator iterates the slots of an account of a fully generated snapshot from seek.
+func (t *Tree) StorageIterator(root common.Hash, account common.Hash, seek common.Hash) (Iterator, error) {
+	diffs, err := t.complete(root)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mem        = make(map[common.Hash][]byte)
+		destructed bool
+	)
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diffs[i].lock.RLock()
+		if _, ok := diffs[i].destructSet[account]; ok {
+			mem, destructed = make(map[common.Hash][]byte), true
+		}
+		for hash, data := range diffs[i].storageData[account] {
+			mem[hash] = data
+		}
+		diffs[i].lock.RUnlock()
+	}
+	return newLayeredIterator(t.diskdb, storageSnapshotPrefix(account), seek, mem, !destructed), nil
+}
+
+// complete returns the diff layers from root down to a fully generated disk layer.
+func (t *Tree) complete(root common.Hash) ([]*diffLayer, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return nil, fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var diffs []*diffLayer
+	for {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		layer = diff.Parent()
+	}
+	if !layer.(*diskLayer).generated() {
+		return nil, ErrNotCoveredYet
+	}
+	return diffs, nil
+}
+
+// disk returns the disk layer of the tree. The caller must hold the lock.
+func (t *Tree) disk() *diskLayer {
+	for _, layer := range t.layers {
+		if base, ok := layer.(*diskLayer); ok {
+			return base
+		}
+	}
+	return nil
+}
+
+func reaches(layer snapshot, base *diskLayer) bool {
+	for layer != nil {
+		if layer.Stale() {
+			return false
+		}
+		if layer == snapshot(base) {
+			return true
+		}
+		layer = layer.Parent()
+	}
+	return false
+}
+
+func markStale(layer snapshot) {
+	switch layer := layer.(type) {
+	case *diffLayer:
+		layer.markStale()
+	case *diskLayer:
+		layer.markStale()
+	}
+}
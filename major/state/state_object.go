@@ -9,6 +9,7 @@ import (
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
 	"github.com/ddmchain/go-ddmchain/ptl"
 )
 
@@ -50,6 +51,7 @@ type stateObject struct {
 	trie Trie 
 	code Code 
 
+	originStorage Storage
 	cachedStorage Storage 
 	dirtyStorage  Storage 
 
@@ -83,6 +85,7 @@ func newObject(db *StateDB, address common.Address, data Account, onDirty func(a
 		address:       address,
 		addrHash:      crypto.Keccak256Hash(address[:]),
 		data:          data,
+		originStorage: make(Storage),
 		cachedStorage: make(Storage),
 		dirtyStorage:  make(Storage),
 		onDirty:       onDirty,
@@ -137,8 +140,38 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 	if exists {
 		return value
 	}
+	value = self.GetCommittedState(db, key)
+	if (value != common.Hash{}) {
+		self.cachedStorage[key] = value
+	}
+	return value
+}
+
+func (self *stateObject) GetCommittedState(db Database, key common.Hash) common.Hash {
+	if self.db != nil {
+		self.db.recorder.addSlot(self.address, key)
+	}
+	value, exists := self.originStorage[key]
+	if exists {
+		self.observeSlot(key, value)
+		return value
+	}
 
-	enc, err := self.getTrie(db).TryGet(key[:])
+	var (
+		enc []byte
+		err error
+	)
+	if snap := self.snapshot(); snap != nil {
+		if _, destructed := self.db.snapDestructs[self.addrHash]; destructed {
+			self.originStorage[key] = value
+			self.observeSlot(key, value)
+			return value
+		}
+		enc, err = snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if self.snapshot() == nil || err != nil {
+		enc, err = self.getTrie(db).TryGet(key[:])
+	}
 	if err != nil {
 		self.setError(err)
 		return common.Hash{}
@@ -150,9 +183,8 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 		}
 		value.SetBytes(content)
 	}
-	if (value != common.Hash{}) {
-		self.cachedStorage[key] = value
-	}
+	self.originStorage[key] = value
+	self.observeSlot(key, value)
 	return value
 }
 
@@ -175,17 +207,57 @@ func (self *stateObject) setState(key, value common.Hash) {
 	}
 }
 
+func (self *stateObject) observeSlot(key, value common.Hash) {
+	if self.db != nil {
+		self.db.observeSlot(self.address, key, value)
+	}
+}
+
+// snapshot returns the flat state snapshot the object's storage is read from,
+// if any.
+func (self *stateObject) snapshot() snapshot.Snapshot {
+	if self.db == nil {
+		return nil
+	}
+	return self.db.snap
+}
+
 func (self *stateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
+
+	var storage map[common.Hash][]byte
+	if self.snapshot() != nil && len(self.dirtyStorage) > 0 {
+		if storage = self.db.snapStorage[self.addrHash]; storage == nil {
+			storage = make(map[common.Hash][]byte)
+			self.db.snapStorage[self.addrHash] = storage
+		}
+	}
+	var slots map[common.Hash]struct{}
+	if self.db != nil && self.db.diffSlots != nil && len(self.dirtyStorage) > 0 {
+		if slots = self.db.diffSlots[self.address]; slots == nil {
+			slots = make(map[common.Hash]struct{})
+			self.db.diffSlots[self.address] = slots
+		}
+	}
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+		self.originStorage[key] = value
+		if slots != nil {
+			slots[key] = struct{}{}
+		}
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
+			if storage != nil {
+				storage[crypto.Keccak256Hash(key[:])] = nil
+			}
 			continue
 		}
 
 		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 		self.setError(tr.TryUpdate(key[:], v))
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v
+		}
 	}
 	return tr
 }
@@ -250,6 +322,7 @@ func (self *stateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 		stateObject.trie = db.db.CopyTrie(self.trie)
 	}
 	stateObject.code = self.code
+	stateObject.originStorage = self.originStorage.Copy()
 	stateObject.dirtyStorage = self.dirtyStorage.Copy()
 	stateObject.cachedStorage = self.dirtyStorage.Copy()
 	stateObject.suicided = self.suicided
@@ -269,6 +342,9 @@ func (self *stateObject) Code(db Database) []byte {
 	if bytes.Equal(self.CodeHash(), emptyCodeHash) {
 		return nil
 	}
+	if self.db != nil {
+		self.db.recorder.addCode(common.BytesToHash(self.CodeHash()))
+	}
 	code, err := db.ContractCode(self.addrHash, common.BytesToHash(self.CodeHash()))
 	if err != nil {
 		self.setError(fmt.Errorf("can't load code hash %x: %v", self.CodeHash(), err))
@@ -2,12 +2,14 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state/snapshot"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/black"
 	"github.com/ddmchain/go-ddmchain/sign"
@@ -25,12 +27,27 @@ var (
 	emptyState = crypto.Keccak256Hash(nil)
 
 	emptyCode = crypto.Keccak256Hash(nil)
+
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
 )
 
 type StateDB struct {
 	db   Database
 	trie Trie
 
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	originalRoot  common.Hash
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
+	diffDestructs map[common.Address]struct{}
+	diffSlots     map[common.Address]map[common.Hash]struct{}
+
+	reads    *readSet
+	recorder *AccessRecorder
+
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
 
@@ -45,6 +62,8 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	accessList *accessList
+
 	journal        journal
 	validRevisions []revision
 	nextRevisionId int
@@ -57,14 +76,34 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                db,
 		trie:              tr,
 		stateObjects:      make(map[common.Address]*stateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
-	}, nil
+		accessList:        newAccessList(),
+	}
+	sdb.openSnapshot(root)
+	return sdb, nil
+}
+
+// openSnapshot looks up the flat snapshot of root, which is then consulted
+// before the trie, and starts collecting the changes for the snapshot of the
+// state once it is committed.
+func (self *StateDB) openSnapshot(root common.Hash) {
+	self.originalRoot = root
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+
+	if self.snaps = self.db.Snapshots(); self.snaps == nil {
+		return
+	}
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 }
 
 func (self *StateDB) setError(err error) {
@@ -91,6 +130,13 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.accessList = newAccessList()
+	if self.diffSlots != nil {
+		self.diffDestructs = make(map[common.Address]struct{})
+		self.diffSlots = make(map[common.Address]map[common.Hash]struct{})
+	}
+	self.reads = nil
+	self.openSnapshot(root)
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -136,17 +182,29 @@ func (self *StateDB) AddRefund(gas uint64) {
 	self.refund += gas
 }
 
+func (self *StateDB) SubRefund(gas uint64) {
+	self.journal = append(self.journal, refundChange{prev: self.refund})
+	if gas > self.refund {
+		panic("refund counter below zero")
+	}
+	self.refund -= gas
+}
+
 func (self *StateDB) Exist(addr common.Address) bool {
-	return self.getStateObject(addr) != nil
+	so := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
+	return so != nil
 }
 
 func (self *StateDB) Empty(addr common.Address) bool {
 	so := self.getStateObject(addr)
+	self.observe(addr, observeEmpty)
 	return so == nil || so.empty()
 }
 
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeBalance)
 	if stateObject != nil {
 		return stateObject.Balance()
 	}
@@ -155,6 +213,7 @@ func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 
 func (self *StateDB) GetNonce(addr common.Address) uint64 {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		return stateObject.Nonce()
 	}
@@ -164,6 +223,7 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 
 func (self *StateDB) GetCode(addr common.Address) []byte {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		return stateObject.Code(self.db)
 	}
@@ -172,12 +232,14 @@ func (self *StateDB) GetCode(addr common.Address) []byte {
 
 func (self *StateDB) GetCodeSize(addr common.Address) int {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject == nil {
 		return 0
 	}
 	if stateObject.code != nil {
 		return len(stateObject.code)
 	}
+	self.recorder.addCode(common.BytesToHash(stateObject.CodeHash()))
 	size, err := self.db.ContractCodeSize(stateObject.addrHash, common.BytesToHash(stateObject.CodeHash()))
 	if err != nil {
 		self.setError(err)
@@ -187,6 +249,7 @@ func (self *StateDB) GetCodeSize(addr common.Address) int {
 
 func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject == nil {
 		return common.Hash{}
 	}
@@ -195,12 +258,24 @@ func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 	stateObject := self.getStateObject(a)
+	self.observe(a, observeAccount)
 	if stateObject != nil {
 		return stateObject.GetState(self.db, b)
 	}
 	return common.Hash{}
 }
 
+// GetCommittedState retrieves a storage slot as of the start of the current
+// transaction, ignoring modifications made by it.
+func (self *StateDB) GetCommittedState(a common.Address, b common.Hash) common.Hash {
+	stateObject := self.getStateObject(a)
+	self.observe(a, observeAccount)
+	if stateObject != nil {
+		return stateObject.GetCommittedState(self.db, b)
+	}
+	return common.Hash{}
+}
+
 func (self *StateDB) Database() Database {
 	return self.db
 }
@@ -214,8 +289,30 @@ func (self *StateDB) StorageTrie(a common.Address) Trie {
 	return cpy.updateTrie(self.db)
 }
 
+// StorageIterator iterates the committed storage of an account from the flat
+// state snapshot, ordered by hashed slot and starting at start. It fails if
+// there is no complete snapshot or the account's storage was modified since.
+func (self *StateDB) StorageIterator(a common.Address, start common.Hash) (snapshot.Iterator, error) {
+	stateObject := self.getStateObject(a)
+	if stateObject == nil {
+		return nil, fmt.Errorf("account %x doesn't exist", a)
+	}
+	if self.snap == nil {
+		return nil, errors.New("no state snapshot")
+	}
+	account, err := self.snap.Account(stateObject.addrHash)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Root != stateObject.data.Root || len(stateObject.dirtyStorage) > 0 {
+		return nil, errors.New("storage modified since snapshot")
+	}
+	return self.snaps.StorageIterator(self.snap.Root(), stateObject.addrHash, start)
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		return stateObject.suicided
 	}
@@ -224,6 +321,9 @@ func (self *StateDB) HasSuicided(addr common.Address) bool {
 
 func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 	stateObject := self.GetOrNewStateObject(addr)
+	if amount.Sign() == 0 {
+		self.observe(addr, observeEmpty)
+	}
 	if stateObject != nil {
 		stateObject.AddBalance(amount)
 	}
@@ -231,6 +331,7 @@ func (self *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 
 func (self *StateDB) SubBalance(addr common.Address, amount *big.Int) {
 	stateObject := self.GetOrNewStateObject(addr)
+	self.observe(addr, observeBalance)
 	if stateObject != nil {
 		stateObject.SubBalance(amount)
 	}
@@ -238,6 +339,7 @@ func (self *StateDB) SubBalance(addr common.Address, amount *big.Int) {
 
 func (self *StateDB) SetBalance(addr common.Address, amount *big.Int) {
 	stateObject := self.GetOrNewStateObject(addr)
+	self.observe(addr, observeBalance)
 	if stateObject != nil {
 		stateObject.SetBalance(amount)
 	}
@@ -245,6 +347,7 @@ func (self *StateDB) SetBalance(addr common.Address, amount *big.Int) {
 
 func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 	stateObject := self.GetOrNewStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
 	}
@@ -252,6 +355,7 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
 	stateObject := self.GetOrNewStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		stateObject.SetCode(crypto.Keccak256Hash(code), code)
 	}
@@ -259,6 +363,7 @@ func (self *StateDB) SetCode(addr common.Address, code []byte) {
 
 func (self *StateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
 	stateObject := self.GetOrNewStateObject(addr)
+	self.observe(addr, observeAccount)
 	if stateObject != nil {
 		stateObject.SetState(self.db, key, value)
 	}
@@ -266,6 +371,7 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 
 func (self *StateDB) Suicide(addr common.Address) bool {
 	stateObject := self.getStateObject(addr)
+	self.observe(addr, observeBalance)
 	if stateObject == nil {
 		return false
 	}
@@ -287,15 +393,29 @@ func (self *StateDB) updateStateObject(stateObject *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	self.setError(self.trie.TryUpdate(addr[:], data))
+
+	if self.snap != nil {
+		self.snapAccounts[stateObject.addrHash] = data
+	}
 }
 
 func (self *StateDB) deleteStateObject(stateObject *stateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.setError(self.trie.TryDelete(addr[:]))
+	if self.diffDestructs != nil {
+		self.diffDestructs[addr] = struct{}{}
+	}
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(self.snapAccounts, stateObject.addrHash)
+		delete(self.snapStorage, stateObject.addrHash)
+	}
 }
 
 func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObject) {
+	self.recorder.addAccount(addr)
 
 	if obj := self.stateObjects[addr]; obj != nil {
 		if obj.deleted {
@@ -304,9 +424,19 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	enc, err := self.trie.TryGet(addr[:])
+	var (
+		enc []byte
+		err error
+	)
+	if self.snap != nil {
+		enc, err = self.snap.AccountRLP(crypto.Keccak256Hash(addr[:]))
+	}
+	if self.snap == nil || err != nil {
+		enc, err = self.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		self.setError(err)
+		self.recordLoad(addr, nil)
 		return nil
 	}
 	var data Account
@@ -317,6 +447,7 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 
 	obj := newObject(self, addr, data, self.MarkStateObjectDirty)
 	self.setStateObject(obj)
+	self.recordLoad(addr, obj)
 	return obj
 }
 
@@ -343,7 +474,20 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 	if prev == nil {
 		self.journal = append(self.journal, createObjectChange{account: &addr})
 	} else {
-		self.journal = append(self.journal, resetObjectChange{prev: prev})
+		change := resetObjectChange{prev: prev}
+		if self.diffDestructs != nil {
+			_, change.prevdiffdestruct = self.diffDestructs[addr]
+			self.diffDestructs[addr] = struct{}{}
+		}
+
+		if self.snap != nil {
+			_, change.prevdestruct = self.snapDestructs[prev.addrHash]
+			change.prevstorage = self.snapStorage[prev.addrHash]
+
+			self.snapDestructs[prev.addrHash] = struct{}{}
+			delete(self.snapStorage, prev.addrHash)
+		}
+		self.journal = append(self.journal, change)
 	}
 	self.setStateObject(newobj)
 	return newobj, prev
@@ -351,6 +495,7 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObjec
 
 func (self *StateDB) CreateAccount(addr common.Address) {
 	new, prev := self.createObject(addr)
+	self.observe(addr, observeCreate)
 	if prev != nil {
 		new.setBalance(prev.data.Balance)
 	}
@@ -389,6 +534,41 @@ func (self *StateDB) Copy() *StateDB {
 		logs:              make(map[common.Hash][]*types.Log, len(self.logs)),
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte),
+		accessList:        self.accessList.Copy(),
+		snaps:             self.snaps,
+		snap:              self.snap,
+		originalRoot:      self.originalRoot,
+		recorder:          self.recorder,
+	}
+	if self.diffSlots != nil {
+		state.diffDestructs = make(map[common.Address]struct{}, len(self.diffDestructs))
+		state.diffSlots = make(map[common.Address]map[common.Hash]struct{}, len(self.diffSlots))
+		for addr := range self.diffDestructs {
+			state.diffDestructs[addr] = struct{}{}
+		}
+		for addr, slots := range self.diffSlots {
+			state.diffSlots[addr] = make(map[common.Hash]struct{}, len(slots))
+			for key := range slots {
+				state.diffSlots[addr][key] = struct{}{}
+			}
+		}
+	}
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(self.snapAccounts))
+		for hash, data := range self.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(self.snapStorage))
+		for hash, storage := range self.snapStorage {
+			state.snapStorage[hash] = make(map[common.Hash][]byte, len(storage))
+			for key, data := range storage {
+				state.snapStorage[hash][key] = data
+			}
+		}
 	}
 
 	for addr := range self.stateObjectsDirty {
@@ -437,6 +617,9 @@ func (self *StateDB) GetRefund() uint64 {
 func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	for addr := range s.stateObjectsDirty {
 		stateObject := s.stateObjects[addr]
+		if s.reads != nil && s.reads.accounts[addr] != nil {
+			s.reads.accounts[addr].dirty = true
+		}
 		if stateObject.suicided || (deleteEmptyObjects && stateObject.empty()) {
 			s.deleteStateObject(stateObject)
 		} else {
@@ -459,6 +642,54 @@ func (self *StateDB) Prepare(thash, bhash common.Hash, ti int) {
 	self.txIndex = ti
 }
 
+// PrepareAccessList resets the access list for a new transaction and warms the
+// sender, the recipient, the precompiles and the entries of the transaction's
+// own access list.
+func (self *StateDB) PrepareAccessList(sender common.Address, dst *common.Address, precompiles []common.Address, list types.AccessList) {
+	self.accessList = newAccessList()
+
+	self.AddAddressToAccessList(sender)
+	if dst != nil {
+		self.AddAddressToAccessList(*dst)
+	}
+	for _, addr := range precompiles {
+		self.AddAddressToAccessList(addr)
+	}
+	for _, el := range list {
+		self.AddAddressToAccessList(el.Address)
+		for _, key := range el.StorageKeys {
+			self.AddSlotToAccessList(el.Address, key)
+		}
+	}
+}
+
+func (self *StateDB) AddAddressToAccessList(addr common.Address) {
+	if self.accessList.AddAddress(addr) {
+		self.journal = append(self.journal, accessListAddAccountChange{&addr})
+	}
+}
+
+func (self *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	addrMod, slotMod := self.accessList.AddSlot(addr, slot)
+	if addrMod {
+		self.journal = append(self.journal, accessListAddAccountChange{&addr})
+	}
+	if slotMod {
+		self.journal = append(self.journal, accessListAddSlotChange{
+			address: &addr,
+			slot:    &slot,
+		})
+	}
+}
+
+func (self *StateDB) AddressInAccessList(addr common.Address) bool {
+	return self.accessList.ContainsAddress(addr)
+}
+
+func (self *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressPresent bool, slotPresent bool) {
+	return self.accessList.Contains(addr, slot)
+}
+
 func (s *StateDB) DeleteSuicides() {
 
 	s.clearJournalAndRefund()
@@ -519,5 +750,12 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+
+	if err == nil && s.snap != nil {
+		if err := s.snaps.Update(root, s.originalRoot, s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+			log.Warn("Failed to update state snapshot", "root", root, "parent", s.originalRoot, "err", err)
+		}
+		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
+	}
 	return root, err
 }
@@ -0,0 +1,163 @@
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+// StateDiff lists the accounts and storage slots changed by a block together
+// with their values before and after it, RLP encoded as stored in the state
+// trie. An empty value means the entry does not exist.
+type StateDiff struct {
+	Accounts []AccountDiff
+}
+
+type AccountDiff struct {
+	Address common.Address
+	Prev    []byte
+	Post    []byte
+	Storage []StorageDiff
+}
+
+// StorageDiff is a changed storage slot. Key is the slot itself if it was
+// written, it is empty for slots only cleared by a self-destruct, which are
+// known by their hash alone.
+type StorageDiff struct {
+	Hash common.Hash
+	Key  []byte
+	Prev []byte
+	Post []byte
+}
+
+var errDiffsNotTracked = errors.New("state changes not tracked")
+
+// TrackDiffs starts recording the destructed accounts and the written storage
+// slots, which Diff, Merge and the witness of a block rely on.
+func (self *StateDB) TrackDiffs() {
+	if self.diffSlots == nil {
+		self.diffDestructs = make(map[common.Address]struct{})
+		self.diffSlots = make(map[common.Address]map[common.Hash]struct{})
+	}
+}
+
+// Diff returns the changes made on top of the root the state was opened with.
+// It must be called after Commit, as it compares the tries of both roots, on a
+// state tracking its changes since it was opened.
+func (self *StateDB) Diff() (*StateDiff, error) {
+	if self.diffSlots == nil {
+		return nil, errDiffsNotTracked
+	}
+	triedb := self.db.TrieDB()
+	prevTrie, err := trie.New(self.originalRoot, triedb)
+	if err != nil {
+		return nil, err
+	}
+	postTrie, err := trie.New(self.trie.Hash(), triedb)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]common.Address, 0, len(self.stateObjects))
+	for addr := range self.stateObjects {
+		addrs = append(addrs, addr)
+	}
+	for addr := range self.diffDestructs {
+		if _, ok := self.stateObjects[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	diff := new(StateDiff)
+	for _, addr := range addrs {
+		hash := crypto.Keccak256Hash(addr[:])
+		prev, err := prevTrie.TryGet(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		post, err := postTrie.TryGet(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		_, destructed := self.diffDestructs[addr]
+		storage, err := self.storageDiff(accountRoot(prev), accountRoot(post), destructed, self.diffSlots[addr])
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(prev, post) && len(storage) == 0 {
+			continue
+		}
+		diff.Accounts = append(diff.Accounts, AccountDiff{Address: addr, Prev: prev, Post: post, Storage: storage})
+	}
+	return diff, nil
+}
+
+// storageDiff compares the written slots of an account between two storage
+// roots. If the account was destructed every slot of the old root is
+// compared, since all of them were cleared.
+func (self *StateDB) storageDiff(prevRoot, postRoot common.Hash, destructed bool, slots map[common.Hash]struct{}) ([]StorageDiff, error) {
+	if prevRoot == postRoot {
+		return nil, nil
+	}
+	triedb := self.db.TrieDB()
+	prevTrie, err := trie.New(prevRoot, triedb)
+	if err != nil {
+		return nil, err
+	}
+	postTrie, err := trie.New(postRoot, triedb)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[common.Hash][]byte, len(slots))
+	for key := range slots {
+		keys[crypto.Keccak256Hash(key[:])] = common.CopyBytes(key[:])
+	}
+	if destructed {
+		it := trie.NewIterator(prevTrie.NodeIterator(nil))
+		for it.Next() {
+			hash := common.BytesToHash(it.Key)
+			if _, ok := keys[hash]; !ok {
+				keys[hash] = nil
+			}
+		}
+		if it.Err != nil {
+			return nil, it.Err
+		}
+	}
+	var diffs []StorageDiff
+	for hash, key := range keys {
+		prev, err := prevTrie.TryGet(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		post, err := postTrie.TryGet(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(prev, post) {
+			diffs = append(diffs, StorageDiff{Hash: hash, Key: key, Prev: prev, Post: post})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return bytes.Compare(diffs[i].Hash[:], diffs[j].Hash[:]) < 0
+	})
+	return diffs, nil
+}
+
+func accountRoot(enc []byte) common.Hash {
+	if len(enc) == 0 {
+		return emptyRoot
+	}
+	var data Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		return emptyRoot
+	}
+	return data.Root
+}
@@ -0,0 +1,326 @@
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	"github.com/ddmchain/go-ddmchain/tree"
+)
+
+var (
+	errHistoryUnavailable = errors.New("state history unavailable for block")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+func stateDiffKey(hash common.Hash, number uint64) []byte {
+	return append(append(append([]byte{}, stateDiffPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func accountHistoryKey(addrHash common.Hash, number uint64) []byte {
+	return append(append(append([]byte{}, accountHistoryPrefix...), addrHash.Bytes()...), encodeBlockNumber(number)...)
+}
+
+func storageHistoryKey(addrHash, slotHash common.Hash, number uint64) []byte {
+	return append(append(append(append([]byte{}, storageHistoryPrefix...), addrHash.Bytes()...), slotHash.Bytes()...), encodeBlockNumber(number)...)
+}
+
+func GetStateDiff(db DatabaseReader, hash common.Hash, number uint64) *state.StateDiff {
+	data, _ := db.Get(stateDiffKey(hash, number))
+	if len(data) == 0 {
+		return nil
+	}
+	diff := new(state.StateDiff)
+	if err := rlp.DecodeBytes(data, diff); err != nil {
+		log.Error("Invalid state diff RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return diff
+}
+
+func WriteStateDiff(db ddmdb.Putter, hash common.Hash, number uint64, diff *state.StateDiff) error {
+	data, err := rlp.EncodeToBytes(diff)
+	if err != nil {
+		return err
+	}
+	if err := db.Put(stateDiffKey(hash, number), data); err != nil {
+		log.Crit("Failed to store state diff", "err", err)
+	}
+	return nil
+}
+
+func DeleteStateDiff(db DatabaseDeleter, hash common.Hash, number uint64) {
+	db.Delete(stateDiffKey(hash, number))
+}
+
+// GetStateHistoryTail returns the first block of the canonical chain whose
+// changes are indexed. Together with the state of its parent, this is the
+// oldest state that can be served from history.
+func GetStateHistoryTail(db DatabaseReader) (uint64, bool) {
+	data, _ := db.Get(stateHistoryTailKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+func WriteStateHistoryTail(db ddmdb.Putter, number uint64) error {
+	if err := db.Put(stateHistoryTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store state history tail", "err", err)
+	}
+	return nil
+}
+
+func DeleteStateHistoryTail(db DatabaseDeleter) {
+	db.Delete(stateHistoryTailKey)
+}
+
+// WriteStateHistory indexes the values a canonical block overwrote under its
+// number. The state at the end of an earlier block is then found in the first
+// entry after it, or in the head state if there is none.
+func WriteStateHistory(db ddmdb.Putter, number uint64, diff *state.StateDiff) error {
+	for _, account := range diff.Accounts {
+		addrHash := crypto.Keccak256Hash(account.Address[:])
+		if err := db.Put(accountHistoryKey(addrHash, number), account.Prev); err != nil {
+			return err
+		}
+		for _, slot := range account.Storage {
+			if err := db.Put(storageHistoryKey(addrHash, slot.Hash, number), slot.Prev); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteStateHistory removes the index entries of a block that is no longer
+// canonical.
+func DeleteStateHistory(db DatabaseDeleter, number uint64, diff *state.StateDiff) {
+	for _, account := range diff.Accounts {
+		addrHash := crypto.Keccak256Hash(account.Address[:])
+		db.Delete(accountHistoryKey(addrHash, number))
+		for _, slot := range account.Storage {
+			db.Delete(storageHistoryKey(addrHash, slot.Hash, number))
+		}
+	}
+}
+
+// readStateHistory returns the first index entry of the account or slot at
+// prefix written by a block after number.
+func readStateHistory(db ddmdb.Iteratee, prefix []byte, number uint64) ([]byte, bool, error) {
+	it := db.NewIterator()
+	defer it.Release()
+
+	if !it.Seek(append(append([]byte{}, prefix...), encodeBlockNumber(number+1)...)) {
+		return nil, false, it.Error()
+	}
+	if key := it.Key(); len(key) != len(prefix)+8 || !bytes.HasPrefix(key, prefix) {
+		return nil, false, nil
+	}
+	return common.CopyBytes(it.Value()), true, nil
+}
+
+// stateHistory serves the state at the end of a past canonical block from the
+// history index, falling back to the head state for entries not changed since.
+type stateHistory struct {
+	db     ddmdb.Database
+	number uint64
+	triedb *trie.Database
+	head   *trie.Trie
+
+	storage map[common.Hash]*trie.Trie
+}
+
+func (h *stateHistory) Account(addrHash common.Hash) ([]byte, error) {
+	enc, ok, err := readStateHistory(h.db, append(append([]byte{}, accountHistoryPrefix...), addrHash.Bytes()...), h.number)
+	if ok || err != nil {
+		return enc, err
+	}
+	return h.head.TryGet(addrHash[:])
+}
+
+func (h *stateHistory) Storage(addrHash, slotHash common.Hash) ([]byte, error) {
+	prefix := append(append(append([]byte{}, storageHistoryPrefix...), addrHash.Bytes()...), slotHash.Bytes()...)
+	enc, ok, err := readStateHistory(h.db, prefix, h.number)
+	if ok || err != nil {
+		return enc, err
+	}
+	tr, ok := h.storage[addrHash]
+	if !ok {
+		enc, err := h.head.TryGet(addrHash[:])
+		if err != nil || len(enc) == 0 {
+			return nil, err
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(enc, &account); err != nil {
+			return nil, err
+		}
+		if tr, err = trie.New(account.Root, h.triedb); err != nil {
+			return nil, err
+		}
+		h.storage[addrHash] = tr
+	}
+	return tr.TryGet(slotHash[:])
+}
+
+func (h *stateHistory) Code(codeHash common.Hash) ([]byte, error) {
+	return h.db.Get(append(append([]byte{}, historyCodePrefix...), codeHash.Bytes()...))
+}
+
+// HistoricalState returns the state at the end of the given block. If its
+// trie is gone, the state of a canonical block is served from the recorded
+// state history, as a view that can be modified but not committed.
+func (bc *BlockChain) HistoricalState(header *types.Header) (*state.StateDB, error) {
+	statedb, err := bc.StateAt(header.Root)
+	if err == nil {
+		return statedb, nil
+	}
+	if !bc.cacheConfig.History {
+		return nil, err
+	}
+	number := header.Number.Uint64()
+	if GetCanonicalHash(bc.db, number) != header.Hash() {
+		return nil, err
+	}
+	if tail, ok := GetStateHistoryTail(bc.db); !ok || number+1 < tail {
+		return nil, errHistoryUnavailable
+	}
+	head := bc.CurrentBlock()
+	if number >= head.NumberU64() {
+		return nil, err
+	}
+	triedb := bc.stateCache.TrieDB()
+	headTrie, err := trie.New(head.Root(), triedb)
+	if err != nil {
+		return nil, err
+	}
+	history := &stateHistory{
+		db:      bc.db,
+		number:  number,
+		triedb:  triedb,
+		head:    headTrie,
+		storage: make(map[common.Hash]*trie.Trie),
+	}
+	return state.New(header.Root, state.NewHistoryDatabase(bc.stateCache, history))
+}
+
+// writeStateHistoryCode keeps the code of contracts the diff removes, as it
+// may no longer be reachable from any state trie.
+func (bc *BlockChain) writeStateHistoryCode(db ddmdb.Putter, diff *state.StateDiff) error {
+	for _, account := range diff.Accounts {
+		if len(account.Prev) == 0 {
+			continue
+		}
+		var prev, post state.Account
+		if err := rlp.DecodeBytes(account.Prev, &prev); err != nil {
+			return err
+		}
+		if len(account.Post) > 0 {
+			if err := rlp.DecodeBytes(account.Post, &post); err != nil {
+				return err
+			}
+		}
+		codeHash := common.BytesToHash(prev.CodeHash)
+		if codeHash == emptyCodeHash || bytes.Equal(prev.CodeHash, post.CodeHash) {
+			continue
+		}
+		code, err := bc.stateCache.ContractCode(crypto.Keccak256Hash(account.Address[:]), codeHash)
+		if err != nil {
+			return err
+		}
+		if err := db.Put(append(append([]byte{}, historyCodePrefix...), codeHash.Bytes()...), code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordStateDiff stores the changes of a block, once its state is committed,
+// for the state history.
+func (bc *BlockChain) recordStateDiff(batch ddmdb.Putter, block *types.Block, statedb *state.StateDB) (*state.StateDiff, error) {
+	if !bc.cacheConfig.History {
+		return nil, nil
+	}
+	diff, err := statedb.Diff()
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteStateDiff(batch, block.Hash(), block.NumberU64(), diff); err != nil {
+		return nil, err
+	}
+	if err := bc.writeStateHistoryCode(batch, diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// writeCanonStateHistory indexes the changes of a new canonical block, which
+// starts the history if there is none yet.
+func (bc *BlockChain) writeCanonStateHistory(batch ddmdb.Putter, number uint64, diff *state.StateDiff) error {
+	if err := WriteStateHistory(batch, number, diff); err != nil {
+		return err
+	}
+	if _, ok := GetStateHistoryTail(bc.db); !ok {
+		return WriteStateHistoryTail(batch, number)
+	}
+	return nil
+}
+
+// reorgStateHistory moves the history index from the dropped blocks to the new
+// canonical ones. The head of newChain is skipped, its changes are indexed
+// along with the block itself.
+func (bc *BlockChain) reorgStateHistory(oldChain, newChain types.Blocks) error {
+	for _, block := range oldChain {
+		if diff := GetStateDiff(bc.db, block.Hash(), block.NumberU64()); diff != nil {
+			DeleteStateHistory(bc.db, block.NumberU64(), diff)
+		}
+	}
+	tail, ok := GetStateHistoryTail(bc.db)
+	for i := len(newChain) - 1; i > 0; i-- {
+		block := newChain[i]
+		diff := GetStateDiff(bc.db, block.Hash(), block.NumberU64())
+		if diff == nil {
+			// Without the changes of this block no state before it can be served.
+			if block.NumberU64() >= tail {
+				tail = block.NumberU64() + 1
+			}
+			continue
+		}
+		if err := WriteStateHistory(bc.db, block.NumberU64(), diff); err != nil {
+			return err
+		}
+	}
+	if ok {
+		return WriteStateHistoryTail(bc.db, tail)
+	}
+	return nil
+}
+
+// rewindStateHistory drops the history index of the blocks above the current
+// head after a rewind. rewound holds the canonical hashes already removed.
+func (bc *BlockChain) rewindStateHistory(rewound map[uint64]common.Hash) {
+	current := bc.currentBlock.NumberU64()
+	for n := current + 1; n <= bc.hc.CurrentHeader().Number.Uint64(); n++ {
+		rewound[n] = GetCanonicalHash(bc.db, n)
+	}
+	for n, hash := range rewound {
+		if n <= current {
+			continue
+		}
+		if diff := GetStateDiff(bc.db, hash, n); diff != nil {
+			DeleteStateHistory(bc.db, n, diff)
+		}
+	}
+	if tail, ok := GetStateHistoryTail(bc.db); ok && current+1 < tail {
+		DeleteStateHistoryTail(bc.db)
+	}
+}
@@ -0,0 +1,107 @@
+
+package core
+
+import (
+	"runtime"
+
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/major/vm"
+	"github.com/ddmchain/go-ddmchain/sign"
+)
+
+// speculativeTx is the outcome of running a transaction on its own copy of
+// the state the block started with.
+type speculativeTx struct {
+	statedb *state.StateDB
+	receipt *types.Receipt
+	gas     uint64
+	err     error
+	done    chan struct{}
+}
+
+// processParallel runs every transaction of the block concurrently on a copy
+// of the pre-block state, recording what each one read. The results are then
+// merged in order; a transaction which read anything changed by the ones
+// before it, or failed, is run again on the merged state instead. The outcome
+// is the same as running the transactions one after the other.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, gp *GasPool, usedGas *uint64, cfg vm.Config) (types.Receipts, []*types.Log, error) {
+	var (
+		txs     = block.Transactions()
+		header  = block.Header()
+		base    = statedb.Copy()
+		results = make([]*speculativeTx, len(txs))
+		tasks   = make(chan int, len(txs))
+		abort   = make(chan struct{})
+	)
+	defer close(abort)
+
+	for i := range txs {
+		results[i] = &speculativeTx{done: make(chan struct{})}
+		tasks <- i
+	}
+	close(tasks)
+
+	workers := runtime.NumCPU()
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	for n := 0; n < workers; n++ {
+		go func() {
+			for i := range tasks {
+				res := results[i]
+				select {
+				case <-abort:
+					close(res.done)
+					continue
+				default:
+				}
+				res.statedb = base.Copy()
+				res.statedb.TrackReads()
+				res.statedb.Prepare(txs[i].Hash(), block.Hash(), i)
+
+				var used uint64
+				res.receipt, res.gas, res.err = ApplyTransaction(p.config, p.bc, nil, new(GasPool).AddGas(block.GasLimit()), res.statedb, header, txs[i], &used, cfg)
+				close(res.done)
+			}
+		}()
+	}
+
+	var (
+		receipts  types.Receipts
+		allLogs   []*types.Log
+		conflicts int
+	)
+	for i, tx := range txs {
+		res := results[i]
+		<-res.done
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+
+		receipt := res.receipt
+		if res.err == nil && gp.Gas() >= tx.Gas() && !statedb.Conflicts(res.statedb) {
+			statedb.Merge(res.statedb)
+			statedb.Finalise(true)
+
+			gp.SubGas(res.gas)
+			*usedGas += res.gas
+
+			receipt.CumulativeGasUsed = *usedGas
+			receipt.Logs = statedb.GetLogs(tx.Hash())
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		} else {
+			conflicts++
+
+			var err error
+			if receipt, _, err = ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg); err != nil {
+				return nil, nil, err
+			}
+		}
+		res.statedb = nil
+
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	log.Debug("Executed transactions in parallel", "number", block.Number(), "txs", len(txs), "reexecuted", conflicts)
+	return receipts, allLogs, nil
+}
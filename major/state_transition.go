@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/major/vm"
 	"github.com/ddmchain/go-ddmchain/sign"
 	"github.com/ddmchain/go-ddmchain/part"
@@ -14,6 +15,8 @@ import (
 
 var (
 	errInsufficientBalanceForGas = errors.New("insufficient balance to pay for gas")
+	errTipAboveFeeCap            = errors.New("max priority fee per gas higher than max fee per gas")
+	errFeeCapTooLow              = errors.New("max fee per gas less than block base fee")
 )
 
 type StateTransition struct {
@@ -34,15 +37,18 @@ type Message interface {
 	To() *common.Address
 
 	GasPrice() *big.Int
+	GasFeeCap() *big.Int
+	GasTipCap() *big.Int
 	Gas() uint64
 	Value() *big.Int
 
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	AccessList() types.AccessList
 }
 
-func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error) {
+func IntrinsicGas(data []byte, accessList types.AccessList, contractCreation, homestead, istanbul bool) (uint64, error) {
 
 	var gas uint64
 	if contractCreation && homestead {
@@ -60,10 +66,14 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 			}
 		}
 
-		if (math.MaxUint64-gas)/params.TxDataNonZeroGas < nz {
+		nonZeroGas := params.TxDataNonZeroGas
+		if istanbul {
+			nonZeroGas = params.TxDataNonZeroGasEIP2028
+		}
+		if (math.MaxUint64-gas)/nonZeroGas < nz {
 			return 0, vm.ErrOutOfGas
 		}
-		gas += nz * params.TxDataNonZeroGas
+		gas += nz * nonZeroGas
 
 		z := uint64(len(data)) - nz
 		if (math.MaxUint64-gas)/params.TxDataZeroGas < z {
@@ -71,6 +81,17 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 		}
 		gas += z * params.TxDataZeroGas
 	}
+	if accessList != nil {
+		addresses, keys := uint64(len(accessList)), uint64(accessList.StorageKeys())
+		if (math.MaxUint64-gas)/params.TxAccessListAddressGas < addresses {
+			return 0, vm.ErrOutOfGas
+		}
+		gas += addresses * params.TxAccessListAddressGas
+		if (math.MaxUint64-gas)/params.TxAccessListStorageKeyGas < keys {
+			return 0, vm.ErrOutOfGas
+		}
+		gas += keys * params.TxAccessListStorageKeyGas
+	}
 	return gas, nil
 }
 
@@ -154,6 +175,18 @@ func (st *StateTransition) preCheck() error {
 			return ErrNonceTooLow
 		}
 	}
+	if st.evm.ChainConfig().IsLondon(st.evm.BlockNumber) && st.evm.BaseFee != nil {
+
+		skip := st.evm.VmConfig().NoBaseFee && msg.GasFeeCap().Sign() == 0 && msg.GasTipCap().Sign() == 0
+		if !skip {
+			if msg.GasFeeCap().Cmp(msg.GasTipCap()) < 0 {
+				return errTipAboveFeeCap
+			}
+			if msg.GasFeeCap().Cmp(st.evm.BaseFee) < 0 {
+				return errFeeCapTooLow
+			}
+		}
+	}
 	return st.buyGas()
 }
 
@@ -165,15 +198,19 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	sender := st.from() 
 
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
+	istanbul := st.evm.ChainConfig().IsIstanbul(st.evm.BlockNumber)
 	contractCreation := msg.To() == nil
 
-	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
+	gas, err := IntrinsicGas(st.data, msg.AccessList(), contractCreation, homestead, istanbul)
 	if err != nil {
 		return nil, 0, false, err
 	}
 	if err = st.useGas(gas); err != nil {
 		return nil, 0, false, err
 	}
+	if rules := st.evm.ChainConfig().Rules(st.evm.BlockNumber); rules.IsBerlin {
+		st.state.PrepareAccessList(sender.Address(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
+	}
 
 	var (
 		evm = st.evm
@@ -195,7 +232,12 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
-	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+
+	// After London the base fee is burned and the engine credits the tips in
+	// Finalize, so nothing is paid to the coinbase here.
+	if !st.evm.ChainConfig().IsLondon(st.evm.BlockNumber) {
+		st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	}
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
@@ -195,9 +195,13 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+		feeThreshold := new(big.Int).Div(new(big.Int).Mul(old.GasFeeCap(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+		tipThreshold := new(big.Int).Div(new(big.Int).Mul(old.GasTipCap(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
 
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
+		if old.GasFeeCap().Cmp(tx.GasFeeCap()) >= 0 || old.GasTipCap().Cmp(tx.GasTipCap()) >= 0 {
+			return false, nil
+		}
+		if feeThreshold.Cmp(tx.GasFeeCap()) > 0 || tipThreshold.Cmp(tx.GasTipCap()) > 0 {
 			return false, nil
 		}
 	}
@@ -273,21 +277,30 @@ func (l *txList) Flatten() types.Transactions {
 	return l.txs.Flatten()
 }
 
-type priceHeap []*types.Transaction
+// priceHeap orders transactions by the pool's ordering policy, evaluated
+// against the base fee of the next block.
+type priceHeap struct {
+	baseFee *big.Int
+	order   TxOrdering
+	list    []*types.Transaction
+}
+
+func (h *priceHeap) Len() int      { return len(h.list) }
+func (h *priceHeap) Swap(i, j int) { h.list[i], h.list[j] = h.list[j], h.list[i] }
 
-func (h priceHeap) Len() int           { return len(h) }
-func (h priceHeap) Less(i, j int) bool { return h[i].GasPrice().Cmp(h[j].GasPrice()) < 0 }
-func (h priceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *priceHeap) Less(i, j int) bool {
+	return h.order.Less(h.list[i], h.list[j], h.baseFee)
+}
 
 func (h *priceHeap) Push(x interface{}) {
-	*h = append(*h, x.(*types.Transaction))
+	h.list = append(h.list, x.(*types.Transaction))
 }
 
 func (h *priceHeap) Pop() interface{} {
-	old := *h
+	old := h.list
 	n := len(old)
 	x := old[n-1]
-	*h = old[0 : n-1]
+	h.list = old[0 : n-1]
 	return x
 }
 
@@ -297,10 +310,10 @@ type txPricedList struct {
 	stales int                                 
 }
 
-func newTxPricedList(all *map[common.Hash]*types.Transaction) *txPricedList {
+func newTxPricedList(all *map[common.Hash]*types.Transaction, order TxOrdering) *txPricedList {
 	return &txPricedList{
 		all:   all,
-		items: new(priceHeap),
+		items: &priceHeap{order: order},
 	}
 }
 
@@ -311,15 +324,32 @@ func (l *txPricedList) Put(tx *types.Transaction) {
 func (l *txPricedList) Removed() {
 
 	l.stales++
-	if l.stales <= len(*l.items)/4 {
+	if l.stales <= l.items.Len()/4 {
 		return
 	}
 
-	reheap := make(priceHeap, 0, len(*l.all))
+	l.reheap()
+}
+
+// SetBaseFee reorders the heap by the tips transactions pay on top of the base
+// fee of the next block.
+func (l *txPricedList) SetBaseFee(baseFee *big.Int) {
+	l.items.baseFee = baseFee
+	l.reheap()
+}
+
+// SetOrdering reorders the heap according to a new ordering policy.
+func (l *txPricedList) SetOrdering(order TxOrdering) {
+	l.items.order = order
+	l.reheap()
+}
+
+func (l *txPricedList) reheap() {
+	reheap := &priceHeap{baseFee: l.items.baseFee, order: l.items.order, list: make([]*types.Transaction, 0, len(*l.all))}
 
-	l.stales, l.items = 0, &reheap
+	l.stales, l.items = 0, reheap
 	for _, tx := range *l.all {
-		*l.items = append(*l.items, tx)
+		l.items.list = append(l.items.list, tx)
 	}
 	heap.Init(l.items)
 }
@@ -328,7 +358,7 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 	drop := make(types.Transactions, 0, 128) 
 	save := make(types.Transactions, 0, 64)  
 
-	for len(*l.items) > 0 {
+	for l.items.Len() > 0 {
 
 		tx := heap.Pop(l.items).(*types.Transaction)
 		if _, ok := (*l.all)[tx.Hash()]; !ok {
@@ -336,12 +366,7 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			continue
 		}
 
-		if tx.GasPrice().Cmp(threshold) >= 0 {
-			save = append(save, tx)
-			break
-		}
-
-		if local.containsTx(tx) {
+		if tx.GasTipCap().Cmp(threshold) >= 0 || l.protected(tx, local) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -355,12 +380,12 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 
 func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
 
-	if local.containsTx(tx) {
+	if l.protected(tx, local) {
 		return false
 	}
 
-	for len(*l.items) > 0 {
-		head := []*types.Transaction(*l.items)[0]
+	for l.items.Len() > 0 {
+		head := l.items.list[0]
 		if _, ok := (*l.all)[head.Hash()]; !ok {
 			l.stales--
 			heap.Pop(l.items)
@@ -369,19 +394,19 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 		break
 	}
 
-	if len(*l.items) == 0 {
+	if l.items.Len() == 0 {
 		log.Error("Pricing query for empty pool") 
 		return false
 	}
-	cheapest := []*types.Transaction(*l.items)[0]
-	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
+	cheapest := l.items.list[0]
+	return !l.items.order.Less(cheapest, tx, l.items.baseFee)
 }
 
 func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
 	drop := make(types.Transactions, 0, count) 
 	save := make(types.Transactions, 0, 64)    
 
-	for len(*l.items) > 0 && count > 0 {
+	for l.items.Len() > 0 && count > 0 {
 
 		tx := heap.Pop(l.items).(*types.Transaction)
 		if _, ok := (*l.all)[tx.Hash()]; !ok {
@@ -389,7 +414,7 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			continue
 		}
 
-		if local.containsTx(tx) {
+		if l.protected(tx, local) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -401,3 +426,11 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 	}
 	return drop
 }
+
+func (l *txPricedList) protected(tx *types.Transaction, local *accountSet) bool {
+	if local.containsTx(tx) {
+		return true
+	}
+	from, err := types.Sender(local.signer, tx)
+	return err == nil && l.items.order.Exempt(from)
+}
@@ -0,0 +1,112 @@
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/types"
+)
+
+const (
+	TxOrderingPrice    = "price"
+	TxOrderingFIFO     = "fifo"
+	TxOrderingPriority = "priority"
+)
+
+// TxOrdering decides which transactions the pool keeps once it is full. The
+// lowest ranked transactions are evicted first, while transactions from exempt
+// senders are never evicted to make room for others, the same way local ones
+// are not. Admit is consulted before a transaction is validated against state.
+type TxOrdering interface {
+	Less(a, b *types.Transaction, baseFee *big.Int) bool
+	Exempt(from common.Address) bool
+	Admit(tx *types.Transaction, from common.Address, local bool) error
+}
+
+// PriceOrdering ranks transactions by the tip they pay on top of the base fee,
+// with the fee cap breaking ties.
+type PriceOrdering struct{}
+
+func (PriceOrdering) Less(a, b *types.Transaction, baseFee *big.Int) bool {
+	switch a.EffectiveGasTipValue(baseFee).Cmp(b.EffectiveGasTipValue(baseFee)) {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+	return a.GasFeeCap().Cmp(b.GasFeeCap()) < 0
+}
+
+func (PriceOrdering) Exempt(common.Address) bool { return false }
+
+func (PriceOrdering) Admit(*types.Transaction, common.Address, bool) error { return nil }
+
+// FIFOOrdering ranks transactions by arrival, so a full pool turns away newer
+// transactions instead of evicting the ones it already holds.
+type FIFOOrdering struct{}
+
+func (FIFOOrdering) Less(a, b *types.Transaction, baseFee *big.Int) bool {
+	return a.Time().After(b.Time())
+}
+
+func (FIFOOrdering) Exempt(common.Address) bool { return false }
+
+func (FIFOOrdering) Admit(*types.Transaction, common.Address, bool) error { return nil }
+
+// PriorityOrdering ranks transactions of allowlisted senders above all others
+// and guarantees them their slots. Transactions within either group are ranked
+// by the fallback ordering.
+type PriorityOrdering struct {
+	signer   types.Signer
+	accounts map[common.Address]struct{}
+	fallback TxOrdering
+}
+
+func NewPriorityOrdering(signer types.Signer, accounts []common.Address, fallback TxOrdering) *PriorityOrdering {
+	o := &PriorityOrdering{
+		signer:   signer,
+		accounts: make(map[common.Address]struct{}, len(accounts)),
+		fallback: fallback,
+	}
+	for _, addr := range accounts {
+		o.accounts[addr] = struct{}{}
+	}
+	return o
+}
+
+func (o *PriorityOrdering) Less(a, b *types.Transaction, baseFee *big.Int) bool {
+	pa, pb := o.prioritized(a), o.prioritized(b)
+	if pa != pb {
+		return pb
+	}
+	return o.fallback.Less(a, b, baseFee)
+}
+
+func (o *PriorityOrdering) Exempt(from common.Address) bool {
+	_, ok := o.accounts[from]
+	return ok || o.fallback.Exempt(from)
+}
+
+func (o *PriorityOrdering) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	return o.fallback.Admit(tx, from, local)
+}
+
+func (o *PriorityOrdering) prioritized(tx *types.Transaction) bool {
+	from, err := types.Sender(o.signer, tx)
+	if err != nil {
+		return false
+	}
+	_, ok := o.accounts[from]
+	return ok
+}
+
+func newTxOrdering(config TxPoolConfig, signer types.Signer) TxOrdering {
+	switch config.Ordering {
+	case TxOrderingFIFO:
+		return FIFOOrdering{}
+	case TxOrderingPriority:
+		return NewPriorityOrdering(signer, config.Priority, PriceOrdering{})
+	}
+	return PriceOrdering{}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule/misc"
 	"github.com/ddmchain/go-ddmchain/major/state"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/signal"
@@ -23,6 +24,10 @@ import (
 const (
 
 	chainHeadChanSize = 10
+
+	// reorgDepth is the deepest reorg whose discarded transactions are put
+	// back into the pool.
+	reorgDepth = 64
 )
 
 var (
@@ -39,11 +44,17 @@ var (
 
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
 
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
+
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+
 	ErrGasLimit = errors.New("exceeds block gas limit")
 
 	ErrNegativeValue = errors.New("negative value")
 
 	ErrOversizedData = errors.New("oversized data")
+
+	ErrEmptyBundle = errors.New("empty transaction bundle")
 )
 
 var (
@@ -79,6 +90,7 @@ const (
 type blockChain interface {
 	CurrentBlock() *types.Block
 	GetBlock(hash common.Hash, number uint64) *types.Block
+	HasTransaction(hash common.Hash) bool
 	StateAt(root common.Hash) (*state.StateDB, error)
 
 	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription
@@ -98,6 +110,11 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 
 
 	Lifetime time.Duration 
+
+	Ordering string           
+	Priority []common.Address 
+
+	BundleBlocks uint64 
 }
 
 var DefaultTxPoolConfig = TxPoolConfig{
@@ -113,6 +130,10 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024 *10, 
 
 	Lifetime: 3 * time.Hour, 
+
+	Ordering: TxOrderingPrice,
+
+	BundleBlocks: 25,
 }
 
 func (config *TxPoolConfig) sanitize() TxPoolConfig {
@@ -129,6 +150,16 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	switch conf.Ordering {
+	case TxOrderingPrice, TxOrderingFIFO, TxOrderingPriority:
+	default:
+		log.Warn("Sanitizing invalid txpool ordering", "provided", conf.Ordering, "updated", DefaultTxPoolConfig.Ordering)
+		conf.Ordering = DefaultTxPoolConfig.Ordering
+	}
+	if conf.BundleBlocks < 1 {
+		log.Warn("Sanitizing invalid txpool bundle lifetime", "provided", conf.BundleBlocks, "updated", DefaultTxPoolConfig.BundleBlocks)
+		conf.BundleBlocks = DefaultTxPoolConfig.BundleBlocks
+	}
 	return conf
 }
 
@@ -138,6 +169,8 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	dropFeed     event.Feed
+	drops        []TxDropEvent
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -148,8 +181,9 @@ type TxPool struct {
 	pendingState  *state.ManagedState 
 	currentMaxGas uint64              
 
-	locals  *accountSet 
-	journal *txJournal  
+	locals   *accountSet 
+	journal  *txJournal  
+	ordering TxOrdering
 
 	pending map[common.Address]*txList         
 	queue   map[common.Address]*txList         
@@ -157,9 +191,16 @@ type TxPool struct {
 	all     map[common.Hash]*types.Transaction 
 	priced  *txPricedList                      
 
+	private map[common.Hash]struct{} 
+	mined   map[common.Hash]uint64   
+	bundles []*txBundle              
+
 	wg sync.WaitGroup 
 
 	homestead bool
+	istanbul  bool
+	berlin    bool
+	london    bool
 }
 
 func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain blockChain) *TxPool {
@@ -170,16 +211,19 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:      config,
 		chainconfig: chainconfig,
 		chain:       chain,
-		signer:      types.NewEIP155Signer(chainconfig.ChainId),
+		signer:      types.LatestSigner(chainconfig),
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
 		all:         make(map[common.Hash]*types.Transaction),
+		private:     make(map[common.Hash]struct{}),
+		mined:       make(map[common.Hash]uint64),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
-	pool.priced = newTxPricedList(&pool.all)
+	pool.ordering = newTxOrdering(config, pool.signer)
+	pool.priced = newTxPricedList(&pool.all, pool.ordering)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	if !config.NoLocals && config.Journal != "" {
@@ -221,10 +265,13 @@ func (pool *TxPool) loop() {
 				if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
 				}
+				if pool.chainconfig.IsIstanbul(ev.Block.Number()) {
+					pool.istanbul = true
+				}
 
 				pool.reset(head.Header(), ev.Block.Header())
 				head = ev.Block
-				pool.mu.Unlock()
+				pool.unlock()
 			}
 
 		case <-pool.chainHeadSub.Err():
@@ -241,11 +288,11 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash())
-
+						pool.dropped(tx, TxDropExpired)
 					}
 				}
 			}
-			pool.mu.Unlock()
+			pool.unlock()
 
 		case <-journal.C:
 			if pool.journal != nil {
@@ -261,7 +308,7 @@ func (pool *TxPool) loop() {
 
 func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
 	pool.reset(oldHead, newHead)
 }
@@ -275,7 +322,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 		oldNum := oldHead.Number.Uint64()
 		newNum := newHead.Number.Uint64()
 
-		if depth := uint64(math.Abs(float64(oldNum) - float64(newNum))); depth > 64 {
+		if depth := uint64(math.Abs(float64(oldNum) - float64(newNum))); depth > reorgDepth {
 			log.Debug("Skipping deep transaction reorg", "depth", depth)
 		} else {
 
@@ -327,8 +374,31 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
-	log.Debug("Reinjecting stale transactions", "count", len(reinject))
+	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
+	pool.berlin = pool.chainconfig.IsBerlin(next)
+	pool.london = pool.chainconfig.IsLondon(next)
+	if pool.london {
+		pool.priced.SetBaseFee(misc.CalcBaseFee(pool.chainconfig, newHead))
+	}
+	pool.pruneBundles(newHead.Number.Uint64())
+
+	var private types.Transactions
+	if len(pool.mined) > 0 {
+		public := make(types.Transactions, 0, len(reinject))
+		for _, tx := range reinject {
+			if _, ok := pool.mined[tx.Hash()]; ok {
+				delete(pool.mined, tx.Hash())
+				pool.private[tx.Hash()] = struct{}{}
+				private = append(private, tx)
+			} else {
+				public = append(public, tx)
+			}
+		}
+		reinject = public
+	}
+	log.Debug("Reinjecting stale transactions", "count", len(reinject)+len(private), "private", len(private))
 	pool.addTxsLocked(reinject, false)
+	pool.addTxsLocked(private, !pool.config.NoLocals)
 
 	pool.demoteUnexecutables()
 
@@ -338,6 +408,21 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	}
 
 	pool.promoteExecutables(nil)
+
+	// Private transactions leaving the pool were mined, evicted ones are already
+	// forgotten. They are remembered for as long as a reorg may reinject them.
+	number := newHead.Number.Uint64()
+	for hash := range pool.private {
+		if pool.all[hash] == nil {
+			delete(pool.private, hash)
+			pool.mined[hash] = number
+		}
+	}
+	for hash, mined := range pool.mined {
+		if mined+reorgDepth < number {
+			delete(pool.mined, hash)
+		}
+	}
 }
 
 func (pool *TxPool) Stop() {
@@ -357,6 +442,46 @@ func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.Subscription
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxDropEvent registers a subscription of TxDropEvent, fired for
+// every transaction the pool discards after having accepted it.
+func (pool *TxPool) SubscribeTxDropEvent(ch chan<- TxDropEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// dropped records a discarded transaction, reported once the lock is released.
+func (pool *TxPool) dropped(tx *types.Transaction, reason string) {
+	delete(pool.private, tx.Hash())
+	pool.drops = append(pool.drops, TxDropEvent{Tx: tx, Reason: reason})
+}
+
+// unlock releases the pool lock and then reports the transactions dropped
+// while it was held, in order.
+func (pool *TxPool) unlock() {
+	drops := pool.drops
+	pool.drops = nil
+	pool.mu.Unlock()
+
+	for _, ev := range drops {
+		pool.dropFeed.Send(ev)
+	}
+}
+
+// SetOrdering replaces the policy deciding which transactions are evicted and
+// admitted once the pool is full.
+func (pool *TxPool) SetOrdering(ordering TxOrdering) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.ordering = ordering
+	pool.priced.SetOrdering(ordering)
+}
+
+// protected reports whether the account's transactions are spared when the pool
+// is full. Exempt accounts are still held to the per-account limits.
+func (pool *TxPool) protected(addr common.Address) bool {
+	return pool.locals.contains(addr) || pool.ordering.Exempt(addr)
+}
+
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
@@ -366,12 +491,12 @@ func (pool *TxPool) GasPrice() *big.Int {
 
 func (pool *TxPool) SetGasPrice(price *big.Int) {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
 		pool.removeTx(tx.Hash())
-
+		pool.dropped(tx, TxDropUnderpriced)
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
@@ -408,11 +533,15 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 
 	pending := make(map[common.Address]types.Transactions)
 	for addr, list := range pool.pending {
-		pending[addr] = list.Flatten()
+		if txs := pool.public(list.Flatten()); len(txs) > 0 {
+			pending[addr] = txs
+		}
 	}
 	queued := make(map[common.Address]types.Transactions)
 	for addr, list := range pool.queue {
-		queued[addr] = list.Flatten()
+		if txs := pool.public(list.Flatten()); len(txs) > 0 {
+			queued[addr] = txs
+		}
 	}
 	return pending, queued
 }
@@ -434,17 +563,40 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
 		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(pending.Flatten())...)
 		}
 		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(queued.Flatten())...)
 		}
 	}
 	return txs
 }
 
+func (pool *TxPool) public(txs types.Transactions) types.Transactions {
+	if len(pool.private) == 0 {
+		return txs
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if _, ok := pool.private[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 
+	if !pool.berlin && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+	if !pool.london && tx.Type() == types.DynamicFeeTxType {
+		return ErrTxTypeNotSupported
+	}
+	if tx.GasFeeCap().Cmp(tx.GasTipCap()) < 0 {
+		return ErrTipAboveFeeCap
+	}
+
 	if tx.Size() > 32*1024 {
 		return ErrOversizedData
 	}
@@ -462,8 +614,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInvalidSender
 	}
 
+	if err := pool.ordering.Admit(tx, from, local); err != nil {
+		return err
+	}
 	local = local || pool.locals.contains(from) 
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && pool.gasPrice.Cmp(tx.GasTipCap()) > 0 {
 		return ErrUnderpriced
 	}
 
@@ -474,7 +629,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -511,7 +666,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash())
-
+			pool.dropped(tx, TxDropUnderpriced)
 		}
 	}
 
@@ -529,6 +684,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			delete(pool.all, old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
+			pool.dropped(old, TxDropReplaced)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.priced.Put(tx)
@@ -536,7 +692,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
-		go pool.txFeed.Send(TxPreEvent{tx})
+		if _, ok := pool.private[hash]; !ok {
+			go pool.txFeed.Send(TxPreEvent{tx})
+		}
 
 		return old != nil, nil
 	}
@@ -546,7 +704,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		return false, err
 	}
 
-	if local {
+	if _, ok := pool.private[hash]; local && !ok {
 		pool.locals.add(from)
 	}
 	pool.journalTx(from, tx)
@@ -573,7 +731,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		delete(pool.all, old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
-
+		pool.dropped(old, TxDropReplaced)
 	}
 	pool.all[hash] = tx
 	pool.priced.Put(tx)
@@ -585,6 +743,9 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
+	if _, ok := pool.private[tx.Hash()]; ok {
+		return
+	}
 	if err := pool.journal.insert(tx); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
@@ -605,6 +766,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
+		pool.dropped(tx, TxDropReplaced)
 		return
 	}
 
@@ -614,6 +776,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
+		pool.dropped(old, TxDropReplaced)
 	}
 
 	if pool.all[hash] == nil {
@@ -624,7 +787,9 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
 
-	go pool.txFeed.Send(TxPreEvent{tx})
+	if _, ok := pool.private[hash]; !ok {
+		go pool.txFeed.Send(TxPreEvent{tx})
+	}
 }
 
 func (pool *TxPool) AddLocal(tx *types.Transaction) error {
@@ -643,9 +808,109 @@ func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, false)
 }
 
+// AddPrivate enqueues a transaction that is never announced to peers nor listed
+// in the pool content, leaving it to blocks sealed by this node. Its sender is
+// not marked local and it is not journaled, as it would be reloaded as public.
+func (pool *TxPool) AddPrivate(tx *types.Transaction) error {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	hash := tx.Hash()
+	if pool.all[hash] != nil {
+		return fmt.Errorf("known transaction: %x", hash)
+	}
+	pool.private[hash] = struct{}{}
+
+	replace, err := pool.add(tx, !pool.config.NoLocals)
+	if err != nil {
+		delete(pool.private, hash)
+		return err
+	}
+	if !replace {
+		from, _ := types.Sender(pool.signer, tx) 
+		pool.promoteExecutables([]common.Address{from})
+	}
+	return nil
+}
+
+// Private reports whether a pooled transaction was submitted privately and
+// must not be propagated.
+func (pool *TxPool) Private(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
+// txBundle is a list of transactions that have to be included in a block
+// together, in order, or not at all.
+type txBundle struct {
+	txs    types.Transactions
+	expiry uint64
+}
+
+// AddBundle validates a bundle of transactions and keeps it, outside of the
+// regular pool, for the next BundleBlocks blocks or until it is included.
+func (pool *TxPool) AddBundle(txs types.Transactions) error {
+	pool.mu.Lock()
+	defer pool.unlock()
+
+	if len(txs) == 0 {
+		return ErrEmptyBundle
+	}
+	for _, tx := range txs {
+		if err := pool.validateTx(tx, true); err != nil {
+			return err
+		}
+	}
+	head := pool.chain.CurrentBlock().NumberU64()
+	pool.bundles = append(pool.bundles, &txBundle{txs: txs, expiry: head + pool.config.BundleBlocks})
+	return nil
+}
+
+// Bundles returns the bundles that may still be included in the block with
+// the given number.
+func (pool *TxPool) Bundles(number *big.Int) []types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var bundles []types.Transactions
+	for _, bundle := range pool.bundles {
+		if bundle.expiry >= number.Uint64() {
+			bundles = append(bundles, bundle.txs)
+		}
+	}
+	return bundles
+}
+
+func (pool *TxPool) pruneBundles(head uint64) {
+	bundles := pool.bundles[:0]
+	for _, bundle := range pool.bundles {
+		stale := false
+		for _, tx := range bundle.txs {
+			from, _ := types.Sender(pool.signer, tx) 
+			if pool.currentState.GetNonce(from) > tx.Nonce() {
+				stale = true
+				break
+			}
+		}
+		switch {
+		case stale:
+		case bundle.expiry <= head:
+			for _, tx := range bundle.txs {
+				pool.dropped(tx, TxDropExpired)
+			}
+		default:
+			bundles = append(bundles, bundle)
+		}
+	}
+	pool.bundles = bundles
+}
+
 func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
 	replace, err := pool.add(tx, local)
 	if err != nil {
@@ -663,7 +928,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
 
 	pool.mu.Lock()
 
-	defer pool.mu.Unlock()
+	defer pool.unlock()
 
 	return pool.addTxsLocked(txs, local)
 }
@@ -779,7 +1044,9 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
-
+			if !pool.chain.HasTransaction(hash) {
+				pool.dropped(tx, TxDropReplaced)
+			}
 		}
 
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -789,7 +1056,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.all, hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
-
+			pool.dropped(tx, TxDropUnpayable)
 		}
 
 		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
@@ -806,7 +1073,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
-
+				pool.dropped(tx, TxDropRateLimit)
 			}
 		}
 
@@ -854,6 +1121,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							pool.dropped(tx, TxDropRateLimit)
 						}
 						pending--
 					}
@@ -875,6 +1143,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						pool.dropped(tx, TxDropRateLimit)
 					}
 					pending--
 				}
@@ -891,7 +1160,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 
 		addresses := make(addresssByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
-			if !pool.locals.contains(addr) { 
+			if !pool.protected(addr) { 
 				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 			}
 		}
@@ -906,7 +1175,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash())
-
+					pool.dropped(tx, TxDropRateLimit)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -916,7 +1185,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash())
-
+				pool.dropped(txs[i], TxDropRateLimit)
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
@@ -934,6 +1203,9 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			if !pool.chain.HasTransaction(hash) {
+				pool.dropped(tx, TxDropReplaced)
+			}
 		}
 
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -943,7 +1215,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			delete(pool.all, hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
-
+			pool.dropped(tx, TxDropUnpayable)
 		}
 		for _, tx := range invalids {
 			hash := tx.Hash()
@@ -0,0 +1,83 @@
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black/sha3"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+// AccessList is an EIP-2930 access list, the accounts and storage slots a
+// transaction declares up front to have them charged at the warm rate.
+type AccessList []AccessTuple
+
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+func (al AccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return sum
+}
+
+// accessListTxdata is the consensus encoding of an AccessListTxType
+// transaction, sent as the payload after the type byte.
+type accessListTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	AccessList   AccessList
+
+	V, R, S *big.Int
+}
+
+func newAccessListTxdata(d *txdata) *accessListTxdata {
+	return &accessListTxdata{
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		Price:        d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (d *accessListTxdata) txdata() txdata {
+	return txdata{
+		Type:         AccessListTxType,
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		Price:        d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
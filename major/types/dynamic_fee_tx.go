@@ -0,0 +1,60 @@
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+)
+
+// dynamicFeeTxdata is the consensus encoding of a DynamicFeeTxType transaction,
+// which pays the block base fee plus a priority fee capped by GasTipCap, never
+// exceeding GasFeeCap per unit of gas in total.
+type dynamicFeeTxdata struct {
+	ChainID      *big.Int
+	AccountNonce uint64
+	GasTipCap    *big.Int
+	GasFeeCap    *big.Int
+	GasLimit     uint64
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	AccessList   AccessList
+
+	V, R, S *big.Int
+}
+
+func newDynamicFeeTxdata(d *txdata) *dynamicFeeTxdata {
+	return &dynamicFeeTxdata{
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		GasTipCap:    d.GasTipCap,
+		GasFeeCap:    d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (d *dynamicFeeTxdata) txdata() txdata {
+	return txdata{
+		Type:         DynamicFeeTxType,
+		ChainID:      d.ChainID,
+		AccountNonce: d.AccountNonce,
+		GasTipCap:    d.GasTipCap,
+		Price:        d.GasFeeCap,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		AccessList:   d.AccessList,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
@@ -2,15 +2,18 @@
 package types
 
 import (
+	"bytes"
 	"container/heap"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/general/math"
 	"github.com/ddmchain/go-ddmchain/black"
 	"github.com/ddmchain/go-ddmchain/ptl"
 )
@@ -18,8 +21,20 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
-	errNoSigner   = errors.New("missing signing methods")
+	ErrInvalidSig         = errors.New("invalid transaction v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	errNoSigner           = errors.New("missing signing methods")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
+
+	ErrGasFeeCapTooLow = errors.New("fee cap less than base fee")
+)
+
+// Transaction types. Typed transactions are encoded as the type byte followed
+// by the type specific payload, see EIP-2718.
+const (
+	LegacyTxType = iota
+	AccessListTxType
+	DynamicFeeTxType
 )
 
 func deriveSigner(V *big.Int) Signer {
@@ -32,6 +47,7 @@ func deriveSigner(V *big.Int) Signer {
 
 type Transaction struct {
 	data txdata
+	time time.Time
 
 	hash atomic.Value
 	size atomic.Value
@@ -50,6 +66,14 @@ type txdata struct {
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
 
+	Type       uint8      `json:"type"                 rlp:"-"`
+	ChainID    *big.Int   `json:"chainId,omitempty"    rlp:"-"`
+	AccessList AccessList `json:"accessList,omitempty" rlp:"-"`
+
+	// GasTipCap is the priority fee cap of dynamic fee transactions, whose
+	// Price holds the total fee cap.
+	GasTipCap *big.Int `json:"maxPriorityFeePerGas,omitempty" rlp:"-"`
+
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
 
@@ -62,6 +86,9 @@ type txdataMarshaling struct {
 	V            *hexutil.Big
 	R            *hexutil.Big
 	S            *hexutil.Big
+	Type         hexutil.Uint64
+	ChainID      *hexutil.Big
+	GasTipCap    *hexutil.Big
 }
 
 func NewTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
@@ -94,17 +121,56 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, time: time.Now()}
+}
+
+// NewAccessListTransaction creates an unsigned AccessListTxType transaction. A
+// nil recipient creates a contract.
+func NewAccessListTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := newTransaction(nonce, to, amount, gasLimit, gasPrice, data)
+	tx.data.Type = AccessListTxType
+	tx.data.ChainID = new(big.Int)
+	if chainId != nil {
+		tx.data.ChainID.Set(chainId)
+	}
+	tx.data.AccessList = make(AccessList, len(accessList))
+	copy(tx.data.AccessList, accessList)
+	return tx
+}
+
+// NewDynamicFeeTransaction creates an unsigned DynamicFeeTxType transaction. A
+// nil recipient creates a contract.
+func NewDynamicFeeTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasTipCap, gasFeeCap *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := NewAccessListTransaction(chainId, nonce, to, amount, gasLimit, gasFeeCap, data, accessList)
+	tx.data.Type = DynamicFeeTxType
+	tx.data.GasTipCap = new(big.Int)
+	if gasTipCap != nil {
+		tx.data.GasTipCap.Set(gasTipCap)
+	}
+	return tx
 }
 
+func (tx *Transaction) Type() uint8 { return tx.data.Type }
+
+// Time returns when the transaction was created or first decoded locally.
+func (tx *Transaction) Time() time.Time { return tx.time }
+
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.data.Type != LegacyTxType {
+		return new(big.Int).Set(tx.data.ChainID)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 func (tx *Transaction) Protected() bool {
+	if tx.data.Type != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
+func (tx *Transaction) AccessList() AccessList { return tx.data.AccessList }
+
 func isProtectedV(V *big.Int) bool {
 	if V.BitLen() <= 8 {
 		v := V.Uint64()
@@ -114,18 +180,112 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
+// EncodeRLP writes legacy transactions as an RLP list and typed transactions as
+// an RLP string holding the type byte and payload.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	buf := new(bytes.Buffer)
+	if err := tx.encodeTyped(buf); err != nil {
+		return err
+	}
+	return rlp.Encode(w, buf.Bytes())
+}
+
+func (tx *Transaction) encodeTyped(w *bytes.Buffer) error {
+	switch tx.data.Type {
+	case AccessListTxType:
+		w.WriteByte(AccessListTxType)
+		return rlp.Encode(w, newAccessListTxdata(&tx.data))
+	case DynamicFeeTxType:
+		w.WriteByte(DynamicFeeTxType)
+		return rlp.Encode(w, newDynamicFeeTxdata(&tx.data))
+	default:
+		return ErrTxTypeNotSupported
+	}
 }
 
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
+	kind, size, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		var data txdata
+		if err := s.Decode(&data); err != nil {
+			return err
+		}
+		tx.data, tx.time = data, time.Now()
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		return nil
+	default:
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		data, err := decodeTyped(b)
+		if err != nil {
+			return err
+		}
+		tx.data, tx.time = data, time.Now()
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	}
+}
+
+// MarshalBinary returns the canonical encoding of the transaction: the RLP list
+// for legacy transactions and the bare type byte and payload for typed ones.
+// This is the form hashed into the transaction trie and sent over RPC.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.data.Type == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
 	}
+	buf := new(bytes.Buffer)
+	err := tx.encodeTyped(buf)
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary decodes the canonical encoding of a transaction.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		var data txdata
+		if err := rlp.DecodeBytes(b, &data); err != nil {
+			return err
+		}
+		*tx = Transaction{data: data, time: time.Now()}
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	}
+	data, err := decodeTyped(b)
+	if err != nil {
+		return err
+	}
+	*tx = Transaction{data: data, time: time.Now()}
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
 
-	return err
+func decodeTyped(b []byte) (txdata, error) {
+	if len(b) == 0 {
+		return txdata{}, errEmptyTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+		var inner accessListTxdata
+		if err := rlp.DecodeBytes(b[1:], &inner); err != nil {
+			return txdata{}, err
+		}
+		return inner.txdata(), nil
+	case DynamicFeeTxType:
+		var inner dynamicFeeTxdata
+		if err := rlp.DecodeBytes(b[1:], &inner); err != nil {
+			return txdata{}, err
+		}
+		return inner.txdata(), nil
+	default:
+		return txdata{}, ErrTxTypeNotSupported
+	}
 }
 
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
@@ -141,16 +301,31 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		return err
 	}
 	var V byte
-	if isProtectedV(dec.V) {
+	switch {
+	case dec.Type != LegacyTxType:
+		if dec.Type != AccessListTxType && dec.Type != DynamicFeeTxType {
+			return ErrTxTypeNotSupported
+		}
+		if dec.Type == DynamicFeeTxType && dec.GasTipCap == nil {
+			return errors.New("missing required field 'maxPriorityFeePerGas' for dynamic fee transaction")
+		}
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' for typed transaction")
+		}
+		if dec.V.BitLen() > 8 {
+			return ErrInvalidSig
+		}
+		V = byte(dec.V.Uint64())
+	case isProtectedV(dec.V):
 		chainID := deriveChainId(dec.V).Uint64()
 		V = byte(dec.V.Uint64() - 35 - 2*chainID)
-	} else {
+	default:
 		V = byte(dec.V.Uint64() - 27)
 	}
 	if !crypto.ValidateSignatureValues(V, dec.R, dec.S, false) {
 		return ErrInvalidSig
 	}
-	*tx = Transaction{data: dec}
+	*tx = Transaction{data: dec, time: time.Now()}
 	return nil
 }
 
@@ -161,6 +336,46 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// GasFeeCap returns the most the transaction pays per unit of gas, which for
+// transactions without a dynamic fee is the gas price.
+func (tx *Transaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.data.Price) }
+
+// GasTipCap returns the most the transaction pays per unit of gas on top of
+// the base fee, which for transactions without a dynamic fee is the gas price.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.data.Type == DynamicFeeTxType {
+		return new(big.Int).Set(tx.data.GasTipCap)
+	}
+	return new(big.Int).Set(tx.data.Price)
+}
+
+// EffectiveGasTipValue returns the priority fee the transaction pays per unit
+// of gas under the given base fee. It is negative if the fee cap is below it.
+func (tx *Transaction) EffectiveGasTipValue(baseFee *big.Int) *big.Int {
+	tip := tx.GasTipCap()
+	if baseFee == nil {
+		return tip
+	}
+	return math.BigMin(tip, new(big.Int).Sub(tx.data.Price, baseFee))
+}
+
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) (*big.Int, error) {
+	tip := tx.EffectiveGasTipValue(baseFee)
+	if tip.Sign() < 0 {
+		return nil, ErrGasFeeCapTooLow
+	}
+	return tip, nil
+}
+
+// EffectiveGasPrice returns the price per unit of gas the transaction pays
+// under the given base fee.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	return math.BigMin(tx.data.Price, new(big.Int).Add(tx.GasTipCap(), baseFee))
+}
+
 func (tx *Transaction) To() *common.Address {
 	if tx.data.Recipient == nil {
 		return nil
@@ -173,7 +388,13 @@ func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.data.Type == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		enc, _ := tx.MarshalBinary()
+		v = crypto.Keccak256Hash(enc)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -183,21 +404,34 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		rlp.Encode(&c, &tx.data)
+	} else {
+		enc, _ := tx.MarshalBinary()
+		c = writeCounter(len(enc))
+	}
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
 
-func (tx *Transaction) AsMessage(s Signer) (Message, error) {
+// AsMessage returns the transaction as a core.Message. With a non-nil base fee
+// the message's gas price is the effective price paid in that block.
+func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 	msg := Message{
 		nonce:      tx.data.AccountNonce,
 		gasLimit:   tx.data.GasLimit,
 		gasPrice:   new(big.Int).Set(tx.data.Price),
+		gasFeeCap:  tx.GasFeeCap(),
+		gasTipCap:  tx.GasTipCap(),
 		to:         tx.data.Recipient,
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
+		accessList: tx.data.AccessList,
 		checkNonce: true,
 	}
+	if baseFee != nil {
+		msg.gasPrice = tx.EffectiveGasPrice(baseFee)
+	}
 
 	var err error
 	msg.from, err = Sender(s, tx)
@@ -209,7 +443,7 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
@@ -229,6 +463,9 @@ func (tx *Transaction) String() string {
 	if tx.data.V != nil {
 
 		signer := deriveSigner(tx.data.V)
+		if tx.data.Type != LegacyTxType {
+			signer = NewLondonSigner(tx.ChainId())
+		}
 		if f, err := Sender(signer, tx); err != nil { 
 			from = "[invalid sender: invalid sig]"
 		} else {
@@ -243,7 +480,7 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := tx.MarshalBinary()
 	return fmt.Sprintf(`
 	TX(%x)
 	Contract: %v
@@ -282,7 +519,7 @@ func (s Transactions) Len() int { return len(s) }
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -309,21 +546,28 @@ func (s TxByNonce) Len() int           { return len(s) }
 func (s TxByNonce) Less(i, j int) bool { return s[i].data.AccountNonce < s[j].data.AccountNonce }
 func (s TxByNonce) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-type TxByPrice Transactions
+// TxByPrice orders transactions by the tip they pay on top of baseFee, which
+// is the plain gas price when baseFee is nil.
+type TxByPrice struct {
+	txs     Transactions
+	baseFee *big.Int
+}
 
-func (s TxByPrice) Len() int           { return len(s) }
-func (s TxByPrice) Less(i, j int) bool { return s[i].data.Price.Cmp(s[j].data.Price) > 0 }
-func (s TxByPrice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s TxByPrice) Len() int { return len(s.txs) }
+func (s TxByPrice) Less(i, j int) bool {
+	return s.txs[i].EffectiveGasTipValue(s.baseFee).Cmp(s.txs[j].EffectiveGasTipValue(s.baseFee)) > 0
+}
+func (s TxByPrice) Swap(i, j int) { s.txs[i], s.txs[j] = s.txs[j], s.txs[i] }
 
 func (s *TxByPrice) Push(x interface{}) {
-	*s = append(*s, x.(*Transaction))
+	s.txs = append(s.txs, x.(*Transaction))
 }
 
 func (s *TxByPrice) Pop() interface{} {
-	old := *s
+	old := s.txs
 	n := len(old)
 	x := old[n-1]
-	*s = old[0 : n-1]
+	s.txs = old[0 : n-1]
 	return x
 }
 
@@ -333,13 +577,16 @@ type TransactionsByPriceAndNonce struct {
 	signer Signer                          
 }
 
-func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
-
-	heads := make(TxByPrice, 0, len(txs))
-	for _, accTxs := range txs {
-		heads = append(heads, accTxs[0])
+func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int) *TransactionsByPriceAndNonce {
 
+	heads := TxByPrice{txs: make(Transactions, 0, len(txs)), baseFee: baseFee}
+	for from, accTxs := range txs {
 		acc, _ := Sender(signer, accTxs[0])
+		if _, err := accTxs[0].EffectiveGasTip(baseFee); err != nil {
+			delete(txs, from)
+			continue
+		}
+		heads.txs = append(heads.txs, accTxs[0])
 		txs[acc] = accTxs[1:]
 	}
 	heap.Init(&heads)
@@ -352,20 +599,22 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transa
 }
 
 func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
-	if len(t.heads) == 0 {
+	if len(t.heads.txs) == 0 {
 		return nil
 	}
-	return t.heads[0]
+	return t.heads.txs[0]
 }
 
 func (t *TransactionsByPriceAndNonce) Shift() {
-	acc, _ := Sender(t.signer, t.heads[0])
+	acc, _ := Sender(t.signer, t.heads.txs[0])
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		t.heads[0], t.txs[acc] = txs[0], txs[1:]
-		heap.Fix(&t.heads, 0)
-	} else {
-		heap.Pop(&t.heads)
+		if _, err := txs[0].EffectiveGasTip(t.heads.baseFee); err == nil {
+			t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
+			heap.Fix(&t.heads, 0)
+			return
+		}
 	}
+	heap.Pop(&t.heads)
 }
 
 func (t *TransactionsByPriceAndNonce) Pop() {
@@ -387,11 +636,14 @@ type Message struct {
 	amount     *big.Int
 	gasLimit   uint64
 	gasPrice   *big.Int
+	gasFeeCap  *big.Int
+	gasTipCap  *big.Int
 	data       []byte
+	accessList AccessList
 	checkNonce bool
 }
 
-func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, checkNonce bool) Message {
+func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, checkNonce bool) Message {
 	return Message{
 		from:       from,
 		to:         to,
@@ -399,16 +651,22 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 		amount:     amount,
 		gasLimit:   gasLimit,
 		gasPrice:   gasPrice,
+		gasFeeCap:  gasFeeCap,
+		gasTipCap:  gasTipCap,
 		data:       data,
+		accessList: accessList,
 		checkNonce: checkNonce,
 	}
 }
 
-func (m Message) From() common.Address { return m.from }
-func (m Message) To() *common.Address  { return m.to }
-func (m Message) GasPrice() *big.Int   { return m.gasPrice }
-func (m Message) Value() *big.Int      { return m.amount }
-func (m Message) Gas() uint64          { return m.gasLimit }
-func (m Message) Nonce() uint64        { return m.nonce }
-func (m Message) Data() []byte         { return m.data }
-func (m Message) CheckNonce() bool     { return m.checkNonce }
+func (m Message) From() common.Address   { return m.from }
+func (m Message) To() *common.Address    { return m.to }
+func (m Message) GasPrice() *big.Int     { return m.gasPrice }
+func (m Message) GasFeeCap() *big.Int    { return m.gasFeeCap }
+func (m Message) GasTipCap() *big.Int    { return m.gasTipCap }
+func (m Message) Value() *big.Int        { return m.amount }
+func (m Message) Gas() uint64            { return m.gasLimit }
+func (m Message) Nonce() uint64          { return m.nonce }
+func (m Message) Data() []byte           { return m.data }
+func (m Message) AccessList() AccessList { return m.accessList }
+func (m Message) CheckNonce() bool       { return m.checkNonce }
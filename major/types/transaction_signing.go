@@ -24,6 +24,10 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsLondon(blockNumber):
+		signer = NewLondonSigner(config.ChainId)
+	case config.IsBerlin(blockNumber):
+		signer = NewEIP2930Signer(config.ChainId)
 	case config.IsEIP155(blockNumber):
 		signer = NewEIP155Signer(config.ChainId)
 	case config.IsHomestead(blockNumber):
@@ -34,6 +38,18 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	return signer
 }
 
+// LatestSigner returns the most permissive signer the chain config can ever
+// require, for use where the block number is not known, such as the tx pool.
+func LatestSigner(config *params.ChainConfig) Signer {
+	if config.LondonBlock != nil {
+		return NewLondonSigner(config.ChainId)
+	}
+	if config.BerlinBlock != nil {
+		return NewEIP2930Signer(config.ChainId)
+	}
+	return NewEIP155Signer(config.ChainId)
+}
+
 func SignTx(tx *Transaction, s Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
 	h := s.Hash(tx)
 	sig, err := crypto.Sign(h[:], prv)
@@ -71,6 +87,125 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+// LondonSigner accepts dynamic fee transactions in addition to everything the
+// EIP2930Signer accepts.
+type LondonSigner struct{ EIP2930Signer }
+
+func NewLondonSigner(chainId *big.Int) LondonSigner {
+	return LondonSigner{NewEIP2930Signer(chainId)}
+}
+
+func (s LondonSigner) Equal(s2 Signer) bool {
+	london, ok := s2.(LondonSigner)
+	return ok && london.chainId.Cmp(s.chainId) == 0
+}
+
+func (s LondonSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.Sender(tx)
+	}
+	if tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	V := new(big.Int).Add(tx.data.V, big.NewInt(27))
+	return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+}
+
+func (s LondonSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.SignatureValues(tx, sig)
+	}
+	if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return R, S, big.NewInt(int64(sig[64])), nil
+}
+
+func (s LondonSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != DynamicFeeTxType {
+		return s.EIP2930Signer.Hash(tx)
+	}
+	return prefixedRlpHash(tx.Type(), []interface{}{
+		s.chainId,
+		tx.data.AccountNonce,
+		tx.data.GasTipCap,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.data.AccessList,
+	})
+}
+
+// EIP2930Signer accepts access list transactions in addition to everything the
+// EIP155Signer accepts.
+type EIP2930Signer struct{ EIP155Signer }
+
+func NewEIP2930Signer(chainId *big.Int) EIP2930Signer {
+	return EIP2930Signer{NewEIP155Signer(chainId)}
+}
+
+func (s EIP2930Signer) Equal(s2 Signer) bool {
+	eip2930, ok := s2.(EIP2930Signer)
+	return ok && eip2930.chainId.Cmp(s.chainId) == 0
+}
+
+func (s EIP2930Signer) Sender(tx *Transaction) (common.Address, error) {
+	switch tx.Type() {
+	case LegacyTxType:
+		return s.EIP155Signer.Sender(tx)
+	case AccessListTxType:
+		if tx.data.ChainID.Cmp(s.chainId) != 0 {
+			return common.Address{}, ErrInvalidChainId
+		}
+		V := new(big.Int).Add(tx.data.V, big.NewInt(27))
+		return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+	default:
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+}
+
+func (s EIP2930Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	switch tx.Type() {
+	case LegacyTxType:
+		return s.EIP155Signer.SignatureValues(tx, sig)
+	case AccessListTxType:
+		if tx.data.ChainID.Sign() != 0 && tx.data.ChainID.Cmp(s.chainId) != 0 {
+			return nil, nil, nil, ErrInvalidChainId
+		}
+		R, S, _, err = HomesteadSigner{}.SignatureValues(tx, sig)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return R, S, big.NewInt(int64(sig[64])), nil
+	default:
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+}
+
+func (s EIP2930Signer) Hash(tx *Transaction) common.Hash {
+	switch tx.Type() {
+	case AccessListTxType:
+		return prefixedRlpHash(tx.Type(), []interface{}{
+			s.chainId,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.data.AccessList,
+		})
+	default:
+		return s.EIP155Signer.Hash(tx)
+	}
+}
+
 type EIP155Signer struct {
 	chainId, chainIdMul *big.Int
 }
@@ -93,6 +228,9 @@ func (s EIP155Signer) Equal(s2 Signer) bool {
 var big8 = big.NewInt(8)
 
 func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	if !tx.Protected() {
 		return HomesteadSigner{}.Sender(tx)
 	}
@@ -140,6 +278,9 @@ func (hs HomesteadSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v
 }
 
 func (hs HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(hs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, true)
 }
 
@@ -172,6 +313,9 @@ func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != LegacyTxType {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
 	return recoverPlain(fs.Hash(tx), tx.data.R, tx.data.S, tx.data.V, false)
 }
 
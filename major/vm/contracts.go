@@ -3,12 +3,14 @@ package vm
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"math/big"
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/general/math"
 	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/black/blake2b"
 	"github.com/ddmchain/go-ddmchain/black/bn256"
 	"github.com/ddmchain/go-ddmchain/part"
 	"golang.org/x/crypto/ripemd160"
@@ -37,6 +39,18 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+var PrecompiledContractsIstanbul = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1}): &ecrecover{},
+	common.BytesToAddress([]byte{2}): &sha256hash{},
+	common.BytesToAddress([]byte{3}): &ripemd160hash{},
+	common.BytesToAddress([]byte{4}): &dataCopy{},
+	common.BytesToAddress([]byte{5}): &bigModExp{},
+	common.BytesToAddress([]byte{6}): &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}): &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}): &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}): &blake2F{},
+}
+
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
 	if contract.UseGas(gas) {
@@ -105,6 +119,23 @@ func (c *dataCopy) Run(in []byte) ([]byte, error) {
 
 type bigModExp struct{}
 
+// ActivePrecompiles returns the addresses of the precompiled contracts enabled
+// under the given rules.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	precompiles := PrecompiledContractsHomestead
+	switch {
+	case rules.IsIstanbul:
+		precompiles = PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		precompiles = PrecompiledContractsByzantium
+	}
+	addrs := make([]common.Address, 0, len(precompiles))
+	for addr := range precompiles {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 var (
 	big1      = big.NewInt(1)
 	big4      = big.NewInt(4)
@@ -244,6 +275,20 @@ func (c *bn256Add) RequiredGas(input []byte) uint64 {
 }
 
 func (c *bn256Add) Run(input []byte) ([]byte, error) {
+	return runBn256Add(input)
+}
+
+type bn256AddIstanbul struct{}
+
+func (c *bn256AddIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256AddGasIstanbul
+}
+
+func (c *bn256AddIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256Add(input)
+}
+
+func runBn256Add(input []byte) ([]byte, error) {
 	x, err := newCurvePoint(getData(input, 0, 64))
 	if err != nil {
 		return nil, err
@@ -264,6 +309,20 @@ func (c *bn256ScalarMul) RequiredGas(input []byte) uint64 {
 }
 
 func (c *bn256ScalarMul) Run(input []byte) ([]byte, error) {
+	return runBn256ScalarMul(input)
+}
+
+type bn256ScalarMulIstanbul struct{}
+
+func (c *bn256ScalarMulIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256ScalarMulGasIstanbul
+}
+
+func (c *bn256ScalarMulIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256ScalarMul(input)
+}
+
+func runBn256ScalarMul(input []byte) ([]byte, error) {
 	p, err := newCurvePoint(getData(input, 0, 64))
 	if err != nil {
 		return nil, err
@@ -289,6 +348,20 @@ func (c *bn256Pairing) RequiredGas(input []byte) uint64 {
 }
 
 func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
+	return runBn256Pairing(input)
+}
+
+type bn256PairingIstanbul struct{}
+
+func (c *bn256PairingIstanbul) RequiredGas(input []byte) uint64 {
+	return params.Bn256PairingBaseGasIstanbul + uint64(len(input)/192)*params.Bn256PairingPerPointGasIstanbul
+}
+
+func (c *bn256PairingIstanbul) Run(input []byte) ([]byte, error) {
+	return runBn256Pairing(input)
+}
+
+func runBn256Pairing(input []byte) ([]byte, error) {
 
 	if len(input)%192 > 0 {
 		return nil, errBadPairingInput
@@ -316,3 +389,57 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+const blake2FInputLength = 213
+
+var (
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+
+	errBlake2FInvalidFinalFlag = errors.New("invalid final flag")
+)
+
+// blake2F runs the BLAKE2b compression function on an input of the rounds
+// (4 bytes big endian), the state h (64 bytes), the message m (128 bytes), the
+// offset counters t (16 bytes) and the final block flag (1 byte), all words
+// little endian.
+type blake2F struct{}
+
+func (c *blake2F) RequiredGas(input []byte) uint64 {
+	if len(input) != blake2FInputLength {
+		return 0
+	}
+	return uint64(binary.BigEndian.Uint32(input[0:4])) * params.Blake2FRoundGas
+}
+
+func (c *blake2F) Run(input []byte) ([]byte, error) {
+	if len(input) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if input[212] != 0 && input[212] != 1 {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	var (
+		rounds = binary.BigEndian.Uint32(input[0:4])
+		final  = input[212] == 1
+
+		h [8]uint64
+		m [16]uint64
+		t [2]uint64
+	)
+	for i := 0; i < 8; i++ {
+		h[i] = binary.LittleEndian.Uint64(input[4+i*8:])
+	}
+	for i := 0; i < 16; i++ {
+		m[i] = binary.LittleEndian.Uint64(input[68+i*8:])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:204])
+	t[1] = binary.LittleEndian.Uint64(input[204:212])
+
+	blake2b.F(&h, m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint64(output[i*8:], h[i])
+	}
+	return output, nil
+}
@@ -92,7 +92,23 @@ func gasReturnDataCopy(gt params.GasTable, evm *EVM, contract *Contract, stack *
 	return gas, nil
 }
 
+// coldAccountGas warms up an account on its first access in a transaction,
+// returning the surcharge over the warm rate that the access costs.
+func coldAccountGas(evm *EVM, addr common.Address) uint64 {
+	if !evm.chainRules.IsBerlin || evm.StateDB.AddressInAccessList(addr) {
+		return 0
+	}
+	evm.StateDB.AddAddressToAccessList(addr)
+	return params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+}
+
 func gasSStore(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if evm.chainRules.IsBerlin {
+		return gasSStoreEIP2929(gt, evm, contract, stack, mem, memorySize)
+	}
+	if evm.chainRules.IsIstanbul {
+		return gasSStoreEIP2200(gt, evm, contract, stack, mem, memorySize)
+	}
 	var (
 		y, x = stack.Back(1), stack.Back(0)
 		val  = evm.StateDB.GetState(contract.Address(), common.BigToHash(x))
@@ -111,6 +127,99 @@ func gasSStore(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, m
 	}
 }
 
+// gasSStoreEIP2200 meters SSTORE against the slot value at the start of the
+// transaction, charging little for writes to already dirty slots and refunding
+// writes that restore the original value.
+func gasSStoreEIP2200(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if contract.Gas <= params.SstoreSentryGasEIP2200 {
+		return 0, ErrSstoreSentry
+	}
+	var (
+		y, x    = stack.Back(1), stack.Back(0)
+		current = evm.StateDB.GetState(contract.Address(), common.BigToHash(x))
+	)
+	value := common.BigToHash(y)
+
+	if current == value {
+		return params.SstoreNoopGasEIP2200, nil
+	}
+	original := evm.StateDB.GetCommittedState(contract.Address(), common.BigToHash(x))
+	if original == current {
+		if original == (common.Hash{}) {
+			return params.SstoreInitGasEIP2200, nil
+		}
+		if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+		return params.SstoreCleanGasEIP2200, nil
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			evm.StateDB.SubRefund(params.SstoreClearRefundEIP2200)
+		} else if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreInitRefundEIP2200)
+		} else {
+			evm.StateDB.AddRefund(params.SstoreCleanRefundEIP2200)
+		}
+	}
+	return params.SstoreDirtyGasEIP2200, nil
+}
+
+// gasSStoreEIP2929 is the EIP-2200 metering with the slot load priced by the
+// access list: a cold slot costs extra once, after which reads are warm.
+func gasSStoreEIP2929(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if contract.Gas <= params.SstoreSentryGasEIP2200 {
+		return 0, ErrSstoreSentry
+	}
+	var (
+		y, x = stack.Back(1), stack.Back(0)
+		slot = common.BigToHash(x)
+		cost uint64
+	)
+	if _, ok := evm.StateDB.SlotInAccessList(contract.Address(), slot); !ok {
+		evm.StateDB.AddSlotToAccessList(contract.Address(), slot)
+		cost = params.ColdSloadCostEIP2929
+	}
+	var (
+		value   = common.BigToHash(y)
+		current = evm.StateDB.GetState(contract.Address(), slot)
+		reset   = params.SstoreCleanGasEIP2200 - params.ColdSloadCostEIP2929
+	)
+	if current == value {
+		return cost + params.WarmStorageReadCostEIP2929, nil
+	}
+	original := evm.StateDB.GetCommittedState(contract.Address(), slot)
+	if original == current {
+		if original == (common.Hash{}) {
+			return cost + params.SstoreInitGasEIP2200, nil
+		}
+		if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+		return cost + reset, nil
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			evm.StateDB.SubRefund(params.SstoreClearRefundEIP2200)
+		} else if value == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreClearRefundEIP2200)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			evm.StateDB.AddRefund(params.SstoreInitGasEIP2200 - params.WarmStorageReadCostEIP2929)
+		} else {
+			evm.StateDB.AddRefund(reset - params.WarmStorageReadCostEIP2929)
+		}
+	}
+	return cost + params.WarmStorageReadCostEIP2929, nil
+}
+
 func makeGasLog(n uint64) gasFunc {
 	return func(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 		requestedSize, overflow := bigUint64(stack.Back(1))
@@ -196,7 +305,7 @@ func gasExtCodeCopy(gt params.GasTable, evm *EVM, contract *Contract, stack *Sta
 	}
 
 	var overflow bool
-	if gas, overflow = math.SafeAdd(gas, gt.ExtcodeCopy); overflow {
+	if gas, overflow = math.SafeAdd(gas, gt.ExtcodeCopy+coldAccountGas(evm, common.BigToAddress(stack.Back(0)))); overflow {
 		return 0, errGasUintOverflow
 	}
 
@@ -263,15 +372,49 @@ func gasCreate(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, m
 	return gas, nil
 }
 
+func gasCreate2(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	var overflow bool
+	gas, err := memoryGasCost(mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	if gas, overflow = math.SafeAdd(gas, params.Create2Gas); overflow {
+		return 0, errGasUintOverflow
+	}
+	wordGas, overflow := bigUint64(stack.Back(2))
+	if overflow {
+		return 0, errGasUintOverflow
+	}
+	if wordGas, overflow = math.SafeMul(toWordSize(wordGas), params.Sha3WordGas); overflow {
+		return 0, errGasUintOverflow
+	}
+	if gas, overflow = math.SafeAdd(gas, wordGas); overflow {
+		return 0, errGasUintOverflow
+	}
+	return gas, nil
+}
+
+func gasExtCodeHash(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return gt.ExtcodeHash + coldAccountGas(evm, common.BigToAddress(stack.Back(0))), nil
+}
+
 func gasBalance(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
-	return gt.Balance, nil
+	return gt.Balance + coldAccountGas(evm, common.BigToAddress(stack.Back(0))), nil
 }
 
 func gasExtCodeSize(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
-	return gt.ExtcodeSize, nil
+	return gt.ExtcodeSize + coldAccountGas(evm, common.BigToAddress(stack.Back(0))), nil
 }
 
 func gasSLoad(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	if evm.chainRules.IsBerlin {
+		slot := common.BigToHash(stack.Back(0))
+		if _, ok := evm.StateDB.SlotInAccessList(contract.Address(), slot); !ok {
+			evm.StateDB.AddSlotToAccessList(contract.Address(), slot)
+			return params.ColdSloadCostEIP2929, nil
+		}
+		return params.WarmStorageReadCostEIP2929, nil
+	}
 	return gt.SLoad, nil
 }
 
@@ -290,10 +433,10 @@ func gasExp(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem
 
 func gasCall(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	var (
-		gas            = gt.Calls
 		transfersValue = stack.Back(2).Sign() != 0
 		address        = common.BigToAddress(stack.Back(1))
 		eip158         = evm.ChainConfig().IsEIP158(evm.BlockNumber)
+		gas            = gt.Calls + coldAccountGas(evm, address)
 	)
 	if eip158 {
 		if transfersValue && evm.StateDB.Empty(address) {
@@ -325,7 +468,7 @@ func gasCall(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem
 }
 
 func gasCallCode(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
-	gas := gt.Calls
+	gas := gt.Calls + coldAccountGas(evm, common.BigToAddress(stack.Back(1)))
 	if stack.Back(2).Sign() != 0 {
 		gas += params.CallValueTransferGas
 	}
@@ -365,6 +508,10 @@ func gasSuicide(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack,
 			address = common.BigToAddress(stack.Back(0))
 			eip158  = evm.ChainConfig().IsEIP158(evm.BlockNumber)
 		)
+		if evm.chainRules.IsBerlin && !evm.StateDB.AddressInAccessList(address) {
+			evm.StateDB.AddAddressToAccessList(address)
+			gas += params.ColdAccountAccessCostEIP2929
+		}
 
 		if eip158 {
 
@@ -388,7 +535,7 @@ func gasDelegateCall(gt params.GasTable, evm *EVM, contract *Contract, stack *St
 		return 0, err
 	}
 	var overflow bool
-	if gas, overflow = math.SafeAdd(gas, gt.Calls); overflow {
+	if gas, overflow = math.SafeAdd(gas, gt.Calls+coldAccountGas(evm, common.BigToAddress(stack.Back(1)))); overflow {
 		return 0, errGasUintOverflow
 	}
 
@@ -408,7 +555,7 @@ func gasStaticCall(gt params.GasTable, evm *EVM, contract *Contract, stack *Stac
 		return 0, err
 	}
 	var overflow bool
-	if gas, overflow = math.SafeAdd(gas, gt.Calls); overflow {
+	if gas, overflow = math.SafeAdd(gas, gt.Calls+coldAccountGas(evm, common.BigToAddress(stack.Back(1)))); overflow {
 		return 0, errGasUintOverflow
 	}
 
@@ -25,6 +25,8 @@ type Config struct {
 
 	EnablePreimageRecording bool
 
+	NoBaseFee bool
+
 	JumpTable [256]operation
 }
 
@@ -42,6 +44,10 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 
 	if !cfg.JumpTable[STOP].valid {
 		switch {
+		case evm.ChainConfig().IsIstanbul(evm.BlockNumber):
+			cfg.JumpTable = istanbulInstructionSet
+		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
+			cfg.JumpTable = constantinopleInstructionSet
 		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
 			cfg.JumpTable = byzantiumInstructionSet
 		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
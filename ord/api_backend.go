@@ -3,6 +3,7 @@ package les
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/ddmchain/go-ddmchain/user"
@@ -22,6 +23,11 @@ import (
 	"github.com/ddmchain/go-ddmchain/control"
 )
 
+var (
+	errNoFinality  = errors.New("finalized blocks are not tracked by light clients")
+	errNoPrivateTx = errors.New("light clients do not seal blocks for private transactions")
+)
+
 type LesApiBackend struct {
 	ddm *LightDDMchain
 	gpo *gasprice.Oracle
@@ -44,6 +50,9 @@ func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.ddm.blockchain.CurrentHeader(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return nil, errNoFinality
+	}
 
 	return b.ddm.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
@@ -86,6 +95,14 @@ func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.ddm.txPool.Add(ctx, signedTx)
 }
 
+func (b *LesApiBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return errNoPrivateTx
+}
+
+func (b *LesApiBackend) SendBundle(ctx context.Context, signedTxs types.Transactions) error {
+	return errNoPrivateTx
+}
+
 func (b *LesApiBackend) RemoveTx(txHash common.Hash) {
 	b.ddm.txPool.RemoveTx(txHash)
 }
@@ -114,6 +131,13 @@ func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.ddm.txPool.SubscribeTxPreEvent(ch)
 }
 
+func (b *LesApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.ddm.blockchain.SubscribeChainEvent(ch)
 }
@@ -146,6 +170,14 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gpo.SuggestTipCap(ctx)
+}
+
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
 func (b *LesApiBackend) ChainDb() ddmdb.Database {
 	return b.ddm.chainDb
 }
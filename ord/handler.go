@@ -12,6 +12,7 @@ import (
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/rule/dpos"
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/major/state"
 	"github.com/ddmchain/go-ddmchain/major/types"
@@ -40,6 +41,7 @@ const (
 	MaxCodeFetch             = 64  
 	MaxProofsFetch           = 64  
 	MaxHelperTrieProofsFetch = 64  
+	MaxDposCheckpointFetch   = 4
 	MaxTxSend                = 64  
 	MaxTxStatus              = 256 
 
@@ -80,6 +82,7 @@ type ProtocolManager struct {
 	networkId   uint64
 	chainConfig *params.ChainConfig
 	blockchain  BlockChain
+	engine      consensus.Engine
 	chainDb     ddmdb.Database
 	odr         *LesOdr
 	server      *LesServer
@@ -110,6 +113,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 		lightSync:   lightSync,
 		eventMux:    mux,
 		blockchain:  blockchain,
+		engine:      engine,
 		chainConfig: chainConfig,
 		chainDb:     chainDb,
 		odr:         odr,
@@ -292,7 +296,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetDposCheckpointMsg}
 
 func (pm *ProtocolManager) handleMsg(p *peer) error {
 
@@ -899,6 +903,62 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
 		return p.SendHelperTrieProofs(req.ReqID, bv, HelperTrieResps{Proofs: nodes.NodeList(), AuxData: auxData})
 
+	case GetDposCheckpointMsg:
+		p.Log().Trace("Received dpos checkpoint request")
+
+		var req struct {
+			ReqID uint64
+			Reqs  []DposCheckpointReq
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		var (
+			bytes int
+			resps []DposCheckpointResp
+		)
+		reqCnt := len(req.Reqs)
+		if reject(uint64(reqCnt), MaxDposCheckpointFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		engine, _ := pm.engine.(*dpos.Dpos)
+		chain, _ := pm.blockchain.(consensus.ChainReader)
+		for _, req := range req.Reqs {
+			if engine == nil || chain == nil {
+				break
+			}
+			header := pm.blockchain.GetHeaderByNumber(req.BlockNum)
+			if header == nil {
+				continue
+			}
+			root, prefix := pm.getHelperTrie(htCanonical, req.ChtNum)
+			if root == (common.Hash{}) {
+				continue
+			}
+			auxTrie, err := trie.New(root, trie.NewDatabase(ddmdb.NewTable(pm.chainDb, prefix)))
+			if err != nil {
+				continue
+			}
+			ancestors, snapshot, err := engine.CheckpointProof(chain, header)
+			if err != nil {
+				continue
+			}
+			var encNumber [8]byte
+			binary.BigEndian.PutUint64(encNumber[:], req.BlockNum)
+
+			var proof light.NodeList
+			auxTrie.Prove(encNumber[:], 0, &proof)
+
+			resps = append(resps, DposCheckpointResp{Header: header, Ancestors: ancestors, Snapshot: snapshot, Proof: proof})
+			if bytes += proof.DataSize() + len(snapshot) + (len(ancestors)+1)*estHeaderRlpSize; bytes >= softResponseLimit {
+				break
+			}
+		}
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		return p.SendDposCheckpoints(req.ReqID, bv, resps)
+
 	case HeaderProofsMsg:
 		if pm.odr == nil {
 			return errResp(ErrUnexpectedResponse, "")
@@ -940,6 +1000,27 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			Obj:     resp.Data,
 		}
 
+	case DposCheckpointMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received dpos checkpoint response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      []DposCheckpointResp
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgDposCheckpoints,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
 	case SendTxMsg:
 		if pm.txpool == nil {
 			return errResp(ErrRequestRejected, "")
@@ -56,6 +56,7 @@ const (
 	MsgProofsV2
 	MsgHeaderProofs
 	MsgHelperTrieProofs
+	MsgDposCheckpoints
 )
 
 type Msg struct {
@@ -27,6 +27,7 @@ var (
 	errDataHashMismatch    = errors.New("data hash mismatch")
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
+	errAncestorMismatch    = errors.New("ancestor hash mismatch")
 	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
 )
 
@@ -51,6 +52,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
 		return (*BloomRequest)(r)
+	case *light.DposCheckpointRequest:
+		return (*DposCheckpointRequest)(r)
 	default:
 		return nil
 	}
@@ -153,7 +156,7 @@ func (r *TrieRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetProofsV1Msg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetProofsV2Msg, 1)
 	default:
 		panic(nil)
@@ -292,7 +295,7 @@ func (r *ChtRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetHeaderProofsMsg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetHelperTrieProofsMsg, 1)
 	default:
 		panic(nil)
@@ -482,3 +485,84 @@ func (db *readTraceDB) Has(key []byte) (bool, error) {
 	_, err := db.Get(key)
 	return err == nil, nil
 }
+
+type DposCheckpointReq struct {
+	ChtNum, BlockNum uint64
+}
+
+type DposCheckpointResp struct {
+	Header    *types.Header
+	Ancestors []*types.Header
+	Snapshot  []byte
+	Proof     light.NodeList
+}
+
+type DposCheckpointRequest light.DposCheckpointRequest
+
+func (r *DposCheckpointRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetDposCheckpointMsg, 1)
+}
+
+func (r *DposCheckpointRequest) CanSend(peer *peer) bool {
+	peer.lock.RLock()
+	defer peer.lock.RUnlock()
+
+	if peer.version < lpv3 {
+		return false
+	}
+	return peer.headInfo.Number >= light.HelperTrieConfirmations && r.ChtNum <= (peer.headInfo.Number-light.HelperTrieConfirmations)/light.CHTFrequencyClient
+}
+
+func (r *DposCheckpointRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting dpos checkpoint", "cht", r.ChtNum, "block", r.BlockNum)
+	return peer.RequestDposCheckpoints(reqID, r.GetCost(peer), []DposCheckpointReq{{ChtNum: r.ChtNum, BlockNum: r.BlockNum}})
+}
+
+func (r *DposCheckpointRequest) Validate(db ddmdb.Database, msg *Msg) error {
+	log.Debug("Validating dpos checkpoint", "cht", r.ChtNum, "block", r.BlockNum)
+
+	if msg.MsgType != MsgDposCheckpoints {
+		return errInvalidMessageType
+	}
+	resps := msg.Obj.([]DposCheckpointResp)
+	if len(resps) != 1 {
+		return errInvalidEntryCount
+	}
+	resp := resps[0]
+	if resp.Header == nil || r.BlockNum != resp.Header.Number.Uint64() {
+		return errCHTNumberMismatch
+	}
+	var encNumber [8]byte
+	binary.BigEndian.PutUint64(encNumber[:], r.BlockNum)
+
+	nodeSet := resp.Proof.NodeSet()
+	reads := &readTraceDB{db: nodeSet}
+	value, err, _ := trie.VerifyProof(r.ChtRoot, encNumber[:], reads)
+	if err != nil {
+		return fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	var node light.ChtNode
+	if err := rlp.DecodeBytes(value, &node); err != nil {
+		return err
+	}
+	if node.Hash != resp.Header.Hash() {
+		return errCHTHashMismatch
+	}
+	child := resp.Header
+	for i := len(resp.Ancestors) - 1; i >= 0; i-- {
+		if resp.Ancestors[i].Hash() != child.ParentHash {
+			return errAncestorMismatch
+		}
+		child = resp.Ancestors[i]
+	}
+
+	r.Header = resp.Header
+	r.Td = node.Td
+	r.Ancestors = resp.Ancestors
+	r.Snapshot = resp.Snapshot
+	r.Proof = nodeSet
+	return nil
+}
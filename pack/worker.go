@@ -3,6 +3,7 @@ package miner
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
@@ -34,6 +35,8 @@ const (
 	chainSideChanSize = 10
 )
 
+var errBundleReverted = errors.New("bundled transaction reverted")
+
 type Agent interface {
 	Work() chan<- *Work
 	SetReturnCh(chan<- *Result)
@@ -239,13 +242,13 @@ func (self *worker) update() {
 				self.currentMu.Lock()
 				acc, _ := types.Sender(self.current.signer, ev.Tx)
 				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
-				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
+				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs, self.current.header.BaseFee)
 
 				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
 				self.currentMu.Unlock()
 			} else {
 
-				if self.config.Dpos != nil && self.config.Dpos.Period == 0 {
+				if self.config.DPos != nil && self.config.DPos.Period == 0 {
 					self.commitNewWork()
 				}
 			}
@@ -330,7 +333,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	}
 	work := &Work{
 		config:    self.config,
-		signer:    types.NewEIP155Signer(self.config.ChainId),
+		signer:    types.MakeSigner(self.config, header.Number),
 		state:     state,
 		ancestors: set.New(),
 		family:    set.New(),
@@ -382,6 +385,9 @@ func (self *worker) commitNewWork() {
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
 	}
+	if self.config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(self.config, parent.Header())
+	}
 
 	if atomic.LoadInt32(&self.mining) == 1 {
 		header.Coinbase = self.coinbase
@@ -414,12 +420,17 @@ func (self *worker) commitNewWork() {
 	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(work.state)
 	}
+	for _, bundle := range self.ddm.TxPool().Bundles(header.Number) {
+		if err := work.commitBundle(bundle, self.chain, self.coinbase); err != nil {
+			log.Debug("Skipping transaction bundle", "hash", bundle[0].Hash(), "size", len(bundle), "err", err)
+		}
+	}
 	pending, err := self.ddm.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
+	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending, header.BaseFee)
 	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 
 	var (
@@ -472,7 +483,7 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 }
 
 func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
-	gp := new(core.GasPool).AddGas(env.header.GasLimit)
+	gp := new(core.GasPool).AddGas(env.header.GasLimit - env.header.GasUsed)
 
 	var coalescedLogs []*types.Log
 
@@ -547,6 +558,33 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 	}
 }
 
+// commitBundle applies all transactions of a bundle in order, restoring the
+// work to its previous state if any of them cannot be included or reverts.
+func (env *Work) commitBundle(txs types.Transactions, bc *core.BlockChain, coinbase common.Address) error {
+	var (
+		gp       = new(core.GasPool).AddGas(env.header.GasLimit - env.header.GasUsed)
+		snap     = env.state.Copy()
+		gasUsed  = env.header.GasUsed
+		tcount   = env.tcount
+		included = len(env.txs)
+	)
+	for _, tx := range txs {
+		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
+
+		err, _ := env.commitTransaction(tx, bc, coinbase, gp)
+		if err == nil && env.receipts[len(env.receipts)-1].Status == types.ReceiptStatusFailed {
+			err = errBundleReverted
+		}
+		if err != nil {
+			env.state, env.header.GasUsed, env.tcount = snap, gasUsed, tcount
+			env.txs, env.receipts = env.txs[:included], env.receipts[:included]
+			return err
+		}
+		env.tcount++
+	}
+	return nil
+}
+
 func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) (error, []*types.Log) {
 	snap := env.state.Snapshot()
 
@@ -6,6 +6,7 @@ import (
 	"math/big"
 
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
 )
 
 var (
@@ -63,13 +64,35 @@ var (
 		},
 	}
 
-	AllDDMhashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(DDMhashConfig), nil}
-	AllDPosProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &DPosConfig{Period: 0, Epoch: 30000}}
+	AllDDMhashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(DDMhashConfig), nil}
+	AllDPosProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &DPosConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(DDMhashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(DDMhashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
+// TrustedCheckpoint is a canonical block a node syncing from scratch takes on
+// trust, so that it fetches the chain from there on instead of verifying it all
+// from genesis. Td is the total difficulty of the block, and Signers the dpos
+// signer set in force at it. Snapshot is the dpos voting snapshot at the block,
+// which is needed past the liveness and governance forks as their state can't
+// be rebuilt from headers.
+type TrustedCheckpoint struct {
+	Number   uint64           `json:"number"`
+	Hash     common.Hash      `json:"hash"`
+	Td       *big.Int         `json:"td"`
+	Signers  []common.Address `json:"signers,omitempty"`
+	Snapshot hexutil.Bytes    `json:"snapshot,omitempty"`
+}
+
+func (c *TrustedCheckpoint) String() string {
+	return fmt.Sprintf("#%d [%x…]", c.Number, c.Hash[:4])
+}
+
+// TrustedCheckpoints are the default sync checkpoints of the known networks, by
+// genesis hash. They are refreshed with every release.
+var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{}
+
 type ChainConfig struct {
 	ChainId *big.Int `json:"chainId"` 
 
@@ -86,6 +109,11 @@ type ChainConfig struct {
 
 	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` 
 
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"`
+	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`
+	BerlinBlock         *big.Int `json:"berlinBlock,omitempty"`
+	LondonBlock         *big.Int `json:"londonBlock,omitempty"`
+
 	DDMhash *DDMhashConfig `json:"ddmhash,omitempty"`
 	DPos *DPosConfig `json:"dpos,omitempty"`
 }
@@ -99,12 +127,63 @@ func (c *DDMhashConfig) String() string {
 type DPosConfig struct {
 	Period uint64 `json:"period"`
 	Epoch  uint64 `json:"epoch"`
+
+	StakeBlock    *big.Int `json:"stakeBlock,omitempty"`
+	MaxValidators uint64   `json:"maxValidators,omitempty"`
+	MinStake      *big.Int `json:"minStake,omitempty"`
+
+	RewardBlock    *big.Int       `json:"rewardBlock,omitempty"`
+	BlockReward    *big.Int       `json:"blockReward,omitempty"`
+	Treasury       common.Address `json:"treasury,omitempty"`
+	TreasuryShare  uint64         `json:"treasuryShare,omitempty"`
+	DelegatorShare uint64         `json:"delegatorShare,omitempty"`
+
+	ShuffleBlock *big.Int `json:"shuffleBlock,omitempty"`
+
+	SlashBlock *big.Int `json:"slashBlock,omitempty"`
+	SlashBurn  bool     `json:"slashBurn,omitempty"`
+
+	FinalityBlock    *big.Int `json:"finalityBlock,omitempty"`
+	FinalityInterval uint64   `json:"finalityInterval,omitempty"`
+
+	LivenessBlock *big.Int `json:"livenessBlock,omitempty"`
+	JailThreshold uint64   `json:"jailThreshold,omitempty"`
+
+	GovernanceBlock *big.Int `json:"governanceBlock,omitempty"`
 }
 
 func (c *DPosConfig) String() string {
 	return "dpos"
 }
 
+func (c *DPosConfig) IsStake(num *big.Int) bool {
+	return isForked(c.StakeBlock, num)
+}
+
+func (c *DPosConfig) IsReward(num *big.Int) bool {
+	return isForked(c.RewardBlock, num)
+}
+
+func (c *DPosConfig) IsShuffle(num *big.Int) bool {
+	return isForked(c.ShuffleBlock, num)
+}
+
+func (c *DPosConfig) IsSlash(num *big.Int) bool {
+	return isForked(c.SlashBlock, num)
+}
+
+func (c *DPosConfig) IsFinality(num *big.Int) bool {
+	return isForked(c.FinalityBlock, num)
+}
+
+func (c *DPosConfig) IsLiveness(num *big.Int) bool {
+	return isForked(c.LivenessBlock, num)
+}
+
+func (c *DPosConfig) IsGovernance(num *big.Int) bool {
+	return isForked(c.GovernanceBlock, num)
+}
+
 func (c *ChainConfig) String() string {
 	var engine interface{}
 	switch {
@@ -115,7 +194,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Istanbul: %v Berlin: %v London: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -124,6 +203,10 @@ func (c *ChainConfig) String() string {
 		c.EIP155Block,
 		c.EIP158Block,
 		c.ByzantiumBlock,
+		c.ConstantinopleBlock,
+		c.IstanbulBlock,
+		c.BerlinBlock,
+		c.LondonBlock,
 		engine,
 	)
 }
@@ -152,11 +235,33 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
+func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+	return isForked(c.ConstantinopleBlock, num)
+}
+
+func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
+	return isForked(c.IstanbulBlock, num)
+}
+
+func (c *ChainConfig) IsBerlin(num *big.Int) bool {
+	return isForked(c.BerlinBlock, num)
+}
+
+func (c *ChainConfig) IsLondon(num *big.Int) bool {
+	return isForked(c.LondonBlock, num)
+}
+
 func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	if num == nil {
 		return GasTableHomestead
 	}
 	switch {
+	case c.IsBerlin(num):
+		return GasTableBerlin
+	case c.IsIstanbul(num):
+		return GasTableIstanbul
+	case c.IsConstantinople(num):
+		return GasTableConstantinople
 	case c.IsEIP158(num):
 		return GasTableEIP158
 	case c.IsEIP150(num):
@@ -206,6 +311,41 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
+		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	if isForkIncompatible(c.IstanbulBlock, newcfg.IstanbulBlock, head) {
+		return newCompatError("Istanbul fork block", c.IstanbulBlock, newcfg.IstanbulBlock)
+	}
+	if isForkIncompatible(c.BerlinBlock, newcfg.BerlinBlock, head) {
+		return newCompatError("Berlin fork block", c.BerlinBlock, newcfg.BerlinBlock)
+	}
+	if isForkIncompatible(c.LondonBlock, newcfg.LondonBlock, head) {
+		return newCompatError("London fork block", c.LondonBlock, newcfg.LondonBlock)
+	}
+	if c.DPos != nil && newcfg.DPos != nil {
+		if isForkIncompatible(c.DPos.StakeBlock, newcfg.DPos.StakeBlock, head) {
+			return newCompatError("DPos stake fork block", c.DPos.StakeBlock, newcfg.DPos.StakeBlock)
+		}
+		if isForkIncompatible(c.DPos.RewardBlock, newcfg.DPos.RewardBlock, head) {
+			return newCompatError("DPos reward fork block", c.DPos.RewardBlock, newcfg.DPos.RewardBlock)
+		}
+		if isForkIncompatible(c.DPos.ShuffleBlock, newcfg.DPos.ShuffleBlock, head) {
+			return newCompatError("DPos shuffle fork block", c.DPos.ShuffleBlock, newcfg.DPos.ShuffleBlock)
+		}
+		if isForkIncompatible(c.DPos.SlashBlock, newcfg.DPos.SlashBlock, head) {
+			return newCompatError("DPos slash fork block", c.DPos.SlashBlock, newcfg.DPos.SlashBlock)
+		}
+		if isForkIncompatible(c.DPos.FinalityBlock, newcfg.DPos.FinalityBlock, head) {
+			return newCompatError("DPos finality fork block", c.DPos.FinalityBlock, newcfg.DPos.FinalityBlock)
+		}
+		if isForkIncompatible(c.DPos.LivenessBlock, newcfg.DPos.LivenessBlock, head) {
+			return newCompatError("DPos liveness fork block", c.DPos.LivenessBlock, newcfg.DPos.LivenessBlock)
+		}
+		if isForkIncompatible(c.DPos.GovernanceBlock, newcfg.DPos.GovernanceBlock, head) {
+			return newCompatError("DPos governance fork block", c.DPos.GovernanceBlock, newcfg.DPos.GovernanceBlock)
+		}
+	}
 	return nil
 }
 
@@ -262,7 +402,8 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
-	IsByzantium                               bool
+	IsByzantium, IsConstantinople, IsIstanbul bool
+	IsBerlin, IsLondon                        bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -270,5 +411,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsConstantinople: c.IsConstantinople(num), IsIstanbul: c.IsIstanbul(num), IsBerlin: c.IsBerlin(num), IsLondon: c.IsLondon(num)}
 }
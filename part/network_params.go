@@ -4,4 +4,6 @@ package params
 const (
 
 	BloomBitsBlocks uint64 = 4096
+
+	ImmutabilityThreshold uint64 = 90000
 )
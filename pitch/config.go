@@ -38,6 +38,10 @@ type Config struct {
 
 	DataDir string
 
+	// DBEngine is the engine on-disk databases are opened with. If empty, each
+	// database keeps the engine it was created with and new ones use leveldb.
+	DBEngine string `toml:",omitempty"`
+
 	P2P p2p.Config
 
 	KeyStoreDir string `toml:",omitempty"`
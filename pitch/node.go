@@ -574,7 +574,11 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ddmdb.Database, er
 	if n.config.DataDir == "" {
 		return ddmdb.NewMemDatabase()
 	}
-	return ddmdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return ddmdb.NewDiskDatabase(n.config.DBEngine, n.config.resolvePath(name), cache, handles)
+}
+
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string) (ddmdb.Database, error) {
+	return openDatabaseWithFreezer(n.config, name, cache, handles, freezer)
 }
 
 func (n *Node) ResolvePath(x string) string {
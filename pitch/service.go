@@ -2,6 +2,7 @@
 package node
 
 import (
+	"path/filepath"
 	"reflect"
 
 	"github.com/ddmchain/go-ddmchain/user"
@@ -22,13 +23,42 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (dd
 	if ctx.config.DataDir == "" {
 		return ddmdb.NewMemDatabase()
 	}
-	db, err := ddmdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := ddmdb.NewDiskDatabase(ctx.config.DBEngine, ctx.config.resolvePath(name), cache, handles)
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// OpenDatabaseWithFreezer is like OpenDatabase, but moves the immutable part
+// of the chain into a freezer in the given directory, which is resolved like
+// the database itself if relative. It defaults to ancient inside the database.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int, freezer string) (ddmdb.Database, error) {
+	return openDatabaseWithFreezer(ctx.config, name, cache, handles, freezer)
+}
+
+func openDatabaseWithFreezer(config *Config, name string, cache int, handles int, freezer string) (ddmdb.Database, error) {
+	if config.DataDir == "" {
+		return ddmdb.NewMemDatabase()
+	}
+	root := config.resolvePath(name)
+	if freezer == "" {
+		freezer = filepath.Join(root, "ancient")
+	} else {
+		freezer = config.resolvePath(freezer)
+	}
+	db, err := ddmdb.NewDiskDatabase(config.DBEngine, root, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	frdb, err := ddmdb.NewFreezerDatabase(db, freezer)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return frdb, nil
+}
+
 func (ctx *ServiceContext) ResolvePath(path string) string {
 	return ctx.config.resolvePath(path)
 }
@@ -330,8 +330,15 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 		if _, err := s.List(); err != nil {
 			return wrapStreamError(err, typ)
 		}
-		for _, f := range fields {
+		for i, f := range fields {
 			err := f.info.decoder(s, val.Field(f.index))
+			if err == EOL && f.optional {
+				for _, rest := range fields[i:] {
+					v := val.Field(rest.index)
+					v.Set(reflect.Zero(v.Type()))
+				}
+				break
+			}
 			if err == EOL {
 				return &decodeError{msg: "too few elements", typ: typ}
 			} else if err != nil {
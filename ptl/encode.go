@@ -440,9 +440,14 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	if err != nil {
 		return nil, err
 	}
+	firstOptional := firstOptionalField(fields)
 	writer := func(val reflect.Value, w *encbuf) error {
+		end := len(fields)
+		for end > firstOptional && val.Field(fields[end-1].index).IsZero() {
+			end--
+		}
 		lh := w.list()
-		for _, f := range fields {
+		for _, f := range fields[:end] {
 			if err := f.info.writer(val.Field(f.index), w); err != nil {
 				return err
 			}
@@ -63,6 +63,11 @@ type CallMsg struct {
 	GasPrice *big.Int        
 	Value    *big.Int        
 	Data     []byte          
+
+	AccessList types.AccessList
+
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
 }
 
 type ContractCaller interface {
@@ -232,6 +232,9 @@ func (ddmhash *DDMhash) verifyHeader(chain consensus.ChainReader, header, parent
 	if diff := new(big.Int).Sub(header.Number, parent.Number); diff.Cmp(big.NewInt(1)) != 0 {
 		return consensus.ErrInvalidNumber
 	}
+	if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		return err
+	}
 
 	if seal {
 		if err := ddmhash.VerifySeal(chain, header); err != nil {
@@ -438,6 +441,7 @@ func (ddmhash *DDMhash) Prepare(chain consensus.ChainReader, header *types.Heade
 func (ddmhash *DDMhash) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
 
 	accumulateRewards(chain.Config(), state, header, uncles)
+	misc.AccumulateTips(chain.Config(), state, header, txs, receipts, header.Coinbase)
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
 	return types.NewBlock(header, txs, uncles, receipts), nil
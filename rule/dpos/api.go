@@ -2,7 +2,13 @@
 package dpos
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
 	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/math"
 	"github.com/ddmchain/go-ddmchain/rule"
 	"github.com/ddmchain/go-ddmchain/major/types"
 	"github.com/ddmchain/go-ddmchain/control"
@@ -67,6 +73,25 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return snap.signers(), nil
 }
 
+func (api *API) GetSignerStatus(number *rpc.BlockNumber) (map[common.Address]*SignerStatus, error) {
+
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.dpos.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.status(), nil
+}
+
 func (api *API) Proposals() map[common.Address]bool {
 	api.dpos.lock.RLock()
 	defer api.dpos.lock.RUnlock()
@@ -78,16 +103,78 @@ func (api *API) Proposals() map[common.Address]bool {
 	return proposals
 }
 
-func (api *API) Propose(address common.Address, auth bool) {
+func (api *API) ParamProposals() map[string]*ParamChange {
+	api.dpos.lock.RLock()
+	defer api.dpos.lock.RUnlock()
+
+	proposals := make(map[string]*ParamChange)
+	for key, change := range api.dpos.paramProposals {
+		proposals[paramNames[key]] = change
+	}
+	return proposals
+}
+
+type paramProposal struct {
+	Value      *math.HexOrDecimal256 `json:"value"`
+	Activation math.HexOrDecimal64   `json:"activation"`
+}
+
+// Propose injects a new vote to be cast by the local signer. The target is
+// either a signer address, voted on with a boolean authorization, or the name
+// of a governance parameter, voted on with a {value, activation} object.
+func (api *API) Propose(target string, value json.RawMessage) error {
+	if common.IsHexAddress(target) {
+		var auth bool
+		if err := json.Unmarshal(value, &auth); err != nil {
+			return err
+		}
+		api.dpos.lock.Lock()
+		defer api.dpos.lock.Unlock()
+
+		api.dpos.proposals[common.HexToAddress(target)] = auth
+		return nil
+	}
+	key, ok := paramKey(target)
+	if !ok {
+		return fmt.Errorf("unknown proposal target %q", target)
+	}
+	var proposal paramProposal
+	if err := json.Unmarshal(value, &proposal); err != nil {
+		return err
+	}
+	if proposal.Value == nil {
+		return errors.New("missing parameter value")
+	}
+	change := &ParamChange{
+		Key:        key,
+		Value:      (*big.Int)(proposal.Value),
+		Activation: uint64(proposal.Activation),
+	}
+	if err := change.validate(); err != nil {
+		return err
+	}
+	if head := api.chain.CurrentHeader().Number.Uint64(); change.Activation <= head {
+		return fmt.Errorf("activation block %d not above head %d", change.Activation, head)
+	}
 	api.dpos.lock.Lock()
 	defer api.dpos.lock.Unlock()
 
-	api.dpos.proposals[address] = auth
+	api.dpos.paramProposals[key] = change
+	return nil
 }
 
-func (api *API) Discard(address common.Address) {
+func (api *API) Discard(target string) error {
 	api.dpos.lock.Lock()
 	defer api.dpos.lock.Unlock()
 
-	delete(api.dpos.proposals, address)
+	if common.IsHexAddress(target) {
+		delete(api.dpos.proposals, common.HexToAddress(target))
+		return nil
+	}
+	key, ok := paramKey(target)
+	if !ok {
+		return fmt.Errorf("unknown proposal target %q", target)
+	}
+	delete(api.dpos.paramProposals, key)
+	return nil
 }
@@ -0,0 +1,191 @@
+
+package dpos
+
+import (
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/major/types"
+)
+
+const confirmDepth = 9
+
+var (
+	errNotCheckpoint = errors.New("block is not an epoch checkpoint")
+
+	errInvalidCheckpointSnapshot = errors.New("snapshot does not match checkpoint header")
+
+	errInvalidCheckpointAncestors = errors.New("invalid checkpoint ancestor headers")
+
+	errCheckpointSnapshotRequired = errors.New("checkpoint past the liveness or governance fork needs a snapshot")
+)
+
+func (s *Snapshot) checkpointDepth() uint64 {
+	depth := s.recentLimit()
+	if depth < confirmDepth {
+		depth = confirmDepth
+	}
+	if depth > s.Number {
+		depth = s.Number
+	}
+	return depth
+}
+
+func (c *Dpos) Epoch() uint64 {
+	return c.config.Epoch
+}
+
+// CheckpointProof returns the encoded voting snapshot at an epoch header along
+// with the headers preceding it, oldest first, that a light client needs to
+// verify the recent signer list and the in-turn confirmation headers.
+func (c *Dpos) CheckpointProof(chain consensus.ChainReader, header *types.Header) ([]*types.Header, []byte, error) {
+	number := header.Number.Uint64()
+	if number == 0 || number%c.config.Epoch != 0 {
+		return nil, nil, errNotCheckpoint
+	}
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	ancestors := make([]*types.Header, snap.checkpointDepth())
+	parent := header
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if parent = chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			return nil, nil, consensus.ErrUnknownAncestor
+		}
+		ancestors[i] = parent
+	}
+	blob, err := snap.encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ancestors, blob, nil
+}
+
+// ImportCheckpoint seeds the engine with the voting snapshot of a trusted epoch
+// header, so that the chain can be verified from that header onwards without
+// replaying its history. The signer set and the recent signers are checked
+// against the headers; liveness counters, jail state and governance parameters
+// cannot be proven from headers alone and are taken as served.
+func (c *Dpos) ImportCheckpoint(header *types.Header, ancestors []*types.Header, blob []byte) error {
+	number := header.Number.Uint64()
+	if number == 0 || number%c.config.Epoch != 0 {
+		return errNotCheckpoint
+	}
+	snap, err := decodeSnapshot(blob)
+	if err != nil {
+		return err
+	}
+	snap.config = c.config
+	snap.sigcache = c.signatures
+
+	if snap.Number != number || snap.Hash != header.Hash() || len(snap.Votes) != 0 || len(snap.Tally) != 0 {
+		return errInvalidCheckpointSnapshot
+	}
+	signers, elected := snap.signers(), checkpointSigners(header)
+	if len(signers) != len(elected) {
+		return errInvalidCheckpointSigners
+	}
+	for i := range signers {
+		if signers[i] != elected[i] {
+			return errInvalidCheckpointSigners
+		}
+	}
+	if uint64(len(ancestors)) < snap.checkpointDepth() {
+		return errInvalidCheckpointAncestors
+	}
+	child := header
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if ancestors[i].Hash() != child.ParentHash || ancestors[i].Number.Uint64()+1 != child.Number.Uint64() {
+			return errInvalidCheckpointAncestors
+		}
+		child = ancestors[i]
+	}
+	first := ancestors[0].Number.Uint64()
+	for block, recent := range snap.Recents {
+		var sealer *types.Header
+		switch {
+		case block == number:
+			sealer = header
+		case block >= first && block < number:
+			sealer = ancestors[block-first]
+		default:
+			return errInvalidCheckpointSnapshot
+		}
+		signer, err := ecrecover(sealer, c.signatures)
+		if err != nil {
+			return err
+		}
+		if signer != recent {
+			return errInvalidCheckpointSnapshot
+		}
+	}
+	if _, ok := snap.Recents[number]; !ok {
+		return errInvalidCheckpointSnapshot
+	}
+	c.recents.Add(snap.Hash, snap)
+	return snap.store(c.db)
+}
+
+// TrustCheckpoint seeds the engine with a voting snapshot at a trusted epoch
+// header. A snapshot given along with the checkpoint is checked against the
+// header and its ancestors, oldest first, as by ImportCheckpoint. Otherwise it
+// is built from the given signer set, or the one in the header, and the recent
+// signers of the ancestors. That is only possible before the liveness and
+// governance forks, whose jail state and parameters headers can't prove.
+func (c *Dpos) TrustCheckpoint(header *types.Header, ancestors []*types.Header, signers []common.Address, snapshot []byte) error {
+	number := header.Number.Uint64()
+	if number == 0 || number%c.config.Epoch != 0 {
+		return errNotCheckpoint
+	}
+	if len(snapshot) > 0 {
+		return c.ImportCheckpoint(header, ancestors, snapshot)
+	}
+	if c.config.IsLiveness(header.Number) || c.config.IsGovernance(header.Number) {
+		return errCheckpointSnapshotRequired
+	}
+	elected := checkpointSigners(header)
+	if len(signers) == 0 {
+		signers = elected
+	}
+	snap := newSnapshot(c.config, c.signatures, number, header.Hash(), signers)
+	if len(snap.Signers) == 0 {
+		return errInvalidCheckpointSigners
+	}
+	if len(elected) > 0 {
+		listed := snap.signers()
+		if len(listed) != len(elected) {
+			return errInvalidCheckpointSigners
+		}
+		for i := range listed {
+			if listed[i] != elected[i] {
+				return errInvalidCheckpointSigners
+			}
+		}
+	}
+	if uint64(len(ancestors)) < snap.checkpointDepth() {
+		return errInvalidCheckpointAncestors
+	}
+	child := header
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if ancestors[i].Hash() != child.ParentHash || ancestors[i].Number.Uint64()+1 != child.Number.Uint64() {
+			return errInvalidCheckpointAncestors
+		}
+		child = ancestors[i]
+	}
+	limit := snap.recentLimit()
+	for i := uint64(0); i < limit && i < number; i++ {
+		sealer := header
+		if i > 0 {
+			sealer = ancestors[uint64(len(ancestors))-i]
+		}
+		signer, err := ecrecover(sealer, c.signatures)
+		if err != nil {
+			return err
+		}
+		snap.Recents[number-i] = signer
+	}
+	c.recents.Add(snap.Hash, snap)
+	return snap.store(c.db)
+}
@@ -0,0 +1,435 @@
+
+package dpos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+// snapshotVersion prefixes every stored snapshot. Blobs starting with '{' are
+// snapshots written by older releases in JSON and are still readable, as are
+// version 1 snapshots predating governance votes.
+const (
+	snapshotVersionV1 = 1
+	snapshotVersion   = 2
+)
+
+const defaultSnapshotRetention = 128
+
+var (
+	snapshotPrefix      = []byte("dpos-")
+	snapshotIndexPrefix = []byte("dpos-index-")
+	snapshotTailKey     = []byte("dpos-tail")
+	snapshotUpgradeKey  = []byte("dpos-upgrade-index")
+
+	finalityVoteKey = []byte("LastFinalityVote")
+)
+
+var errUnknownSnapshotVersion = errors.New("unknown snapshot version")
+
+type storedRecent struct {
+	Block  uint64
+	Signer common.Address
+}
+
+type storedTally struct {
+	Address   common.Address
+	Authorize bool
+	Votes     uint64
+}
+
+type storedLiveness struct {
+	Signer common.Address
+	Signed uint64
+	Missed uint64
+	Jailed bool
+}
+
+type storedParam struct {
+	Key   uint8
+	Value *big.Int
+}
+
+type storedSnapshotV1 struct {
+	Number   uint64
+	Hash     common.Hash
+	Signers  []common.Address
+	Recents  []storedRecent
+	Votes    []*Vote
+	Tally    []storedTally
+	Liveness []storedLiveness
+}
+
+type storedSnapshot struct {
+	Number    uint64
+	Hash      common.Hash
+	Signers   []common.Address
+	Recents   []storedRecent
+	Votes     []*Vote
+	Tally     []storedTally
+	Liveness  []storedLiveness
+	Proposals []*ParamChange
+	Params    []storedParam
+	Pending   []*ParamChange
+}
+
+func snapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotPrefix...), hash[:]...)
+}
+
+func snapshotIndexKey(number uint64) []byte {
+	key := make([]byte, len(snapshotIndexPrefix)+8)
+	copy(key, snapshotIndexPrefix)
+	binary.BigEndian.PutUint64(key[len(snapshotIndexPrefix):], number)
+	return key
+}
+
+func (s *Snapshot) encode() ([]byte, error) {
+	stored := &storedSnapshot{
+		Number:  s.Number,
+		Hash:    s.Hash,
+		Signers: s.signers(),
+		Votes:   s.Votes,
+		Params:  s.storedParams(),
+		Pending: s.Pending,
+	}
+	for block, signer := range s.Recents {
+		stored.Recents = append(stored.Recents, storedRecent{block, signer})
+	}
+	sort.Slice(stored.Recents, func(i, j int) bool {
+		return stored.Recents[i].Block < stored.Recents[j].Block
+	})
+	for address, tally := range s.Tally {
+		stored.Tally = append(stored.Tally, storedTally{address, tally.Authorize, uint64(tally.Votes)})
+	}
+	sort.Slice(stored.Tally, func(i, j int) bool {
+		return bytes.Compare(stored.Tally[i].Address[:], stored.Tally[j].Address[:]) < 0
+	})
+	for _, tally := range stored.Tally {
+		if param := s.Tally[tally.Address].Param; param != nil {
+			stored.Proposals = append(stored.Proposals, param)
+		}
+	}
+	for signer, liveness := range s.Liveness {
+		stored.Liveness = append(stored.Liveness, storedLiveness{signer, liveness.Signed, liveness.Missed, liveness.Jailed})
+	}
+	sort.Slice(stored.Liveness, func(i, j int) bool {
+		return bytes.Compare(stored.Liveness[i].Signer[:], stored.Liveness[j].Signer[:]) < 0
+	})
+	blob, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{snapshotVersion}, blob...), nil
+}
+
+func decodeSnapshot(blob []byte) (*Snapshot, error) {
+	if len(blob) == 0 {
+		return nil, errUnknownSnapshotVersion
+	}
+	switch blob[0] {
+	case '{':
+		snap := new(Snapshot)
+		if err := json.Unmarshal(blob, snap); err != nil {
+			return nil, err
+		}
+		return snap, nil
+
+	case snapshotVersionV1:
+		legacy := new(storedSnapshotV1)
+		if err := rlp.DecodeBytes(blob[1:], legacy); err != nil {
+			return nil, err
+		}
+		return (&storedSnapshot{
+			Number:   legacy.Number,
+			Hash:     legacy.Hash,
+			Signers:  legacy.Signers,
+			Recents:  legacy.Recents,
+			Votes:    legacy.Votes,
+			Tally:    legacy.Tally,
+			Liveness: legacy.Liveness,
+		}).snapshot(), nil
+
+	case snapshotVersion:
+		stored := new(storedSnapshot)
+		if err := rlp.DecodeBytes(blob[1:], stored); err != nil {
+			return nil, err
+		}
+		return stored.snapshot(), nil
+
+	default:
+		return nil, errUnknownSnapshotVersion
+	}
+}
+
+func (stored *storedSnapshot) snapshot() *Snapshot {
+	snap := newSnapshot(nil, nil, stored.Number, stored.Hash, stored.Signers)
+	for _, recent := range stored.Recents {
+		snap.Recents[recent.Block] = recent.Signer
+	}
+	snap.Votes = stored.Votes
+	proposals := make(map[common.Address]*ParamChange)
+	for _, param := range stored.Proposals {
+		proposals[param.id()] = param
+	}
+	for _, tally := range stored.Tally {
+		snap.Tally[tally.Address] = Tally{Authorize: tally.Authorize, Votes: int(tally.Votes), Param: proposals[tally.Address]}
+	}
+	for _, liveness := range stored.Liveness {
+		snap.Liveness[liveness.Signer] = Liveness{Signed: liveness.Signed, Missed: liveness.Missed, Jailed: liveness.Jailed}
+	}
+	for _, param := range stored.Params {
+		snap.Params[param.Key] = param.Value
+	}
+	snap.Pending = stored.Pending
+	return snap
+}
+
+// readFinalityVote returns the height of the last finality checkpoint the local
+// signer attested to.
+func readFinalityVote(db ddmdb.Database) uint64 {
+	blob, err := db.Get(finalityVoteKey)
+	if err != nil || len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+func writeFinalityVote(db ddmdb.Database, number uint64) error {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], number)
+	return db.Put(finalityVoteKey, blob[:])
+}
+
+func readSnapshotIndex(db ddmdb.Database, number uint64) []common.Hash {
+	blob, err := db.Get(snapshotIndexKey(number))
+	if err != nil {
+		return nil
+	}
+	var hashes []common.Hash
+	if err := rlp.DecodeBytes(blob, &hashes); err != nil {
+		return nil
+	}
+	return hashes
+}
+
+func readSnapshotTail(db ddmdb.Database) uint64 {
+	blob, err := db.Get(snapshotTailKey)
+	if err != nil || len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+func writeSnapshotTail(db ddmdb.Database, number uint64) error {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], number)
+	return db.Put(snapshotTailKey, blob[:])
+}
+
+func indexSnapshot(db ddmdb.Database, number uint64, hash common.Hash) error {
+	hashes := readSnapshotIndex(db, number)
+	for _, known := range hashes {
+		if known == hash {
+			return nil
+		}
+	}
+	blob, err := rlp.EncodeToBytes(append(hashes, hash))
+	if err != nil {
+		return err
+	}
+	if err := db.Put(snapshotIndexKey(number), blob); err != nil {
+		return err
+	}
+	if number < readSnapshotTail(db) {
+		return writeSnapshotTail(db, number)
+	}
+	return nil
+}
+
+// pruneSnapshots deletes every indexed snapshot more than retention
+// checkpoints below head. A zero retention keeps all snapshots.
+func pruneSnapshots(db ddmdb.Database, head uint64, retention uint64) (int, error) {
+	if retention == 0 || head < retention*checkpointInterval {
+		return 0, nil
+	}
+	var (
+		tail   = readSnapshotTail(db)
+		cutoff = head - retention*checkpointInterval
+		pruned = 0
+	)
+	if tail >= cutoff {
+		return 0, nil
+	}
+	for number := tail - tail%checkpointInterval; number < cutoff; number += checkpointInterval {
+		for _, hash := range readSnapshotIndex(db, number) {
+			if err := db.Delete(snapshotKey(hash)); err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+		if err := db.Delete(snapshotIndexKey(number)); err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, writeSnapshotTail(db, cutoff)
+}
+
+// UpgradeSnapshots indexes the snapshots stored by releases predating the
+// snapshot index, so they are pruned like the others. Unreadable ones are
+// deleted. It only walks the database once.
+func (c *Dpos) UpgradeSnapshots() error {
+	if done, _ := c.db.Has(snapshotUpgradeKey); done {
+		return nil
+	}
+	it := c.db.NewIteratorWithPrefix(snapshotPrefix)
+	defer it.Release()
+
+	indexed, deleted := 0, 0
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(snapshotPrefix)+common.HashLength {
+			continue
+		}
+		hash := common.BytesToHash(key[len(snapshotPrefix):])
+		snap, err := decodeSnapshot(it.Value())
+		if err != nil || snap.Hash != hash {
+			if err := c.db.Delete(common.CopyBytes(key)); err != nil {
+				return err
+			}
+			deleted++
+			continue
+		}
+		if err := indexSnapshot(c.db, snap.Number, hash); err != nil {
+			return err
+		}
+		indexed++
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if indexed > 0 || deleted > 0 {
+		log.Info("Upgraded voting snapshots", "indexed", indexed, "deleted", deleted)
+	}
+	return c.db.Put(snapshotUpgradeKey, []byte{1})
+}
+
+func (c *Dpos) SetSnapshotRetention(retention uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.retention = retention
+}
+
+func (c *Dpos) pruneSnapshots(head uint64) error {
+	c.lock.RLock()
+	retention := c.retention
+	c.lock.RUnlock()
+
+	pruned, err := pruneSnapshots(c.db, head, retention)
+	if pruned > 0 {
+		log.Debug("Pruned stale voting snapshots", "head", head, "pruned", pruned)
+	}
+	return err
+}
+
+// replay rebuilds the voting snapshots of the canonical chain from the genesis
+// header, calling fn on the genesis snapshot and every checkpoint snapshot.
+func (c *Dpos) replay(chain consensus.ChainReader, fn func(*Snapshot) error) error {
+	genesis := chain.GetHeaderByNumber(0)
+	if genesis == nil {
+		return errUnknownBlock
+	}
+	snap := newSnapshot(c.config, c.signatures, 0, genesis.Hash(), checkpointSigners(genesis))
+	if err := fn(snap); err != nil {
+		return err
+	}
+	head := chain.CurrentHeader().Number.Uint64()
+	for snap.Number+checkpointInterval <= head {
+		headers := make([]*types.Header, 0, checkpointInterval)
+		for number := snap.Number + 1; number <= snap.Number+checkpointInterval; number++ {
+			header := chain.GetHeaderByNumber(number)
+			if header == nil {
+				return errUnknownBlock
+			}
+			headers = append(headers, header)
+		}
+		var err error
+		if snap, err = snap.apply(headers, chain.GetHeader); err != nil {
+			return err
+		}
+		if err := fn(snap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildSnapshots recomputes and stores the checkpoint snapshots of the
+// canonical chain, replacing whatever was stored before.
+func (c *Dpos) RebuildSnapshots(chain consensus.ChainReader) (int, error) {
+	stored := 0
+	err := c.replay(chain, func(snap *Snapshot) error {
+		if err := snap.store(c.db); err != nil {
+			return err
+		}
+		stored++
+		if snap.Number%(64*checkpointInterval) == 0 {
+			log.Info("Rebuilt voting snapshot", "number", snap.Number, "hash", snap.Hash)
+		}
+		return c.pruneSnapshots(snap.Number)
+	})
+	c.recents.Purge()
+	return stored, err
+}
+
+// VerifySnapshots checks every retained checkpoint snapshot of the canonical
+// chain against one recomputed from headers. Checkpoints that were never
+// stored are counted as missing.
+func (c *Dpos) VerifySnapshots(chain consensus.ChainReader) (checked int, missing int, err error) {
+	tail := readSnapshotTail(c.db)
+	err = c.replay(chain, func(snap *Snapshot) error {
+		if snap.Number < tail {
+			return nil
+		}
+		blob, err := c.db.Get(snapshotKey(snap.Hash))
+		if err != nil {
+			missing++
+			return nil
+		}
+		stored, err := decodeSnapshot(blob)
+		if err != nil {
+			return fmt.Errorf("snapshot %d [%x…]: %v", snap.Number, snap.Hash[:4], err)
+		}
+		have, _ := stored.encode()
+		want, err := snap.encode()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(have, want) {
+			return fmt.Errorf("snapshot %d [%x…] does not match headers", snap.Number, snap.Hash[:4])
+		}
+		indexed := false
+		for _, hash := range readSnapshotIndex(c.db, snap.Number) {
+			indexed = indexed || hash == snap.Hash
+		}
+		if !indexed {
+			return fmt.Errorf("snapshot %d [%x…] missing from index", snap.Number, snap.Hash[:4])
+		}
+		checked++
+		return nil
+	})
+	return checked, missing, err
+}
@@ -31,6 +31,8 @@ const (
 	inmemorySnapshots  = 128  
 	inmemorySignatures = 4096 
 
+	defaultValidators = 21
+
 	wiggleTime = 500 * time.Millisecond 
 )
 
@@ -60,6 +62,8 @@ var (
 
 	errInvalidCheckpointVote = errors.New("vote nonce in checkpoint block non-zero")
 
+	errInvalidStakeVote = errors.New("vote nonce after stake fork non-zero")
+
 	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
 
 	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
@@ -85,10 +89,27 @@ var (
 
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
 
+// Sealer produces the signatures of the local signer: header seals and
+// finality votes.
+type Sealer interface {
+	SignHeader(signer common.Address, header *types.Header) ([]byte, error)
+	SignVote(signer common.Address, vote *FinalityVote) ([]byte, error)
+}
+
+type localSealer SignerFn
+
+func (fn localSealer) SignHeader(signer common.Address, header *types.Header) ([]byte, error) {
+	return fn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+}
+
+func (fn localSealer) SignVote(signer common.Address, vote *FinalityVote) ([]byte, error) {
+	return fn(accounts.Account{Address: signer}, vote.sigHash().Bytes())
+}
+
 func sigHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewKeccak256()
 
-	rlp.Encode(hasher, []interface{}{
+	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -104,7 +125,11 @@ func sigHash(header *types.Header) (hash common.Hash) {
 		header.Extra[:len(header.Extra)-65], 
 		header.MixDigest,
 		header.Nonce,
-	})
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	rlp.Encode(hasher, enc)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -133,7 +158,7 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 }
 
 type Dpos struct {
-	config *params.DposConfig 
+	config *params.DPosConfig 
 	db     ddmdb.Database       
 
 	recents    *lru.ARCCache 
@@ -141,27 +166,45 @@ type Dpos struct {
 
 	proposals map[common.Address]bool 
 
+	paramProposals map[uint8]*ParamChange
+
+	finality *finalityTally
+
+	retention uint64
+
 	signer common.Address 
-	signFn SignerFn       
+	sealer Sealer         
 	lock   sync.RWMutex   
 }
 
-func New(config *params.DposConfig, db ddmdb.Database) *Dpos {
+func New(config *params.DPosConfig, db ddmdb.Database) *Dpos {
 
 	conf := *config
 	if conf.Epoch == 0 {
 		conf.Epoch = epochLength
 	}
+	if conf.MaxValidators == 0 {
+		conf.MaxValidators = defaultValidators
+	}
+	if conf.FinalityInterval == 0 {
+		conf.FinalityInterval = defaultFinalityInterval
+	}
+	if conf.JailThreshold == 0 {
+		conf.JailThreshold = defaultJailThreshold
+	}
 
 	recents, _ := lru.NewARC(inmemorySnapshots)
 	signatures, _ := lru.NewARC(inmemorySignatures)
 
 	return &Dpos{
-		config:     &conf,
-		db:         db,
-		recents:    recents,
-		signatures: signatures,
-		proposals:  make(map[common.Address]bool),
+		config:         &conf,
+		db:             db,
+		recents:        recents,
+		signatures:     signatures,
+		proposals:      make(map[common.Address]bool),
+		paramProposals: make(map[uint8]*ParamChange),
+		finality:       newFinalityTally(db),
+		retention:      defaultSnapshotRetention,
 	}
 }
 
@@ -206,12 +249,22 @@ func (c *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 		return errInvalidCheckpointBeneficiary
 	}
 
-	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+	slash := c.config.IsSlash(header.Number) && bytes.Equal(header.Nonce[:], nonceSlashVote)
+	unjail := c.config.IsLiveness(header.Number) && bytes.Equal(header.Nonce[:], nonceUnjailVote)
+	param := c.config.IsGovernance(header.Number) && bytes.Equal(header.Nonce[:], nonceParamVote)
+	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) && !slash && !unjail && !param {
 		return errInvalidVote
 	}
 	if checkpoint && !bytes.Equal(header.Nonce[:], nonceDropVote) {
 		return errInvalidCheckpointVote
 	}
+	stake := c.config.IsStake(header.Number)
+	if stake && !bytes.Equal(header.Nonce[:], nonceDropVote) && !slash && !unjail && !param {
+		return errInvalidStakeVote
+	}
+	if param && header.Coinbase != (common.Address{}) {
+		return errInvalidParamVote
+	}
 
 	if len(header.Extra) < extraVanity {
 		return errMissingVanity
@@ -219,6 +272,11 @@ func (c *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
+	if param {
+		if _, err := parseParamVote(header); err != nil {
+			return err
+		}
+	}
 
 	signersBytes := len(header.Extra) - extraVanity - extraSeal
 	if !checkpoint && signersBytes != 0 {
@@ -227,6 +285,9 @@ func (c *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 	if checkpoint && signersBytes%common.AddressLength != 0 {
 		return errInvalidCheckpointSigners
 	}
+	if checkpoint && stake && (signersBytes == 0 || uint64(signersBytes/common.AddressLength) > c.config.MaxValidators) {
+		return errInvalidCheckpointSigners
+	}
 
 	if header.MixDigest != (common.Hash{}) {
 		return errInvalidMixDigest
@@ -265,16 +326,21 @@ func (c *Dpos) verifyCascadingFields(chain consensus.ChainReader, header *types.
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
-		return ErrInvalidTimestamp
-	}
-
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
 		return err
 	}
+	if parent.Time.Uint64()+snap.governed().Period > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	if target, ok := snap.gasTarget(); ok && header.GasLimit != calcGasLimit(parent, target) {
+		return errInvalidGasLimit
+	}
+	if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		return err
+	}
 
-	if number%c.config.Epoch == 0 {
+	if number%c.config.Epoch == 0 && !c.config.IsStake(header.Number) {
 		signers := make([]byte, len(snap.Signers)*common.AddressLength)
 		for i, signer := range snap.signers() {
 			copy(signers[i*common.AddressLength:], signer[:])
@@ -294,6 +360,17 @@ func (c *Dpos) snapshot(chain consensus.ChainReader, number uint64, hash common.
 		headers []*types.Header
 		snap    *Snapshot
 	)
+	ancestors := parents
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		if len(ancestors) > 0 {
+			if first := ancestors[0].Number.Uint64(); number >= first && number-first < uint64(len(ancestors)) {
+				if header := ancestors[number-first]; header.Hash() == hash {
+					return header
+				}
+			}
+		}
+		return chain.GetHeader(hash, number)
+	}
 	for snap == nil {
 
 		if s, ok := c.recents.Get(hash); ok {
@@ -301,7 +378,7 @@ func (c *Dpos) snapshot(chain consensus.ChainReader, number uint64, hash common.
 			break
 		}
 
-		if number%checkpointInterval == 0 {
+		if number%checkpointInterval == 0 || number%c.config.Epoch == 0 {
 			if s, err := loadSnapshot(c.config, c.signatures, c.db, hash); err == nil {
 				log.Trace("Loaded voting snapshot form disk", "number", number, "hash", hash)
 				snap = s
@@ -314,11 +391,7 @@ func (c *Dpos) snapshot(chain consensus.ChainReader, number uint64, hash common.
 			if err := c.VerifyHeader(chain, genesis, false); err != nil {
 				return nil, err
 			}
-			signers := make([]common.Address, (len(genesis.Extra)-extraVanity-extraSeal)/common.AddressLength)
-			for i := 0; i < len(signers); i++ {
-				copy(signers[i][:], genesis.Extra[extraVanity+i*common.AddressLength:])
-			}
-			snap = newSnapshot(c.config, c.signatures, 0, genesis.Hash(), signers)
+			snap = newSnapshot(c.config, c.signatures, 0, genesis.Hash(), checkpointSigners(genesis))
 			if err := snap.store(c.db); err != nil {
 				return nil, err
 			}
@@ -348,7 +421,7 @@ func (c *Dpos) snapshot(chain consensus.ChainReader, number uint64, hash common.
 	for i := 0; i < len(headers)/2; i++ {
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
-	snap, err := snap.apply(headers)
+	snap, err := snap.apply(headers, getHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -359,6 +432,9 @@ func (c *Dpos) snapshot(chain consensus.ChainReader, number uint64, hash common.
 			return nil, err
 		}
 		log.Trace("Stored voting snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+		if err = c.pruneSnapshots(snap.Number); err != nil {
+			return nil, err
+		}
 	}
 	return snap, err
 }
@@ -396,11 +472,20 @@ func (c *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	for seen, recent := range snap.Recents {
 		if recent == signer {
 
-			if limit := uint64(len(snap.Signers)/2 + 1); seen > number-limit {
+			if limit := snap.recentLimit(); seen > number-limit {
 				return errUnauthorized
 			}
 		}
 	}
+	if c.config.IsLiveness(header.Number) {
+		unjail := bytes.Equal(header.Nonce[:], nonceUnjailVote)
+		if snap.jailed(signer) && !unjail {
+			return errJailed
+		}
+		if unjail && (!snap.jailed(signer) || header.Coinbase != signer) {
+			return errInvalidUnjail
+		}
+	}
 
 	confirmRand := snap.Number;
 	confirmNum := uint64(0)
@@ -435,7 +520,7 @@ func (c *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 	if err != nil {
 		return err
 	}
-	if number%c.config.Epoch != 0 {
+	if number%c.config.Epoch != 0 && !c.config.IsStake(header.Number) {
 		c.lock.RLock()
 
 		addresses := make([]common.Address, 0, len(c.proposals))
@@ -455,6 +540,26 @@ func (c *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 		}
 		c.lock.RUnlock()
 	}
+	var param *ParamChange
+	if c.config.IsGovernance(header.Number) && number%c.config.Epoch != 0 && header.Coinbase == (common.Address{}) {
+		c.lock.RLock()
+
+		changes := make([]*ParamChange, 0, len(c.paramProposals))
+		for _, change := range c.paramProposals {
+			if snap.validParam(change, number) {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) > 0 {
+			param = changes[rand.Intn(len(changes))]
+			copy(header.Nonce[:], nonceParamVote)
+		}
+		c.lock.RUnlock()
+	}
+	if c.config.IsLiveness(header.Number) && number%c.config.Epoch != 0 && snap.jailed(c.signer) {
+		header.Coinbase = c.signer
+		copy(header.Nonce[:], nonceUnjailVote)
+	}
 
 	confirmRand := snap.Number;
 	confirmNum := uint64(0)
@@ -469,8 +574,12 @@ func (c *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
 	}
 	header.Extra = header.Extra[:extraVanity]
+	if param != nil && bytes.Equal(header.Nonce[:], nonceParamVote) {
+		header.Extra = make([]byte, extraVanity)
+		encodeParamVote(header.Extra, param)
+	}
 
-	if number%c.config.Epoch == 0 {
+	if number%c.config.Epoch == 0 && !c.config.IsStake(header.Number) {
 		for _, signer := range snap.signers() {
 			header.Extra = append(header.Extra, signer[:]...)
 		}
@@ -483,7 +592,10 @@ func (c *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(c.config.Period))
+	if target, ok := snap.gasTarget(); ok {
+		header.GasLimit = calcGasLimit(parent, target)
+	}
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(snap.governed().Period))
 	if header.Time.Int64() < time.Now().Unix() {
 		header.Time = big.NewInt(time.Now().Unix())
 	}
@@ -491,18 +603,151 @@ func (c *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 }
 
 func (c *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	if c.config.IsStake(header.Number) {
+		if err := applyStaking(chain.Config(), header, state, txs, receipts); err != nil {
+			return nil, err
+		}
+		if header.Number.Uint64()%c.config.Epoch == 0 {
+			if err := c.elect(chain, header, state); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.config.IsSlash(header.Number) {
+		if err := c.slash(chain, header, state, txs, receipts); err != nil {
+			return nil, err
+		}
+	}
+	if chain.Config().IsLondon(header.Number) {
+		signer, err := c.beneficiary(header)
+		if err != nil {
+			return nil, err
+		}
+		misc.AccumulateTips(chain.Config(), state, header, txs, receipts, signer)
+	}
+	if c.config.IsReward(header.Number) {
+		signer, err := c.beneficiary(header)
+		if err != nil {
+			return nil, err
+		}
+		config := c.config
+		if c.config.IsGovernance(header.Number) {
+			snap, err := c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+			if err != nil {
+				return nil, err
+			}
+			config = snap.governed()
+		}
+		accumulateRewards(config, state, header, signer, txs, receipts)
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
 
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
 
+func (c *Dpos) elect(chain consensus.ChainReader, header *types.Header, state *state.StateDB) error {
+	elected := electValidators(c.config, state)
+	if len(elected) == 0 {
+		snap, err := c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+		if err != nil {
+			return err
+		}
+		elected = snap.signers()
+	}
+	signers := make([]byte, len(elected)*common.AddressLength)
+	for i, signer := range elected {
+		copy(signers[i*common.AddressLength:], signer[:])
+	}
+
+	if len(header.Extra) == extraVanity+extraSeal {
+		extra := make([]byte, 0, extraVanity+len(signers)+extraSeal)
+		extra = append(extra, header.Extra[:extraVanity]...)
+		extra = append(extra, signers...)
+		header.Extra = append(extra, header.Extra[extraVanity:]...)
+		return nil
+	}
+	if len(header.Extra) < extraVanity+extraSeal || !bytes.Equal(header.Extra[extraVanity:len(header.Extra)-extraSeal], signers) {
+		return errInvalidCheckpointSigners
+	}
+	return nil
+}
+
+func (c *Dpos) slash(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	marked := bytes.Equal(header.Nonce[:], nonceSlashVote)
+
+	number := header.Number.Uint64()
+	if number%c.config.Epoch == 0 {
+		return nil
+	}
+	offender, found := findEvidence(state, txs, receipts, c.signatures)
+	if found {
+		snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return err
+		}
+		if _, ok := snap.Signers[offender]; !ok {
+			found = false
+		}
+	}
+	if marked && (!found || header.Coinbase != offender) {
+		return errInvalidSlash
+	}
+	if !found {
+		return nil
+	}
+	if bytes.Equal(header.Nonce[:], nonceUnjailVote) {
+		return nil
+	}
+	if !marked {
+		if _, err := ecrecover(header, c.signatures); err == nil {
+			return errMissingSlash
+		}
+		header.Coinbase = offender
+		copy(header.Nonce[:], nonceSlashVote)
+	}
+	newLedger(state).slash(offender, c.config.SlashBurn)
+	return nil
+}
+
+func (c *Dpos) beneficiary(header *types.Header) (common.Address, error) {
+	if signer, err := ecrecover(header, c.signatures); err == nil {
+		return signer, nil
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.signer == (common.Address{}) {
+		return common.Address{}, errUnauthorized
+	}
+	return c.signer, nil
+}
+
+func checkpointSigners(header *types.Header) []common.Address {
+	signers := make([]common.Address, (len(header.Extra)-extraVanity-extraSeal)/common.AddressLength)
+	for i := 0; i < len(signers); i++ {
+		copy(signers[i][:], header.Extra[extraVanity+i*common.AddressLength:])
+	}
+	return signers
+}
+
 func (c *Dpos) Authorize(signer common.Address, signFn SignerFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	c.signer = signer
-	c.signFn = signFn
+	c.sealer = localSealer(signFn)
+}
+
+// AuthorizeSealer injects a signer whose signatures are produced by the given
+// sealer instead of a local keystore account.
+func (c *Dpos) AuthorizeSealer(signer common.Address, sealer Sealer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.signer = signer
+	c.sealer = sealer
 }
 
 func (c *Dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
@@ -513,18 +758,17 @@ func (c *Dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 		return nil, errUnknownBlock
 	}
 
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
-		return nil, errWaitTransactions
-	}
-
 	c.lock.RLock()
-	signer, signFn := c.signer, c.signFn
+	signer, sealer := c.signer, c.sealer
 	c.lock.RUnlock()
 
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return nil, err
 	}
+	if snap.governed().Period == 0 && len(block.Transactions()) == 0 {
+		return nil, errWaitTransactions
+	}
 	if _, authorized := snap.Signers[signer]; !authorized {
 		return nil, errUnauthorized
 	}
@@ -532,13 +776,18 @@ func (c *Dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 	for seen, recent := range snap.Recents {
 		if recent == signer {
 
-			if limit := uint64(len(snap.Signers)/2 + 1); number < limit || seen > number-limit {
+			if limit := snap.recentLimit(); number < limit || seen > number-limit {
 				log.Info("Signed recently, must wait for others")
 				<-stop
 				return nil, nil
 			}
 		}
 	}
+	if c.config.IsLiveness(header.Number) && snap.jailed(signer) && !bytes.Equal(header.Nonce[:], nonceUnjailVote) {
+		log.Info("Signer jailed, must wait for an unjail slot")
+		<-stop
+		return nil, nil
+	}
 
 	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) 
 	if header.Difficulty.Cmp(diffNoTurn) == 0 {
@@ -555,7 +804,7 @@ func (c *Dpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan
 	case <-time.After(delay):
 	}
 
-	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	sighash, err := sealer.SignHeader(signer, header)
 	if err != nil {
 		return nil, err
 	}
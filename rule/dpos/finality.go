@@ -0,0 +1,192 @@
+
+package dpos
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/black/sha3"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+const defaultFinalityInterval = 8
+
+var (
+	errNotFinalityCheckpoint = errors.New("block is not a finality checkpoint")
+
+	errAlreadyVoted = errors.New("already voted at or above this height")
+
+	errKnownVote = errors.New("finality vote already known")
+
+	errStaleVote = errors.New("finality vote below finalized block")
+)
+
+type FinalityVote struct {
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`
+	Signature []byte      `json:"signature"`
+}
+
+func (v *FinalityVote) sigHash() (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, []interface{}{"dpos-finality", v.Number, v.Hash})
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+func (v *FinalityVote) ID() (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+	rlp.Encode(hasher, v)
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+type finalityTally struct {
+	lock sync.Mutex
+
+	votes     map[common.Hash]map[common.Address]struct{}
+	finalized uint64
+	voted     uint64
+}
+
+// newFinalityTally restores the height of the last checkpoint voted on, so a
+// restarted signer never attests to a second block at the same height.
+func newFinalityTally(db ddmdb.Database) *finalityTally {
+	tally := &finalityTally{votes: make(map[common.Hash]map[common.Address]struct{})}
+	if db != nil {
+		tally.voted = readFinalityVote(db)
+	}
+	return tally
+}
+
+func (c *Dpos) finalityCheckpoint(header *types.Header) bool {
+	number := header.Number.Uint64()
+	return number > 0 && c.config.IsFinality(header.Number) && number%c.config.FinalityInterval == 0
+}
+
+// SignFinalityVote attests to the given header if it is a finality checkpoint
+// and the local signer is a member of the validator set at that height. The
+// engine never signs two checkpoints at the same or a lower height.
+func (c *Dpos) SignFinalityVote(chain consensus.ChainReader, header *types.Header) (*FinalityVote, error) {
+	if !c.finalityCheckpoint(header) {
+		return nil, errNotFinalityCheckpoint
+	}
+	c.lock.RLock()
+	signer, sealer := c.signer, c.sealer
+	c.lock.RUnlock()
+
+	if sealer == nil {
+		return nil, errUnauthorized
+	}
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return nil, errUnauthorized
+	}
+
+	c.finality.lock.Lock()
+	defer c.finality.lock.Unlock()
+
+	if header.Number.Uint64() <= c.finality.voted {
+		return nil, errAlreadyVoted
+	}
+	vote := &FinalityVote{Number: header.Number.Uint64(), Hash: header.Hash()}
+	sig, err := sealer.SignVote(signer, vote)
+	if err != nil {
+		return nil, err
+	}
+	vote.Signature = sig
+	if c.db != nil {
+		if err := writeFinalityVote(c.db, vote.Number); err != nil {
+			return nil, err
+		}
+	}
+	c.finality.voted = vote.Number
+
+	return vote, nil
+}
+
+func (c *Dpos) verifyFinalityVote(chain consensus.ChainReader, vote *FinalityVote) (common.Address, *Snapshot, error) {
+	header := chain.GetHeader(vote.Hash, vote.Number)
+	if header == nil {
+		return common.Address{}, nil, errUnknownBlock
+	}
+	if !c.finalityCheckpoint(header) {
+		return common.Address{}, nil, errNotFinalityCheckpoint
+	}
+	pubkey, err := crypto.Ecrecover(vote.sigHash().Bytes(), vote.Signature)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return common.Address{}, nil, errUnauthorized
+	}
+	return signer, snap, nil
+}
+
+// AddFinalityVote verifies and tallies a checkpoint vote. It reports true
+// exactly once per checkpoint, when more than two thirds of the validator set
+// have attested to it.
+func (c *Dpos) AddFinalityVote(chain consensus.ChainReader, vote *FinalityVote) (bool, error) {
+	c.finality.lock.Lock()
+	finalized := c.finality.finalized
+	c.finality.lock.Unlock()
+
+	if vote.Number <= finalized {
+		return false, errStaleVote
+	}
+	signer, snap, err := c.verifyFinalityVote(chain, vote)
+	if err != nil {
+		return false, err
+	}
+
+	c.finality.lock.Lock()
+	defer c.finality.lock.Unlock()
+
+	if vote.Number <= c.finality.finalized {
+		return false, errStaleVote
+	}
+	votes := c.finality.votes[vote.Hash]
+	if votes == nil {
+		votes = make(map[common.Address]struct{})
+		c.finality.votes[vote.Hash] = votes
+	}
+	if _, ok := votes[signer]; ok {
+		return false, errKnownVote
+	}
+	votes[signer] = struct{}{}
+
+	if 3*len(votes) <= 2*len(snap.Signers) {
+		return false, nil
+	}
+	c.finality.finalized = vote.Number
+	for hash := range c.finality.votes {
+		if header := chain.GetHeaderByHash(hash); header == nil || header.Number.Uint64() <= vote.Number {
+			delete(c.finality.votes, hash)
+		}
+	}
+	return true, nil
+}
+
+func (c *Dpos) SetFinalized(number uint64) {
+	c.finality.lock.Lock()
+	defer c.finality.lock.Unlock()
+
+	if number > c.finality.finalized {
+		c.finality.finalized = number
+	}
+}
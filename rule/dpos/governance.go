@@ -0,0 +1,257 @@
+
+package dpos
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/part"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+const (
+	ParamPeriod uint8 = iota + 1
+	ParamGasLimit
+	ParamBlockReward
+	ParamTreasuryShare
+	ParamDelegatorShare
+)
+
+var paramNames = map[uint8]string{
+	ParamPeriod:         "period",
+	ParamGasLimit:       "gasLimit",
+	ParamBlockReward:    "blockReward",
+	ParamTreasuryShare:  "treasuryShare",
+	ParamDelegatorShare: "delegatorShare",
+}
+
+var nonceParamVote = hexutil.MustDecode("0x7070707070707070")
+
+// A parameter vote is carried in the vanity of a header with the param nonce:
+// the parameter key, the activation block and the big endian value.
+const (
+	paramActivationOffset = 1
+	paramValueOffset      = 9
+	paramValueLength      = 23
+)
+
+var (
+	errInvalidParamVote = errors.New("invalid parameter vote")
+
+	errInvalidGasLimit = errors.New("gas limit does not follow governed target")
+)
+
+// ParamChange is a governance proposal to set a chain parameter to a value from
+// the first epoch boundary at or after the activation block.
+type ParamChange struct {
+	Key        uint8    `json:"key"`
+	Value      *big.Int `json:"value"`
+	Activation uint64   `json:"activation"`
+}
+
+func paramKey(name string) (uint8, bool) {
+	for key, known := range paramNames {
+		if known == name {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+// id derives the pseudo address that the proposal is tallied under.
+func (p *ParamChange) id() common.Address {
+	blob, _ := rlp.EncodeToBytes(p)
+	return common.BytesToAddress(crypto.Keccak256(blob)[12:])
+}
+
+func (p *ParamChange) equal(q *ParamChange) bool {
+	return p.Key == q.Key && p.Value.Cmp(q.Value) == 0 && p.Activation == q.Activation
+}
+
+func (p *ParamChange) validate() error {
+	if _, ok := paramNames[p.Key]; !ok || p.Value == nil || p.Value.Sign() < 0 {
+		return errInvalidParamVote
+	}
+	if p.Value.BitLen() > paramValueLength*8 {
+		return errInvalidParamVote
+	}
+	switch p.Key {
+	case ParamPeriod:
+		if !p.Value.IsUint64() {
+			return errInvalidParamVote
+		}
+	case ParamGasLimit:
+		if !p.Value.IsUint64() || p.Value.Uint64() < params.MinGasLimit {
+			return errInvalidParamVote
+		}
+	case ParamTreasuryShare, ParamDelegatorShare:
+		if p.Value.Cmp(big100) > 0 {
+			return errInvalidParamVote
+		}
+	}
+	return nil
+}
+
+func encodeParamVote(vanity []byte, change *ParamChange) {
+	for i := range vanity[:extraVanity] {
+		vanity[i] = 0
+	}
+	vanity[0] = change.Key
+	binary.BigEndian.PutUint64(vanity[paramActivationOffset:], change.Activation)
+	value := change.Value.Bytes()
+	copy(vanity[extraVanity-len(value):extraVanity], value)
+}
+
+func parseParamVote(header *types.Header) (*ParamChange, error) {
+	if len(header.Extra) < extraVanity {
+		return nil, errMissingVanity
+	}
+	change := &ParamChange{
+		Key:        header.Extra[0],
+		Activation: binary.BigEndian.Uint64(header.Extra[paramActivationOffset:]),
+		Value:      new(big.Int).SetBytes(header.Extra[paramValueOffset:extraVanity]),
+	}
+	if err := change.validate(); err != nil {
+		return nil, err
+	}
+	if change.Activation <= header.Number.Uint64() {
+		return nil, errInvalidParamVote
+	}
+	return change, nil
+}
+
+// validParam reports whether voting for the change at the given block would
+// have any effect.
+func (s *Snapshot) validParam(change *ParamChange, number uint64) bool {
+	if change.Activation <= number {
+		return false
+	}
+	for _, pending := range s.Pending {
+		if pending.equal(change) {
+			return false
+		}
+	}
+	return true
+}
+
+// castParam tallies a parameter vote, replacing the signer's previous vote on
+// the same key. A change gaining a majority is queued until its activation.
+func (s *Snapshot) castParam(signer common.Address, number uint64, change *ParamChange) {
+	for i, vote := range s.Votes {
+		if tally := s.Tally[vote.Address]; vote.Signer == signer && tally.Param != nil && tally.Param.Key == change.Key {
+			s.uncast(vote.Address, vote.Authorize)
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			break
+		}
+	}
+	id := change.id()
+	if tally, ok := s.Tally[id]; ok {
+		tally.Votes++
+		s.Tally[id] = tally
+	} else {
+		s.Tally[id] = Tally{Authorize: true, Votes: 1, Param: change}
+	}
+	s.Votes = append(s.Votes, &Vote{
+		Signer:    signer,
+		Block:     number,
+		Address:   id,
+		Authorize: true,
+	})
+	if s.Tally[id].Votes <= len(s.Signers)/2 {
+		return
+	}
+	for i := 0; i < len(s.Pending); i++ {
+		if s.Pending[i].Key == change.Key {
+			s.Pending = append(s.Pending[:i], s.Pending[i+1:]...)
+			i--
+		}
+	}
+	s.Pending = append(s.Pending, change)
+
+	for i := 0; i < len(s.Votes); i++ {
+		if s.Votes[i].Address == id {
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			i--
+		}
+	}
+	delete(s.Tally, id)
+}
+
+// activateParams makes every queued change whose activation block has been
+// reached effective. It is called on epoch boundaries only.
+func (s *Snapshot) activateParams(number uint64) {
+	for i := 0; i < len(s.Pending); i++ {
+		if change := s.Pending[i]; change.Activation <= number {
+			s.Params[change.Key] = change.Value
+			s.Pending = append(s.Pending[:i], s.Pending[i+1:]...)
+			i--
+		}
+	}
+}
+
+// governed returns the engine config with the effective governance parameters
+// applied.
+func (s *Snapshot) governed() *params.DPosConfig {
+	if len(s.Params) == 0 {
+		return s.config
+	}
+	conf := *s.config
+	if value, ok := s.Params[ParamPeriod]; ok {
+		conf.Period = value.Uint64()
+	}
+	if value, ok := s.Params[ParamBlockReward]; ok {
+		conf.BlockReward = value
+	}
+	if value, ok := s.Params[ParamTreasuryShare]; ok {
+		conf.TreasuryShare = value.Uint64()
+	}
+	if value, ok := s.Params[ParamDelegatorShare]; ok {
+		conf.DelegatorShare = value.Uint64()
+	}
+	return &conf
+}
+
+func (s *Snapshot) gasTarget() (uint64, bool) {
+	value, ok := s.Params[ParamGasLimit]
+	if !ok {
+		return 0, false
+	}
+	return value.Uint64(), true
+}
+
+// calcGasLimit moves the parent gas limit towards the governed target by at
+// most the protocol bound per block.
+func calcGasLimit(parent *types.Header, target uint64) uint64 {
+	delta := parent.GasLimit/params.GasLimitBoundDivisor - 1
+
+	limit := parent.GasLimit
+	switch {
+	case limit+delta < target:
+		limit += delta
+	case limit > target+delta:
+		limit -= delta
+	default:
+		limit = target
+	}
+	if limit < params.MinGasLimit {
+		limit = params.MinGasLimit
+	}
+	return limit
+}
+
+func (s *Snapshot) storedParams() []storedParam {
+	stored := make([]storedParam, 0, len(s.Params))
+	for key, value := range s.Params {
+		stored = append(stored, storedParam{key, value})
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].Key < stored[j].Key
+	})
+	return stored
+}
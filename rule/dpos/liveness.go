@@ -0,0 +1,141 @@
+
+package dpos
+
+import (
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/major/types"
+)
+
+const defaultJailThreshold = 64
+
+var nonceUnjailVote = hexutil.MustDecode("0x4a4a4a4a4a4a4a4a")
+
+var (
+	errJailed = errors.New("signer jailed")
+
+	errInvalidUnjail = errors.New("unjail vote from non-jailed signer")
+)
+
+// Liveness counts the blocks a signer sealed and the in-turn slots it missed
+// in the current epoch. Jailed signers are skipped by the in-turn rotation
+// until they seal an unjail block of their own.
+type Liveness struct {
+	Signed uint64 `json:"signed"`
+	Missed uint64 `json:"missed"`
+	Jailed bool   `json:"jailed"`
+}
+
+type SignerStatus struct {
+	Signed uint64  `json:"signed"`
+	Missed uint64  `json:"missed"`
+	Uptime float64 `json:"uptime"`
+	Jailed bool    `json:"jailed"`
+}
+
+func confirmTarget(parent uint64) (confirmNum, confirmRand uint64) {
+	if parent > 8 {
+		return parent - 8, 1
+	}
+	return 0, parent
+}
+
+func ancestorHash(headers []*types.Header, number uint64, getHeader func(common.Hash, uint64) *types.Header) (common.Hash, error) {
+	first := headers[0].Number.Uint64()
+	if number >= first {
+		return headers[number-first].Hash(), nil
+	}
+	hash, n := headers[0].ParentHash, first-1
+	for n > number {
+		header := getHeader(hash, n)
+		if header == nil {
+			return common.Hash{}, errUnknownBlock
+		}
+		hash, n = header.ParentHash, n-1
+	}
+	return hash, nil
+}
+
+func (s *Snapshot) jailed(signer common.Address) bool {
+	return s.Liveness[signer].Jailed
+}
+
+func (s *Snapshot) active() int {
+	active := 0
+	for signer := range s.Signers {
+		if !s.jailed(signer) {
+			active++
+		}
+	}
+	return active
+}
+
+func (s *Snapshot) recentLimit() uint64 {
+	return uint64(s.active()/2 + 1)
+}
+
+func (s *Snapshot) pruneRecents(number uint64) {
+	limit := s.recentLimit()
+	for block := range s.Recents {
+		if block+limit <= number {
+			delete(s.Recents, block)
+		}
+	}
+}
+
+func (s *Snapshot) track(signer, expected common.Address, number uint64) {
+	liveness := s.Liveness[signer]
+	liveness.Signed++
+	s.Liveness[signer] = liveness
+
+	if _, ok := s.Signers[expected]; !ok || expected == signer {
+		return
+	}
+	liveness = s.Liveness[expected]
+	liveness.Missed++
+	if !liveness.Jailed && liveness.Missed >= s.config.JailThreshold && s.active() > 1 {
+		liveness.Jailed = true
+		s.Liveness[expected] = liveness
+		s.pruneRecents(number)
+		return
+	}
+	s.Liveness[expected] = liveness
+}
+
+func (s *Snapshot) unjail(signer common.Address) {
+	if liveness, ok := s.Liveness[signer]; ok {
+		liveness.Jailed = false
+		liveness.Missed = 0
+		s.Liveness[signer] = liveness
+	}
+}
+
+func (s *Snapshot) resetLiveness() {
+	for signer, liveness := range s.Liveness {
+		if _, ok := s.Signers[signer]; !ok || !liveness.Jailed {
+			delete(s.Liveness, signer)
+			continue
+		}
+		s.Liveness[signer] = Liveness{Jailed: true}
+	}
+}
+
+func (s *Snapshot) status() map[common.Address]*SignerStatus {
+	status := make(map[common.Address]*SignerStatus)
+	for signer := range s.Signers {
+		liveness := s.Liveness[signer]
+		uptime := 1.0
+		if total := liveness.Signed + liveness.Missed; total > 0 {
+			uptime = float64(liveness.Signed) / float64(total)
+		}
+		status[signer] = &SignerStatus{
+			Signed: liveness.Signed,
+			Missed: liveness.Missed,
+			Uptime: uptime,
+			Jailed: liveness.Jailed,
+		}
+	}
+	return status
+}
@@ -0,0 +1,79 @@
+
+package dpos
+
+import (
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+var big100 = big.NewInt(100)
+
+func blockFees(txs []*types.Transaction, receipts []*types.Receipt, baseFee *big.Int) *big.Int {
+	fees := new(big.Int)
+	for i, tx := range txs {
+		if i >= len(receipts) {
+			break
+		}
+		price := tx.GasPrice()
+		if baseFee != nil {
+			if price = tx.EffectiveGasTipValue(baseFee); price.Sign() < 0 {
+				continue
+			}
+		}
+		fees.Add(fees, new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), price))
+	}
+	return fees
+}
+
+// accumulateRewards credits the block reward to the signer and splits its income
+// between the treasury and its delegators. The income is the reward plus the
+// fees already credited to the signer, only the tips once the base fee is
+// burned. Rounding dust stays with the signer.
+func accumulateRewards(config *params.DPosConfig, state *state.StateDB, header *types.Header, signer common.Address, txs []*types.Transaction, receipts []*types.Receipt) {
+	income := blockFees(txs, receipts, header.BaseFee)
+	if config.BlockReward != nil {
+		state.AddBalance(signer, config.BlockReward)
+		income.Add(income, config.BlockReward)
+	}
+	if income.Sign() == 0 {
+		return
+	}
+	remaining := new(big.Int).Set(income)
+
+	if config.TreasuryShare > 0 && config.Treasury != (common.Address{}) {
+		cut := new(big.Int).Mul(income, new(big.Int).SetUint64(config.TreasuryShare))
+		cut.Div(cut, big100)
+		if cut.Cmp(remaining) > 0 {
+			cut.Set(remaining)
+		}
+		state.SubBalance(signer, cut)
+		state.AddBalance(config.Treasury, cut)
+		remaining.Sub(remaining, cut)
+	}
+	if config.DelegatorShare > 0 {
+		ledger := newLedger(state)
+
+		stake := ledger.stake(signer)
+		if stake.Sign() == 0 {
+			return
+		}
+		pool := new(big.Int).Mul(income, new(big.Int).SetUint64(config.DelegatorShare))
+		pool.Div(pool, big100)
+		if pool.Cmp(remaining) > 0 {
+			pool.Set(remaining)
+		}
+		for _, delegator := range ledger.delegators(signer) {
+			cut := new(big.Int).Mul(pool, ledger.delegation(delegator, signer))
+			cut.Div(cut, stake)
+			if cut.Sign() == 0 {
+				continue
+			}
+			state.SubBalance(signer, cut)
+			state.AddBalance(delegator, cut)
+		}
+	}
+}
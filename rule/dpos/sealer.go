@@ -0,0 +1,238 @@
+
+package dpos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/sign"
+	"github.com/ddmchain/go-ddmchain/control"
+)
+
+const DefaultSealerTimeout = 5 * time.Second
+
+var (
+	errDoubleSign = errors.New("request below or conflicting with signing high-water mark")
+
+	errInvalidSealerSignature = errors.New("external signer returned an invalid signature")
+)
+
+// HeaderRequest is the single parameter of the sealer_signHeader call made to
+// an external signer. It carries every header field covered by the seal along
+// with the resulting seal hash, so the signer can recompute what it signs. The
+// extra-data excludes the 65 byte seal and the base fee is only present after
+// the London fork. The call must return the 65 byte [R || S || V] secp256k1
+// signature of SealHash, hex encoded.
+type HeaderRequest struct {
+	Address     common.Address   `json:"address"`
+	SealHash    common.Hash      `json:"sealHash"`
+	ParentHash  common.Hash      `json:"parentHash"`
+	UncleHash   common.Hash      `json:"sha3Uncles"`
+	Coinbase    common.Address   `json:"miner"`
+	Root        common.Hash      `json:"stateRoot"`
+	TxHash      common.Hash      `json:"transactionsRoot"`
+	ReceiptHash common.Hash      `json:"receiptsRoot"`
+	Bloom       types.Bloom      `json:"logsBloom"`
+	Difficulty  *hexutil.Big     `json:"difficulty"`
+	Number      *hexutil.Big     `json:"number"`
+	GasLimit    hexutil.Uint64   `json:"gasLimit"`
+	GasUsed     hexutil.Uint64   `json:"gasUsed"`
+	Time        *hexutil.Big     `json:"timestamp"`
+	Extra       hexutil.Bytes    `json:"extraData"`
+	MixDigest   common.Hash      `json:"mixHash"`
+	Nonce       types.BlockNonce `json:"nonce"`
+	BaseFee     *hexutil.Big     `json:"baseFeePerGas,omitempty"`
+}
+
+// VoteRequest is the single parameter of the sealer_signVote call, made for
+// finality votes. The call returns the signature of SealHash, the keccak256 of
+// rlp("dpos-finality", Number, Hash).
+type VoteRequest struct {
+	Address  common.Address `json:"address"`
+	SealHash common.Hash    `json:"sealHash"`
+	Number   hexutil.Uint64 `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+}
+
+func newHeaderRequest(signer common.Address, header *types.Header) *HeaderRequest {
+	return &HeaderRequest{
+		Address:     signer,
+		SealHash:    sigHash(header),
+		ParentHash:  header.ParentHash,
+		UncleHash:   header.UncleHash,
+		Coinbase:    header.Coinbase,
+		Root:        header.Root,
+		TxHash:      header.TxHash,
+		ReceiptHash: header.ReceiptHash,
+		Bloom:       header.Bloom,
+		Difficulty:  (*hexutil.Big)(header.Difficulty),
+		Number:      (*hexutil.Big)(header.Number),
+		GasLimit:    hexutil.Uint64(header.GasLimit),
+		GasUsed:     hexutil.Uint64(header.GasUsed),
+		Time:        (*hexutil.Big)(header.Time),
+		Extra:       header.Extra[:len(header.Extra)-extraSeal],
+		MixDigest:   header.MixDigest,
+		Nonce:       header.Nonce,
+		BaseFee:     (*hexutil.Big)(header.BaseFee),
+	}
+}
+
+type watermark struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// highWaterMark records the last header and finality vote handed out for
+// signing, persisted before every request so that a restart cannot be used to
+// sign a conflicting header at the same height.
+type highWaterMark struct {
+	path string
+
+	Header watermark `json:"header"`
+	Vote   watermark `json:"vote"`
+}
+
+func loadHighWaterMark(path string) (*highWaterMark, error) {
+	hwm := &highWaterMark{path: path}
+
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return hwm, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, hwm); err != nil {
+		return nil, fmt.Errorf("corrupt high-water mark %s: %v", path, err)
+	}
+	return hwm, nil
+}
+
+func (hwm *highWaterMark) advance(mark *watermark, number uint64, hash common.Hash) error {
+	if number < mark.Number || (number == mark.Number && hash != mark.Hash) {
+		return errDoubleSign
+	}
+	if number == mark.Number {
+		return nil
+	}
+	prev := *mark
+	mark.Number, mark.Hash = number, hash
+
+	if err := hwm.flush(); err != nil {
+		*mark = prev
+		return err
+	}
+	return nil
+}
+
+func (hwm *highWaterMark) flush() error {
+	blob, err := json.MarshalIndent(hwm, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := hwm.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, hwm.path)
+}
+
+// RemoteSealer forwards signing requests to an external signer over IPC or
+// HTTP, keeping the sealing key off the node.
+type RemoteSealer struct {
+	endpoint string
+	timeout  time.Duration
+
+	client *rpc.Client
+	hwm    *highWaterMark
+	lock   sync.Mutex
+}
+
+// NewRemoteSealer creates a sealer for the signer at endpoint. The connection
+// is established lazily. The high-water mark is kept in the file at hwmPath.
+func NewRemoteSealer(endpoint string, timeout time.Duration, hwmPath string) (*RemoteSealer, error) {
+	if timeout <= 0 {
+		timeout = DefaultSealerTimeout
+	}
+	hwm, err := loadHighWaterMark(hwmPath)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteSealer{endpoint: endpoint, timeout: timeout, hwm: hwm}, nil
+}
+
+func (s *RemoteSealer) SignHeader(signer common.Address, header *types.Header) ([]byte, error) {
+	req := newHeaderRequest(signer, header)
+	return s.sign(signer, &s.hwm.Header, header.Number.Uint64(), req.SealHash, "sealer_signHeader", req)
+}
+
+func (s *RemoteSealer) SignVote(signer common.Address, vote *FinalityVote) ([]byte, error) {
+	req := &VoteRequest{
+		Address:  signer,
+		SealHash: vote.sigHash(),
+		Number:   hexutil.Uint64(vote.Number),
+		Hash:     vote.Hash,
+	}
+	return s.sign(signer, &s.hwm.Vote, vote.Number, req.SealHash, "sealer_signVote", req)
+}
+
+func (s *RemoteSealer) sign(signer common.Address, mark *watermark, number uint64, hash common.Hash, method string, req interface{}) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.hwm.advance(mark, number, hash); err != nil {
+		log.Warn("Refused to sign conflicting request", "method", method, "number", number, "hash", hash, "mark", mark.Number)
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	if s.client == nil {
+		client, err := rpc.DialContext(ctx, s.endpoint)
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+	var sig hexutil.Bytes
+	if err := s.client.CallContext(ctx, &sig, method, req); err != nil {
+		if ctx.Err() != nil {
+			s.client.Close()
+			s.client = nil
+		}
+		return nil, err
+	}
+	if len(sig) != extraSeal {
+		return nil, errInvalidSealerSignature
+	}
+	pubkey, err := crypto.Ecrecover(hash.Bytes(), sig)
+	if err != nil {
+		return nil, err
+	}
+	var recovered common.Address
+	copy(recovered[:], crypto.Keccak256(pubkey[1:])[12:])
+	if recovered != signer {
+		return nil, errInvalidSealerSignature
+	}
+	return sig, nil
+}
+
+func (s *RemoteSealer) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+}
@@ -0,0 +1,109 @@
+
+package dpos
+
+import (
+	"errors"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/hexutil"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/ptl"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// SlashingAddress receives double-signing evidence. The transaction payload is
+// the RLP encoding of an Evidence; any value sent along is burned.
+var SlashingAddress = common.HexToAddress("0x000000000000000000000000000000000000dd06")
+
+var (
+	nonceSlashVote = hexutil.MustDecode("0x5151515151515151")
+
+	slashedPrefix = []byte("slashed")
+)
+
+var (
+	errInvalidEvidence = errors.New("invalid double-sign evidence")
+
+	errInvalidSlash = errors.New("slash vote without matching evidence")
+
+	errMissingSlash = errors.New("valid evidence without slash vote")
+)
+
+type Evidence struct {
+	First  *types.Header `json:"first"`
+	Second *types.Header `json:"second"`
+}
+
+func (e *Evidence) offender(sigcache *lru.ARCCache) (common.Address, error) {
+	if e.First == nil || e.Second == nil || e.First.Number == nil || e.Second.Number == nil {
+		return common.Address{}, errInvalidEvidence
+	}
+	if e.First.Number.Cmp(e.Second.Number) != 0 || e.First.Number.Sign() == 0 {
+		return common.Address{}, errInvalidEvidence
+	}
+	if len(e.First.Extra) < extraSeal || len(e.Second.Extra) < extraSeal || sigHash(e.First) == sigHash(e.Second) {
+		return common.Address{}, errInvalidEvidence
+	}
+	first, err := ecrecover(e.First, sigcache)
+	if err != nil {
+		return common.Address{}, err
+	}
+	second, err := ecrecover(e.Second, sigcache)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if first != second {
+		return common.Address{}, errInvalidEvidence
+	}
+	return first, nil
+}
+
+func EncodeEvidence(first, second *types.Header) ([]byte, error) {
+	return rlp.EncodeToBytes(&Evidence{First: first, Second: second})
+}
+
+func (l *ledger) slashed(candidate common.Address) bool {
+	return l.get(crypto.Keccak256Hash(slashedPrefix, candidate[:])).Sign() != 0
+}
+
+func (l *ledger) slash(candidate common.Address, burn bool) {
+	l.set(crypto.Keccak256Hash(slashedPrefix, candidate[:]), common.Big1)
+	if !burn {
+		return
+	}
+	for _, delegator := range l.delegators(candidate) {
+		l.set(crypto.Keccak256Hash(delegationPrefix, delegator[:], candidate[:]), common.Big0)
+		l.listRemove(delegatorList(candidate), delegator)
+	}
+	if stake := l.stake(candidate); stake.Sign() > 0 {
+		l.state.SubBalance(StakingAddress, stake)
+		l.set(crypto.Keccak256Hash(stakePrefix, candidate[:]), common.Big0)
+	}
+	l.listRemove(candidatesPrefix, candidate)
+}
+
+// findEvidence returns the offender proven by the first valid, not yet acted
+// upon evidence transaction in the block.
+func findEvidence(state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt, sigcache *lru.ARCCache) (common.Address, bool) {
+	ledger := newLedger(state)
+	for i, tx := range txs {
+		if to := tx.To(); to == nil || *to != SlashingAddress {
+			continue
+		}
+		if i >= len(receipts) || receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		evidence := new(Evidence)
+		if err := rlp.DecodeBytes(tx.Data(), evidence); err != nil {
+			continue
+		}
+		offender, err := evidence.offender(sigcache)
+		if err != nil || ledger.slashed(offender) {
+			continue
+		}
+		return offender, true
+	}
+	return common.Address{}, false
+}
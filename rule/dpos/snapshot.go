@@ -3,12 +3,13 @@ package dpos
 
 import (
 	"bytes"
-	"encoding/json"
 	"encoding/binary"
+	"math/big"
 	"math/rand"
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
 	"github.com/ddmchain/go-ddmchain/ddmpv"
 	"github.com/ddmchain/go-ddmchain/part"
 	lru "github.com/hashicorp/golang-lru"
@@ -24,10 +25,12 @@ type Vote struct {
 type Tally struct {
 	Authorize bool `json:"authorize"` 
 	Votes     int  `json:"votes"`     
+
+	Param *ParamChange `json:"param,omitempty"`
 }
 
 type Snapshot struct {
-	config   *params.DposConfig 
+	config   *params.DPosConfig 
 	sigcache *lru.ARCCache        
 
 	Number  uint64                      `json:"number"`  
@@ -36,9 +39,14 @@ type Snapshot struct {
 	Recents map[uint64]common.Address   `json:"recents"` 
 	Votes   []*Vote                     `json:"votes"`   
 	Tally   map[common.Address]Tally    `json:"tally"`   
+
+	Liveness map[common.Address]Liveness `json:"liveness,omitempty"`
+
+	Params  map[uint8]*big.Int `json:"params,omitempty"`
+	Pending []*ParamChange     `json:"pending,omitempty"`
 }
 
-func newSnapshot(config *params.DposConfig, sigcache *lru.ARCCache, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
+func newSnapshot(config *params.DPosConfig, sigcache *lru.ARCCache, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
 	snap := &Snapshot{
 		config:   config,
 		sigcache: sigcache,
@@ -47,6 +55,8 @@ func newSnapshot(config *params.DposConfig, sigcache *lru.ARCCache, number uint6
 		Signers:  make(map[common.Address]struct{}),
 		Recents:  make(map[uint64]common.Address),
 		Tally:    make(map[common.Address]Tally),
+		Liveness: make(map[common.Address]Liveness),
+		Params:   make(map[uint8]*big.Int),
 	}
 	for _, signer := range signers {
 		snap.Signers[signer] = struct{}{}
@@ -54,27 +64,36 @@ func newSnapshot(config *params.DposConfig, sigcache *lru.ARCCache, number uint6
 	return snap
 }
 
-func loadSnapshot(config *params.DposConfig, sigcache *lru.ARCCache, db ddmdb.Database, hash common.Hash) (*Snapshot, error) {
-	blob, err := db.Get(append([]byte("dpos-"), hash[:]...))
+func loadSnapshot(config *params.DPosConfig, sigcache *lru.ARCCache, db ddmdb.Database, hash common.Hash) (*Snapshot, error) {
+	blob, err := db.Get(snapshotKey(hash))
 	if err != nil {
 		return nil, err
 	}
-	snap := new(Snapshot)
-	if err := json.Unmarshal(blob, snap); err != nil {
+	snap, err := decodeSnapshot(blob)
+	if err != nil {
 		return nil, err
 	}
 	snap.config = config
 	snap.sigcache = sigcache
+	if snap.Liveness == nil {
+		snap.Liveness = make(map[common.Address]Liveness)
+	}
+	if snap.Params == nil {
+		snap.Params = make(map[uint8]*big.Int)
+	}
 
 	return snap, nil
 }
 
 func (s *Snapshot) store(db ddmdb.Database) error {
-	blob, err := json.Marshal(s)
+	blob, err := s.encode()
 	if err != nil {
 		return err
 	}
-	return db.Put(append([]byte("dpos-"), s.Hash[:]...), blob)
+	if err := db.Put(snapshotKey(s.Hash), blob); err != nil {
+		return err
+	}
+	return indexSnapshot(db, s.Number, s.Hash)
 }
 
 func (s *Snapshot) copy() *Snapshot {
@@ -87,6 +106,9 @@ func (s *Snapshot) copy() *Snapshot {
 		Recents:  make(map[uint64]common.Address),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+		Liveness: make(map[common.Address]Liveness),
+		Params:   make(map[uint8]*big.Int),
+		Pending:  make([]*ParamChange, len(s.Pending)),
 	}
 	for signer := range s.Signers {
 		cpy.Signers[signer] = struct{}{}
@@ -97,7 +119,14 @@ func (s *Snapshot) copy() *Snapshot {
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
+	for signer, liveness := range s.Liveness {
+		cpy.Liveness[signer] = liveness
+	}
+	for key, value := range s.Params {
+		cpy.Params[key] = value
+	}
 	copy(cpy.Votes, s.Votes)
+	copy(cpy.Pending, s.Pending)
 
 	return cpy
 }
@@ -142,7 +171,7 @@ func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
 	return true
 }
 
-func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
+func (s *Snapshot) apply(headers []*types.Header, getHeader func(common.Hash, uint64) *types.Header) (*Snapshot, error) {
 
 	if len(headers) == 0 {
 		return s, nil
@@ -162,12 +191,25 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	for _, header := range headers {
 
 		number := header.Number.Uint64()
+		live := s.config.IsLiveness(header.Number)
+
+		var expected common.Address
+		if live && header.Difficulty.Cmp(diffNoTurn) == 0 {
+			confirmNum, confirmRand := confirmTarget(number - 1)
+			hash, err := ancestorHash(headers, confirmNum, getHeader)
+			if err != nil {
+				return nil, err
+			}
+			expected, _ = snap.inturnSigner(number, hash, confirmRand)
+		}
 		if number%s.config.Epoch == 0 {
+			snap.activateParams(number)
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+			snap.resetLiveness()
 		}
 
-		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+		if limit := snap.recentLimit(); number >= limit {
 			delete(snap.Recents, number-limit)
 		}
 
@@ -185,6 +227,32 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		}
 		snap.Recents[number] = signer
 
+		if live {
+			snap.track(signer, expected, number)
+			if bytes.Equal(header.Nonce[:], nonceUnjailVote) {
+				snap.unjail(signer)
+				continue
+			}
+		}
+		if s.config.IsGovernance(header.Number) && bytes.Equal(header.Nonce[:], nonceParamVote) {
+			change, err := parseParamVote(header)
+			if err != nil {
+				return nil, err
+			}
+			snap.castParam(signer, number, change)
+			continue
+		}
+		if s.config.IsSlash(header.Number) && bytes.Equal(header.Nonce[:], nonceSlashVote) {
+			snap.remove(header.Coinbase, number)
+			continue
+		}
+		if s.config.IsStake(header.Number) {
+			if number%s.config.Epoch == 0 {
+				snap.elect(checkpointSigners(header), number)
+			}
+			continue
+		}
+
 		for i, vote := range snap.Votes {
 			if vote.Signer == signer && vote.Address == header.Coinbase {
 
@@ -218,8 +286,9 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				snap.Signers[header.Coinbase] = struct{}{}
 			} else {
 				delete(snap.Signers, header.Coinbase)
+				delete(snap.Liveness, header.Coinbase)
 
-				if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+				if limit := snap.recentLimit(); number >= limit {
 					delete(snap.Recents, number-limit)
 				}
 
@@ -250,6 +319,41 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+func (s *Snapshot) remove(signer common.Address, number uint64) {
+	if _, ok := s.Signers[signer]; !ok {
+		return
+	}
+	delete(s.Signers, signer)
+	delete(s.Liveness, signer)
+
+	if limit := s.recentLimit(); number >= limit {
+		delete(s.Recents, number-limit)
+	}
+	for i := 0; i < len(s.Votes); i++ {
+		if s.Votes[i].Signer == signer || s.Votes[i].Address == signer {
+			if s.Votes[i].Signer == signer {
+				s.uncast(s.Votes[i].Address, s.Votes[i].Authorize)
+			}
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			i--
+		}
+	}
+	delete(s.Tally, signer)
+}
+
+func (s *Snapshot) elect(signers []common.Address, number uint64) {
+	s.Signers = make(map[common.Address]struct{})
+	for _, signer := range signers {
+		s.Signers[signer] = struct{}{}
+	}
+	for signer := range s.Liveness {
+		if _, ok := s.Signers[signer]; !ok {
+			delete(s.Liveness, signer)
+		}
+	}
+	s.pruneRecents(number)
+}
+
 func (s *Snapshot) signers() []common.Address {
 	signers := make([]common.Address, 0, len(s.Signers))
 	for signer := range s.Signers {
@@ -270,12 +374,15 @@ func (s *Snapshot) ableSigners(number uint64) []common.Address {
 	for block, recent := range s.Recents {
 		recents[block] = recent
 	}
-	if limit := uint64(len(s.Signers)/2 + 1); number >= limit {
+	if limit := s.recentLimit(); number >= limit {
 		delete(recents, number-limit)
 	}
 
 	signers := make([]common.Address, 0, len(s.Signers))
 	for signer := range s.Signers {
+		if s.jailed(signer) {
+			continue
+		}
 		isRecent := false
 		for _, recent := range recents {
 			if recent == signer {
@@ -298,24 +405,51 @@ func (s *Snapshot) ableSigners(number uint64) []common.Address {
 }
 
 func (s *Snapshot) inturn(number uint64, signer common.Address, hash common.Hash, confirmRand uint64) bool {
+	expected, ok := s.inturnSigner(number, hash, confirmRand)
+	return ok && expected == signer
+}
+
+func (s *Snapshot) inturnSigner(number uint64, hash common.Hash, confirmRand uint64) (common.Address, bool) {
+	signers := s.ableSigners(number)
+	if len(signers) == 0 {
+		return common.Address{}, false
+	}
+	if s.config.IsShuffle(new(big.Int).SetUint64(number)) {
+		return shuffleSigners(signers, hash, number)[0], true
+	}
 	var lowHash int64
 	b_buf := bytes.NewBuffer(hash[len(hash)-8:])
 	binary.Read(b_buf, binary.BigEndian, &lowHash)
 
-	signers, offset := s.ableSigners(number), 0
-	sigLen := len(signers)
-
 	var curTurn int
-	rand.Seed(lowHash)
+	rng := rand.New(rand.NewSource(lowHash))
 	for i := uint64(0); i < confirmRand; i++ {
-		curTurn = rand.Intn(sigLen)
+		curTurn = rng.Intn(len(signers))
 	}
+	return signers[curTurn], true
+}
 
-	for offset < sigLen && signers[offset] != signer {
-		offset++
+// shuffleSigners returns a copy of the sorted signer list permuted by a
+// Fisher-Yates shuffle. Randomness is drawn from a keccak256 hash chain seeded
+// with the confirmation header hash and the block number being sealed:
+//
+//   seed_0 = keccak256(hash ++ uint64_be(number))
+//   seed_k = keccak256(seed_k-1)
+//
+// and for k = 1..n-1 the element at n-k is swapped with the one at
+// seed_k mod (n-k+1). The first element of the result is the in-turn signer.
+func shuffleSigners(signers []common.Address, hash common.Hash, number uint64) []common.Address {
+	shuffled := make([]common.Address, len(signers))
+	copy(shuffled, signers)
+
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
+	seed := crypto.Keccak256(hash[:], enc[:])
+
+	for i := len(shuffled) - 1; i > 0; i-- {
+		seed = crypto.Keccak256(seed)
+		j := new(big.Int).Mod(new(big.Int).SetBytes(seed), big.NewInt(int64(i+1))).Int64()
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	}
-
-	ret := (curTurn == offset)
-
-	return ret
+	return shuffled
 }
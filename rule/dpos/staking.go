@@ -0,0 +1,237 @@
+
+package dpos
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/black"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+// StakingAddress is the system account holding all bonded stake. Transactions
+// sent to it are interpreted by the engine as delegation operations:
+//
+//   0x01 ++ candidate           delegate tx.Value() to candidate
+//   0x02 ++ candidate ++ amount withdraw amount (32 bytes, big endian)
+var StakingAddress = common.HexToAddress("0x000000000000000000000000000000000000dd05")
+
+const (
+	stakeOpDelegate   = 0x01
+	stakeOpUndelegate = 0x02
+)
+
+var (
+	candidatesPrefix = []byte("candidates")
+	delegatorsPrefix = []byte("delegators")
+	stakePrefix      = []byte("stake")
+	delegationPrefix = []byte("delegation")
+)
+
+type ledger struct {
+	state *state.StateDB
+}
+
+func newLedger(state *state.StateDB) *ledger {
+	return &ledger{state: state}
+}
+
+func (l *ledger) get(key common.Hash) *big.Int {
+	return l.state.GetState(StakingAddress, key).Big()
+}
+
+func (l *ledger) set(key common.Hash, value *big.Int) {
+	l.state.SetState(StakingAddress, key, common.BigToHash(value))
+}
+
+func (l *ledger) listLen(list []byte) uint64 {
+	return l.get(crypto.Keccak256Hash(list)).Uint64()
+}
+
+// listSlot keys the elements by their fixed width index, so that the first one
+// doesn't share the slot of the list length.
+func listSlot(list []byte, index uint64) common.Hash {
+	return crypto.Keccak256Hash(list, common.BigToHash(new(big.Int).SetUint64(index)).Bytes())
+}
+
+func (l *ledger) listAt(list []byte, index uint64) common.Address {
+	return common.BytesToAddress(l.state.GetState(StakingAddress, listSlot(list, index)).Bytes())
+}
+
+func (l *ledger) list(list []byte) []common.Address {
+	items := make([]common.Address, l.listLen(list))
+	for i := range items {
+		items[i] = l.listAt(list, uint64(i))
+	}
+	return items
+}
+
+func (l *ledger) listAdd(list []byte, addr common.Address) {
+	pos := crypto.Keccak256Hash(list, addr[:])
+	if l.get(pos).Sign() != 0 {
+		return
+	}
+	size := l.listLen(list)
+	l.state.SetState(StakingAddress, listSlot(list, size), addr.Hash())
+	l.set(pos, new(big.Int).SetUint64(size+1))
+	l.set(crypto.Keccak256Hash(list), new(big.Int).SetUint64(size+1))
+}
+
+func (l *ledger) listRemove(list []byte, addr common.Address) {
+	pos := crypto.Keccak256Hash(list, addr[:])
+	index := l.get(pos).Uint64()
+	if index == 0 {
+		return
+	}
+	index--
+
+	size := l.listLen(list)
+	if last := size - 1; index != last {
+		moved := l.listAt(list, last)
+		l.state.SetState(StakingAddress, listSlot(list, index), moved.Hash())
+		l.set(crypto.Keccak256Hash(list, moved[:]), new(big.Int).SetUint64(index+1))
+	}
+	l.state.SetState(StakingAddress, listSlot(list, size-1), common.Hash{})
+	l.set(pos, common.Big0)
+	l.set(crypto.Keccak256Hash(list), new(big.Int).SetUint64(size-1))
+}
+
+func delegatorList(candidate common.Address) []byte {
+	return append(append([]byte{}, delegatorsPrefix...), candidate[:]...)
+}
+
+func (l *ledger) candidates() []common.Address {
+	return l.list(candidatesPrefix)
+}
+
+func (l *ledger) delegators(candidate common.Address) []common.Address {
+	return l.list(delegatorList(candidate))
+}
+
+func (l *ledger) stake(candidate common.Address) *big.Int {
+	return l.get(crypto.Keccak256Hash(stakePrefix, candidate[:]))
+}
+
+func (l *ledger) delegation(delegator, candidate common.Address) *big.Int {
+	return l.get(crypto.Keccak256Hash(delegationPrefix, delegator[:], candidate[:]))
+}
+
+func (l *ledger) delegate(delegator, candidate common.Address, amount *big.Int) {
+	l.set(crypto.Keccak256Hash(delegationPrefix, delegator[:], candidate[:]), new(big.Int).Add(l.delegation(delegator, candidate), amount))
+	l.set(crypto.Keccak256Hash(stakePrefix, candidate[:]), new(big.Int).Add(l.stake(candidate), amount))
+
+	l.listAdd(candidatesPrefix, candidate)
+	l.listAdd(delegatorList(candidate), delegator)
+}
+
+func (l *ledger) undelegate(delegator, candidate common.Address, amount *big.Int) bool {
+	bonded := l.delegation(delegator, candidate)
+	if amount.Sign() <= 0 || bonded.Cmp(amount) < 0 {
+		return false
+	}
+	bonded = new(big.Int).Sub(bonded, amount)
+	l.set(crypto.Keccak256Hash(delegationPrefix, delegator[:], candidate[:]), bonded)
+	if bonded.Sign() == 0 {
+		l.listRemove(delegatorList(candidate), delegator)
+	}
+	total := new(big.Int).Sub(l.stake(candidate), amount)
+	l.set(crypto.Keccak256Hash(stakePrefix, candidate[:]), total)
+	if total.Sign() == 0 {
+		l.listRemove(candidatesPrefix, candidate)
+	}
+	return true
+}
+
+func (l *ledger) refund(to common.Address, amount *big.Int) {
+	if amount.Sign() > 0 {
+		l.state.SubBalance(StakingAddress, amount)
+		l.state.AddBalance(to, amount)
+	}
+}
+
+func (l *ledger) execute(from common.Address, data []byte, value *big.Int) {
+	if len(data) < 1+common.AddressLength {
+		l.refund(from, value)
+		return
+	}
+	candidate := common.BytesToAddress(data[1 : 1+common.AddressLength])
+
+	switch data[0] {
+	case stakeOpDelegate:
+		if len(data) != 1+common.AddressLength || value.Sign() == 0 {
+			l.refund(from, value)
+			return
+		}
+		l.delegate(from, candidate, value)
+
+	case stakeOpUndelegate:
+		amount := new(big.Int).SetBytes(data[1+common.AddressLength:])
+		if len(data) != 1+common.AddressLength+common.HashLength || !l.undelegate(from, candidate, amount) {
+			l.refund(from, value)
+			return
+		}
+		l.refund(from, new(big.Int).Add(amount, value))
+
+	default:
+		l.refund(from, value)
+	}
+}
+
+func applyStaking(config *params.ChainConfig, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	var (
+		signer = types.MakeSigner(config, header.Number)
+		ledger = newLedger(state)
+	)
+	for i, tx := range txs {
+		if to := tx.To(); to == nil || *to != StakingAddress {
+			continue
+		}
+		if i >= len(receipts) || receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		ledger.execute(from, tx.Data(), tx.Value())
+	}
+	return nil
+}
+
+func electValidators(config *params.DPosConfig, state *state.StateDB) []common.Address {
+	ledger := newLedger(state)
+
+	type candidate struct {
+		address common.Address
+		stake   *big.Int
+	}
+	var candidates []candidate
+	for _, address := range ledger.candidates() {
+		stake := ledger.stake(address)
+		if ledger.slashed(address) || stake.Sign() == 0 || (config.MinStake != nil && stake.Cmp(config.MinStake) < 0) {
+			continue
+		}
+		candidates = append(candidates, candidate{address, stake})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if cmp := candidates[i].stake.Cmp(candidates[j].stake); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(candidates[i].address[:], candidates[j].address[:]) < 0
+	})
+	if uint64(len(candidates)) > config.MaxValidators {
+		candidates = candidates[:config.MaxValidators]
+	}
+	elected := make([]common.Address, len(candidates))
+	for i, candidate := range candidates {
+		elected[i] = candidate.address
+	}
+	sort.Slice(elected, func(i, j int) bool {
+		return bytes.Compare(elected[i][:], elected[j][:]) < 0
+	})
+	return elected
+}
@@ -0,0 +1,85 @@
+
+package misc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/general/math"
+	"github.com/ddmchain/go-ddmchain/major/state"
+	"github.com/ddmchain/go-ddmchain/major/types"
+	"github.com/ddmchain/go-ddmchain/part"
+)
+
+// VerifyEip1559Header checks that the base fee of a header after the London
+// fork follows from its parent.
+func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Header) error {
+	if !config.IsLondon(header.Number) {
+		if header.BaseFee != nil {
+			return fmt.Errorf("invalid baseFee before fork: have %v, want <nil>", header.BaseFee)
+		}
+		return nil
+	}
+	if header.BaseFee == nil {
+		return fmt.Errorf("header is missing baseFee")
+	}
+	if expected := CalcBaseFee(config, parent); header.BaseFee.Cmp(expected) != 0 {
+		return fmt.Errorf("invalid baseFee: have %v, want %v, parentBaseFee %v, parentGasUsed %d",
+			header.BaseFee, expected, parent.BaseFee, parent.GasUsed)
+	}
+	return nil
+}
+
+// CalcBaseFee calculates the base fee of the header following parent. The fee
+// moves by at most 1/8 per block towards keeping usage at half the gas limit.
+func CalcBaseFee(config *params.ChainConfig, parent *types.Header) *big.Int {
+	if !config.IsLondon(parent.Number) {
+		return new(big.Int).SetUint64(params.InitialBaseFee)
+	}
+	target := parent.GasLimit / params.ElasticityMultiplier
+
+	switch {
+	case target == 0, parent.GasUsed == target:
+		return new(big.Int).Set(parent.BaseFee)
+
+	case parent.GasUsed > target:
+		delta := new(big.Int).SetUint64(parent.GasUsed - target)
+		delta.Mul(delta, parent.BaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(target))
+		delta.Div(delta, new(big.Int).SetUint64(params.BaseFeeChangeDenominator))
+
+		return delta.Add(parent.BaseFee, math.BigMax(delta, common.Big1))
+
+	default:
+		delta := new(big.Int).SetUint64(target - parent.GasUsed)
+		delta.Mul(delta, parent.BaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(target))
+		delta.Div(delta, new(big.Int).SetUint64(params.BaseFeeChangeDenominator))
+
+		return math.BigMax(delta.Sub(parent.BaseFee, delta), common.Big0)
+	}
+}
+
+// AccumulateTips credits the priority fees paid by the transactions of a block
+// to the block's signer. After the London fork the state transition burns the
+// base fee and leaves the tip to the consensus engine.
+func AccumulateTips(config *params.ChainConfig, state *state.StateDB, header *types.Header, txs []*types.Transaction, receipts []*types.Receipt, signer common.Address) {
+	if !config.IsLondon(header.Number) {
+		return
+	}
+	fees := new(big.Int)
+	for i, tx := range txs {
+		if i >= len(receipts) {
+			break
+		}
+		tip, err := tx.EffectiveGasTip(header.BaseFee)
+		if err != nil {
+			continue
+		}
+		fees.Add(fees, tip.Mul(tip, new(big.Int).SetUint64(receipts[i].GasUsed)))
+	}
+	if fees.Sign() > 0 {
+		state.AddBalance(signer, fees)
+	}
+}
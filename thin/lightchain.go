@@ -11,6 +11,7 @@ import (
 
 	"github.com/ddmchain/go-ddmchain/general"
 	"github.com/ddmchain/go-ddmchain/rule"
+	"github.com/ddmchain/go-ddmchain/rule/dpos"
 	"github.com/ddmchain/go-ddmchain/major"
 	"github.com/ddmchain/go-ddmchain/major/state"
 	"github.com/ddmchain/go-ddmchain/major/types"
@@ -364,6 +365,9 @@ func (self *LightChain) SyncCht(ctx context.Context) bool {
 	if self.odr.ChtIndexer() == nil {
 		return false
 	}
+	if engine, ok := self.engine.(*dpos.Dpos); ok {
+		return self.syncDposCheckpoint(ctx, engine)
+	}
 	headNum := self.CurrentHeader().Number.Uint64()
 	chtCount, _, _ := self.odr.ChtIndexer().Sections()
 	if headNum+1 < chtCount*CHTFrequencyClient {
@@ -381,6 +385,34 @@ func (self *LightChain) SyncCht(ctx context.Context) bool {
 	return false
 }
 
+func (self *LightChain) syncDposCheckpoint(ctx context.Context, engine *dpos.Dpos) bool {
+	chtCount, _, _ := self.odr.ChtIndexer().Sections()
+	if chtCount == 0 {
+		return false
+	}
+	num := (chtCount*CHTFrequencyClient - 1) / engine.Epoch() * engine.Epoch()
+	if num == 0 || self.CurrentHeader().Number.Uint64() >= num {
+		return false
+	}
+	req, err := GetDposCheckpoint(ctx, self.odr, num)
+	if err != nil {
+		log.Debug("Failed to retrieve dpos checkpoint", "number", num, "err", err)
+		return false
+	}
+	if err := engine.ImportCheckpoint(req.Header, req.Ancestors, req.Snapshot); err != nil {
+		log.Warn("Rejected dpos checkpoint", "number", num, "hash", req.Header.Hash(), "err", err)
+		return false
+	}
+	self.mu.Lock()
+	if self.hc.CurrentHeader().Number.Uint64() < num {
+		self.hc.SetCurrentHeader(req.Header)
+	}
+	self.mu.Unlock()
+
+	log.Info("Imported dpos checkpoint", "number", num, "hash", req.Header.Hash())
+	return true
+}
+
 func (self *LightChain) LockChain() {
 	self.chainmu.RLock()
 }
@@ -110,6 +110,28 @@ func (req *ChtRequest) StoreResult(db ddmdb.Database) {
 	core.WriteCanonicalHash(db, hash, num)
 }
 
+type DposCheckpointRequest struct {
+	OdrRequest
+	ChtNum, BlockNum uint64
+	ChtRoot          common.Hash
+	Header           *types.Header
+	Td               *big.Int
+	Ancestors        []*types.Header
+	Snapshot         []byte
+	Proof            *NodeSet
+}
+
+func (req *DposCheckpointRequest) StoreResult(db ddmdb.Database) {
+	for _, header := range req.Ancestors {
+		core.WriteHeader(db, header)
+		core.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+	}
+	core.WriteHeader(db, req.Header)
+	hash, num := req.Header.Hash(), req.Header.Number.Uint64()
+	core.WriteTd(db, hash, num, req.Td)
+	core.WriteCanonicalHash(db, hash, num)
+}
+
 type BloomRequest struct {
 	OdrRequest
 	BloomTrieNum   uint64
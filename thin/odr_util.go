@@ -26,11 +26,24 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 		return header, nil
 	}
 
+	chtCount, sectionHead := trustedCht(odr)
+	if number >= chtCount*CHTFrequencyClient {
+		return nil, ErrNoTrustedCht
+	}
+	r := &ChtRequest{ChtRoot: GetChtRoot(db, chtCount-1, sectionHead), ChtNum: chtCount - 1, BlockNum: number}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Header, nil
+}
+
+func trustedCht(odr OdrBackend) (uint64, common.Hash) {
 	var (
 		chtCount, sectionHeadNum uint64
 		sectionHead              common.Hash
 	)
 	if odr.ChtIndexer() != nil {
+		db := odr.Database()
 		chtCount, sectionHeadNum, sectionHead = odr.ChtIndexer().Sections()
 		canonicalHash := core.GetCanonicalHash(db, sectionHeadNum)
 
@@ -43,14 +56,19 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 			}
 		}
 	}
+	return chtCount, sectionHead
+}
+
+func GetDposCheckpoint(ctx context.Context, odr OdrBackend, number uint64) (*DposCheckpointRequest, error) {
+	chtCount, sectionHead := trustedCht(odr)
 	if number >= chtCount*CHTFrequencyClient {
 		return nil, ErrNoTrustedCht
 	}
-	r := &ChtRequest{ChtRoot: GetChtRoot(db, chtCount-1, sectionHead), ChtNum: chtCount - 1, BlockNum: number}
+	r := &DposCheckpointRequest{ChtRoot: GetChtRoot(odr.Database(), chtCount-1, sectionHead), ChtNum: chtCount - 1, BlockNum: number}
 	if err := odr.Retrieve(ctx, r); err != nil {
 		return nil, err
 	}
-	return r.Header, nil
+	return r, nil
 }
 
 func GetCanonicalHash(ctx context.Context, odr OdrBackend, number uint64) (common.Hash, error) {
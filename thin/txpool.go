@@ -45,6 +45,7 @@ type TxPool struct {
 	clearIdx     uint64                               
 
 	homestead bool
+	istanbul  bool
 }
 
 type TxRelayBackend interface {
@@ -250,6 +251,7 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	m, r := txc.getLists()
 	pool.relay.NewHead(pool.head, m, r)
 	pool.homestead = pool.config.IsHomestead(head.Number)
+	pool.istanbul = pool.config.IsIstanbul(head.Number)
 	pool.signer = types.MakeSigner(pool.config, head.Number)
 }
 
@@ -299,11 +301,15 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		return core.ErrNegativeValue
 	}
 
+	if tx.GasFeeCap().Cmp(tx.GasTipCap()) < 0 {
+		return core.ErrTipAboveFeeCap
+	}
+
 	if b := currentState.GetBalance(from); b.Cmp(tx.Cost()) < 0 {
 		return core.ErrInsufficientFunds
 	}
 
-	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	gas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,205 @@
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+
+	"github.com/ddmchain/go-ddmchain/general"
+	"github.com/ddmchain/go-ddmchain/black/sha3"
+	"github.com/ddmchain/go-ddmchain/ddmpv"
+	"github.com/ddmchain/go-ddmchain/ptl"
+)
+
+var errStackTrieOrder = errors.New("stack trie keys not in increasing order")
+
+// StackTrie builds a trie from leaves inserted in increasing key order. Every
+// subtrie left behind by an insertion can no longer change, so it is written
+// to the database and only its hash is kept: the memory holds no more than the
+// path to the last leaf, and no node is written that is not in the final trie.
+type StackTrie struct {
+	db     ddmdb.Putter
+	prefix []byte
+	root   node
+	last   []byte
+
+	tmp bytes.Buffer
+	sha hash.Hash
+}
+
+// NewStackTrie creates a trie builder writing its nodes into db. All the keys
+// inserted must have the same length.
+func NewStackTrie(db ddmdb.Putter) *StackTrie {
+	return NewStackTrieAt(db, nil)
+}
+
+// NewStackTrieAt creates a builder of the subtrie found at the nibble path
+// prefix of a larger trie. Every key inserted must start with the prefix, and
+// the root of the subtrie is only written if the larger trie stores it too.
+func NewStackTrieAt(db ddmdb.Putter, prefix []byte) *StackTrie {
+	return &StackTrie{db: db, prefix: prefix, sha: sha3.NewKeccak256()}
+}
+
+// TryUpdate inserts a leaf, which must come after all the ones inserted so far.
+func (t *StackTrie) TryUpdate(key, value []byte) error {
+	if len(value) == 0 {
+		return errors.New("stack trie can't delete")
+	}
+	hex := keybytesToHex(key)
+	if t.last != nil && (len(hex) != len(t.last) || bytes.Compare(hex, t.last) <= 0) {
+		return errStackTrieOrder
+	}
+	if !bytes.HasPrefix(hex, t.prefix) {
+		return errors.New("stack trie key outside of its subtrie")
+	}
+	t.last = hex
+
+	var err error
+	t.root, err = t.insert(t.root, hex[len(t.prefix):], valueNode(common.CopyBytes(value)))
+	return err
+}
+
+func (t *StackTrie) insert(n node, key []byte, value node) (node, error) {
+	switch n := n.(type) {
+	case nil:
+		return &shortNode{Key: key, Val: value}, nil
+
+	case *shortNode:
+		match := prefixLen(key, n.Key)
+		if match == len(n.Key) {
+			child, err := t.insert(n.Val, key[match:], value)
+			if err != nil {
+				return nil, err
+			}
+			n.Val = child
+			return n, nil
+		}
+		// The new leaf branches off to the right, everything left of it is done
+		var done node = &shortNode{Key: n.Key[match+1:], Val: n.Val}
+		if match+1 == len(n.Key) {
+			done = n.Val
+		}
+		done, err := t.finish(done)
+		if err != nil {
+			return nil, err
+		}
+		branch := &fullNode{}
+		branch.Children[n.Key[match]] = done
+		branch.Children[key[match]] = &shortNode{Key: key[match+1:], Val: value}
+		if match == 0 {
+			return branch, nil
+		}
+		return &shortNode{Key: key[:match], Val: branch}, nil
+
+	case *fullNode:
+		for i := 0; i < int(key[0]); i++ {
+			if child := n.Children[i]; child != nil {
+				done, err := t.finish(child)
+				if err != nil {
+					return nil, err
+				}
+				n.Children[i] = done
+			}
+		}
+		child, err := t.insert(n.Children[key[0]], key[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n.Children[key[0]] = child
+		return n, nil
+
+	default:
+		return nil, errStackTrieOrder
+	}
+}
+
+// finish writes out a subtrie no later leaf can change. It returns its hash, or
+// the subtrie itself if it is small enough to be embedded in its parent.
+func (t *StackTrie) finish(n node) (node, error) {
+	hashed, err := t.commit(n, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := hashed.(hashNode); ok {
+		return hashed, nil
+	}
+	return n, nil
+}
+
+// Commit writes out the rest of the trie and returns its root hash. The trie
+// can't be used afterwards.
+func (t *StackTrie) Commit() (common.Hash, error) {
+	if t.root == nil {
+		return emptyRoot, nil
+	}
+	hashed, err := t.commit(t.root, len(t.prefix) == 0)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	t.root = nil
+	if hash, ok := hashed.(hashNode); ok {
+		return common.BytesToHash(hash), nil
+	}
+	return t.hash(), nil
+}
+
+// commit collapses a node and writes it out, unless it's embedded in its parent.
+func (t *StackTrie) commit(n node, force bool) (node, error) {
+	var collapsed node
+	switch n := n.(type) {
+	case hashNode:
+		return n, nil
+
+	case *shortNode:
+		short := &shortNode{Key: hexToCompact(n.Key), Val: n.Val}
+		if _, ok := n.Val.(valueNode); !ok {
+			child, err := t.commit(n.Val, false)
+			if err != nil {
+				return nil, err
+			}
+			short.Val = child
+		}
+		collapsed = short
+
+	case *fullNode:
+		full := &fullNode{}
+		for i := 0; i < 16; i++ {
+			if n.Children[i] == nil {
+				full.Children[i] = valueNode(nil)
+				continue
+			}
+			child, err := t.commit(n.Children[i], false)
+			if err != nil {
+				return nil, err
+			}
+			full.Children[i] = child
+		}
+		full.Children[16] = valueNode(nil)
+		collapsed = full
+
+	default:
+		return n, nil
+	}
+	t.tmp.Reset()
+	if err := rlp.Encode(&t.tmp, collapsed); err != nil {
+		return nil, err
+	}
+	if t.tmp.Len() < 32 && !force {
+		return collapsed, nil
+	}
+	hash := t.hash()
+	if err := t.db.Put(hash[:], common.CopyBytes(t.tmp.Bytes())); err != nil {
+		return nil, err
+	}
+	return hashNode(hash[:]), nil
+}
+
+// hash returns the hash of the node last encoded.
+func (t *StackTrie) hash() common.Hash {
+	var hash common.Hash
+	t.sha.Reset()
+	t.sha.Write(t.tmp.Bytes())
+	t.sha.Sum(hash[:0])
+	return hash
+}
@@ -225,9 +225,28 @@ func (b *SimulatedBackend) EstimateGas(ctx context.Context, call ddmchain.CallMs
 
 func (b *SimulatedBackend) callContract(ctx context.Context, call ddmchain.CallMsg, block *types.Block, statedb *state.StateDB) ([]byte, uint64, bool, error) {
 
+	if baseFee := block.BaseFee(); baseFee != nil && call.GasPrice == nil {
+		if call.GasFeeCap == nil && call.GasTipCap == nil {
+			call.GasPrice, call.GasFeeCap, call.GasTipCap = new(big.Int), new(big.Int), new(big.Int)
+		} else {
+			if call.GasFeeCap == nil {
+				call.GasFeeCap = new(big.Int)
+			}
+			if call.GasTipCap == nil {
+				call.GasTipCap = new(big.Int)
+			}
+			call.GasPrice = math.BigMin(new(big.Int).Add(call.GasTipCap, baseFee), call.GasFeeCap)
+		}
+	}
 	if call.GasPrice == nil {
 		call.GasPrice = big.NewInt(1)
 	}
+	if call.GasFeeCap == nil {
+		call.GasFeeCap = call.GasPrice
+	}
+	if call.GasTipCap == nil {
+		call.GasTipCap = call.GasPrice
+	}
 	if call.Gas == 0 {
 		call.Gas = 50000000
 	}
@@ -242,7 +261,7 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ddmchain.CallM
 
 	evmContext := core.NewEVMContext(msg, block.Header(), b.blockchain, nil)
 
-	vmenv := vm.NewEVM(evmContext, statedb, b.config, vm.Config{})
+	vmenv := vm.NewEVM(evmContext, statedb, b.config, vm.Config{NoBaseFee: true})
 	gaspool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.NewStateTransition(vmenv, msg, gaspool).TransitionDb()
@@ -351,14 +370,17 @@ type callmsg struct {
 	ddmchain.CallMsg
 }
 
-func (m callmsg) From() common.Address { return m.CallMsg.From }
-func (m callmsg) Nonce() uint64        { return 0 }
-func (m callmsg) CheckNonce() bool     { return false }
-func (m callmsg) To() *common.Address  { return m.CallMsg.To }
-func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
-func (m callmsg) Gas() uint64          { return m.CallMsg.Gas }
-func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
-func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+func (m callmsg) From() common.Address         { return m.CallMsg.From }
+func (m callmsg) Nonce() uint64                { return 0 }
+func (m callmsg) CheckNonce() bool             { return false }
+func (m callmsg) To() *common.Address          { return m.CallMsg.To }
+func (m callmsg) GasPrice() *big.Int           { return m.CallMsg.GasPrice }
+func (m callmsg) GasFeeCap() *big.Int          { return m.CallMsg.GasFeeCap }
+func (m callmsg) GasTipCap() *big.Int          { return m.CallMsg.GasTipCap }
+func (m callmsg) Gas() uint64                  { return m.CallMsg.Gas }
+func (m callmsg) Value() *big.Int              { return m.CallMsg.Value }
+func (m callmsg) Data() []byte                 { return m.CallMsg.Data }
+func (m callmsg) AccessList() types.AccessList { return m.CallMsg.AccessList }
 
 type filterBackend struct {
 	db ddmdb.Database
@@ -384,6 +406,12 @@ func (fb *filterBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Su
 		return nil
 	})
 }
+func (fb *filterBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
 func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return fb.bc.SubscribeChainEvent(ch)
 }
@@ -415,6 +415,9 @@ func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID
 	if w.device == nil {
 		return nil, accounts.ErrWalletClosed
 	}
+	if tx.Type() != types.LegacyTxType {
+		return nil, types.ErrTxTypeNotSupported
+	}
 
 	path, ok := w.paths[account.Address]
 	if !ok {